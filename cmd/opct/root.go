@@ -3,6 +3,7 @@ package cmd
 import (
 	"fmt"
 	"os"
+	"strings"
 
 	log "github.com/sirupsen/logrus"
 	logwriter "github.com/sirupsen/logrus/hooks/writer"
@@ -10,11 +11,16 @@ import (
 	"github.com/spf13/cobra"
 	"github.com/spf13/viper"
 	"github.com/vmware-tanzu/sonobuoy/cmd/sonobuoy/app"
+	"k8s.io/apimachinery/pkg/util/validation"
+
+	"github.com/redhat-openshift-ecosystem/provider-certification-tool/pkg"
 
 	"github.com/redhat-openshift-ecosystem/provider-certification-tool/pkg/cmd/adm"
 	"github.com/redhat-openshift-ecosystem/provider-certification-tool/pkg/cmd/get"
 	"github.com/redhat-openshift-ecosystem/provider-certification-tool/pkg/cmd/report"
 	"github.com/redhat-openshift-ecosystem/provider-certification-tool/pkg/destroy"
+	"github.com/redhat-openshift-ecosystem/provider-certification-tool/pkg/pipeline"
+	"github.com/redhat-openshift-ecosystem/provider-certification-tool/pkg/preflight"
 	"github.com/redhat-openshift-ecosystem/provider-certification-tool/pkg/retrieve"
 	"github.com/redhat-openshift-ecosystem/provider-certification-tool/pkg/run"
 	"github.com/redhat-openshift-ecosystem/provider-certification-tool/pkg/status"
@@ -39,6 +45,11 @@ var rootCmd = &cobra.Command{
 		}
 		log.SetLevel(logrusLevel)
 
+		// Validate the namespace of the validation environment
+		if errs := validation.IsDNS1123Label(viper.GetString("namespace")); len(errs) > 0 {
+			log.Fatalf("invalid namespace %q: %s", viper.GetString("namespace"), strings.Join(errs, ", "))
+		}
+
 		// Additional log options
 		log.SetFormatter(&log.TextFormatter{
 			FullTimestamp: true,
@@ -85,13 +96,17 @@ func init() {
 
 	rootCmd.PersistentFlags().String("kubeconfig", "", "kubeconfig for target OpenShift cluster")
 	rootCmd.PersistentFlags().String("log-level", "info", "logging level")
+	rootCmd.PersistentFlags().String("namespace", pkg.CertificationNamespace, "namespace of the validation environment, concurrent environments must use different namespaces. Can be set by the environment variable OPCT_NAMESPACE")
 	initBindFlag("kubeconfig")
 	initBindFlag("log-level")
+	initBindFlag("namespace")
 
 	// Link in child commands
 	rootCmd.AddCommand(destroy.NewCmdDestroy())
 	rootCmd.AddCommand(retrieve.NewCmdRetrieve())
 	rootCmd.AddCommand(run.NewCmdRun())
+	rootCmd.AddCommand(pipeline.NewCmdPipeline())
+	rootCmd.AddCommand(preflight.NewCmdPreflight())
 	rootCmd.AddCommand(status.NewCmdStatus())
 	rootCmd.AddCommand(version.NewCmdVersion())
 	rootCmd.AddCommand(report.NewCmdReport())
@@ -105,5 +120,8 @@ func init() {
 
 // initConfig reads in config file and ENV variables if set.
 func initConfig() {
+	// Variables are prefixed to not be overridden by common variables of the
+	// environment, like NAMESPACE, e.g. OPCT_NAMESPACE.
+	viper.SetEnvPrefix("opct")
 	viper.AutomaticEnv() // read in environment variables that match
 }
@@ -6,6 +6,7 @@ package archive
 import (
 	"fmt"
 	"sort"
+	"strings"
 
 	log "github.com/sirupsen/logrus"
 	v1 "k8s.io/api/core/v1"
@@ -52,3 +53,24 @@ func ParseOpctConfig(cms *v1.ConfigMapList) []*RuntimeInfoItem {
 	}
 	return cmData
 }
+
+// RunProfileKey is the key in the plugins-config ConfigMap storing the
+// effective profile used to launch the run (opct run --config).
+const RunProfileKey = "run-profile"
+
+// RunTagKeyPrefix is the prefix of the keys in the plugins-config ConfigMap
+// storing the user-defined run tags, e.g. tag.customer=acme.
+const RunTagKeyPrefix = "tag."
+
+// ParseRunTags extracts the user-defined run tags from the items parsed by
+// ParseOpctConfig.
+func ParseRunTags(items []*RuntimeInfoItem) map[string]string {
+	tags := make(map[string]string)
+	for _, item := range items {
+		if item.Config != "plugins-config" || !strings.HasPrefix(item.Name, RunTagKeyPrefix) {
+			continue
+		}
+		tags[strings.TrimPrefix(item.Name, RunTagKeyPrefix)] = item.Value
+	}
+	return tags
+}
@@ -121,3 +121,29 @@ func TestParseOpctConfig(t *testing.T) {
 		})
 	}
 }
+
+func TestParseRunTags(t *testing.T) {
+	cms := &v1.ConfigMapList{Items: []v1.ConfigMap{
+		{
+			ObjectMeta: metav1.ObjectMeta{Name: "plugins-config"},
+			Data: map[string]string{
+				"run-mode":     "regular",
+				"tag.customer": "acme",
+				"tag.hw":       "gen3",
+			},
+		},
+		{
+			ObjectMeta: metav1.ObjectMeta{Name: "opct-version"},
+			Data: map[string]string{
+				"tag.ignored": "true",
+			},
+		},
+	}}
+	want := map[string]string{"customer": "acme", "hw": "gen3"}
+	if got := ParseRunTags(ParseOpctConfig(cms)); !reflect.DeepEqual(got, want) {
+		t.Errorf("ParseRunTags() => got: %v, want: %v", got, want)
+	}
+	if got := ParseRunTags(nil); len(got) != 0 {
+		t.Errorf("ParseRunTags() => got: %v, want empty", got)
+	}
+}
@@ -0,0 +1,70 @@
+/*
+Handle items in the file path meta/opct-partial.json
+*/
+package archive
+
+import (
+	"sort"
+	"time"
+)
+
+// PartialInfoFile is the marker of the archive retrieved from a running
+// environment with 'opct retrieve --partial'.
+const PartialInfoFile = "meta/opct-partial.json"
+
+// States of the plugins in the partial archive.
+const (
+	// PartialPluginComplete is the plugin with the results received by the aggregator.
+	PartialPluginComplete = "complete"
+	// PartialPluginInProgress is the plugin with the results collected from the
+	// plugin pod while running, the results are incomplete.
+	PartialPluginInProgress = "in-progress"
+	// PartialPluginMissing is the plugin without results.
+	PartialPluginMissing = "missing"
+)
+
+// PartialInfo describes the archive retrieved before the validation finished.
+type PartialInfo struct {
+	Partial          bool      `json:"partial"`
+	RunID            string    `json:"runId,omitempty"`
+	RetrievedAt      time.Time `json:"retrievedAt"`
+	AggregatorStatus string    `json:"aggregatorStatus,omitempty"`
+
+	// Plugins is the state of the results of each plugin.
+	Plugins map[string]string `json:"plugins"`
+}
+
+// NewPartialInfo creates the partial info of the run.
+func NewPartialInfo(runID string) *PartialInfo {
+	return &PartialInfo{
+		Partial:     true,
+		RunID:       runID,
+		RetrievedAt: time.Now().UTC().Truncate(time.Second),
+		Plugins:     map[string]string{},
+	}
+}
+
+// PluginsByState returns the sorted plugin names in the state.
+func (pi *PartialInfo) PluginsByState(state string) []string {
+	plugins := []string{}
+	if pi == nil {
+		return plugins
+	}
+	for name, st := range pi.Plugins {
+		if st == state {
+			plugins = append(plugins, name)
+		}
+	}
+	sort.Strings(plugins)
+	return plugins
+}
+
+// MissingPlugins returns the plugins without results in the partial archive.
+func (pi *PartialInfo) MissingPlugins() []string {
+	return pi.PluginsByState(PartialPluginMissing)
+}
+
+// InProgressPlugins returns the plugins with incomplete results in the partial archive.
+func (pi *PartialInfo) InProgressPlugins() []string {
+	return pi.PluginsByState(PartialPluginInProgress)
+}
@@ -0,0 +1,24 @@
+package archive
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPartialInfoPluginsByState(t *testing.T) {
+	pi := NewPartialInfo("id")
+	pi.Plugins = map[string]string{
+		"99-openshift-artifacts-collector":   PartialPluginMissing,
+		"20-openshift-conformance-validated": PartialPluginMissing,
+		"10-openshift-kube-conformance":      PartialPluginComplete,
+		"05-openshift-cluster-upgrade":       PartialPluginInProgress,
+	}
+	assert.True(t, pi.Partial)
+	assert.Equal(t, []string{"20-openshift-conformance-validated", "99-openshift-artifacts-collector"}, pi.MissingPlugins())
+	assert.Equal(t, []string{"10-openshift-kube-conformance"}, pi.PluginsByState(PartialPluginComplete))
+	assert.Equal(t, []string{"05-openshift-cluster-upgrade"}, pi.InProgressPlugins())
+
+	var nilInfo *PartialInfo
+	assert.Empty(t, nilInfo.MissingPlugins())
+}
@@ -318,10 +318,16 @@ func (os *OpenShiftSummary) SetPluginResult(in *plugin.OPCTPluginSummary) error
 }
 
 func (os *OpenShiftSummary) GetResultOCPValidated() *plugin.OPCTPluginSummary {
+	if os.PluginResultOCPValidated == nil {
+		return &plugin.OPCTPluginSummary{}
+	}
 	return os.PluginResultOCPValidated
 }
 
 func (os *OpenShiftSummary) GetResultK8SValidated() *plugin.OPCTPluginSummary {
+	if os.PluginResultK8sConformance == nil {
+		return &plugin.OPCTPluginSummary{}
+	}
 	return os.PluginResultK8sConformance
 }
 
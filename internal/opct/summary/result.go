@@ -59,6 +59,10 @@ type ResultSummary struct {
 
 	// BaselineAPI holds the data fetched from the baseline API.
 	BaselineAPI string
+
+	// Partial is set when the archive was retrieved from a running environment
+	// with 'opct retrieve --partial'.
+	Partial *archive.PartialInfo
 }
 
 // HasValidResults checks if the result instance has valid archive to be processed,
@@ -299,8 +303,11 @@ func (rs *ResultSummary) extractAndLoadData() error {
 		pathPluginDefinition10 = "plugins/10-openshift-kube-conformance/definition.json"
 		pathPluginDefinition20 = "plugins/20-openshift-conformance-validated/definition.json"
 
-		pathResourceNSOpctConfigMap = "resources/ns/openshift-provider-certification/core_v1_configmaps.json"
+		// OPCT ConfigMaps are read from the namespace of the validation environment (opct,
+		// by default), the legacy namespace is used to keep compatibility with older archives.
 		pathResourceNsKubeConfigMap = "resources/ns/kube-system/core_v1_configmaps.json"
+		namespaceOpct               = "opct"
+		namespaceOpctLegacy         = "openshift-provider-certification"
 
 		// artifacts collector locations on archive file
 		pathPluginArtifactTestsK8S     = "plugins/99-openshift-artifacts-collector/results/global/artifacts_e2e-tests_openshift-kube-conformance.txt"
@@ -333,11 +340,14 @@ func (rs *ResultSummary) extractAndLoadData() error {
 	ocpCO := configv1.ClusterOperatorList{}
 	ocpCN := configv1.NetworkList{}
 	opctConfigMapList := v1.ConfigMapList{}
+	nsConfigMapLists := map[string]*v1.ConfigMapList{}
+	reNsConfigMaps := regexp.MustCompile(`^resources/ns/([^/]+)/core_v1_configmaps.json$`)
 	kubeSystemConfigMapList := v1.ConfigMapList{}
 	nodes := v1.NodeList{}
 
 	pluginDef10 := SonobuoyPluginDefinition{}
 	pluginDef20 := SonobuoyPluginDefinition{}
+	partialInfo := archive.PartialInfo{}
 
 	if rs.SavePath != "" {
 		log.Debugf("Creating output directory %s...", rs.SavePath)
@@ -377,9 +387,16 @@ func (rs *ResultSummary) extractAndLoadData() error {
 		if err := results.ExtractFileIntoStruct(pathMetaConfig, path, info, &metaConfig); err != nil {
 			return errors.Wrap(err, fmt.Sprintf("extracting file '%s': %v", path, err))
 		}
-		if err := results.ExtractFileIntoStruct(pathResourceNSOpctConfigMap, path, info, &opctConfigMapList); err != nil {
+		if err := results.ExtractFileIntoStruct(archive.PartialInfoFile, path, info, &partialInfo); err != nil {
 			return errors.Wrap(err, fmt.Sprintf("extracting file '%s': %v", path, err))
 		}
+		if match := reNsConfigMaps.FindStringSubmatch(path); match != nil && path != pathResourceNsKubeConfigMap {
+			cms := &v1.ConfigMapList{}
+			if err := results.ExtractFileIntoStruct(path, path, info, cms); err != nil {
+				return errors.Wrap(err, fmt.Sprintf("extracting file '%s': %v", path, err))
+			}
+			nsConfigMapLists[match[1]] = cms
+		}
 		if err := results.ExtractFileIntoStruct(pathResourceNodes, path, info, &nodes); err != nil {
 			return errors.Wrap(err, fmt.Sprintf("extracting file '%s': %v", path, err))
 		}
@@ -477,6 +494,17 @@ func (rs *ResultSummary) extractAndLoadData() error {
 
 	rs.GetSonobuoy().ParseMetaRunlogs(&metaRunLogs)
 	rs.GetSonobuoy().ParseMetaConfig(&metaConfig)
+	if partialInfo.Partial {
+		log.Warnf("The archive %s is partial, retrieved from a running environment, the checks of the plugins without results %v and in progress %v are skipped", rs.Archive, partialInfo.MissingPlugins(), partialInfo.InProgressPlugins())
+		rs.Partial = &partialInfo
+	}
+	// The environment namespace is set by 'opct --namespace', stored in the aggregator config.
+	for _, ns := range []string{metaConfig.Namespace, namespaceOpct, namespaceOpctLegacy} {
+		if cms, ok := nsConfigMapLists[ns]; ok && ns != "" {
+			opctConfigMapList = *cms
+			break
+		}
+	}
 	rs.GetSonobuoy().ParseOpctConfigMap(&opctConfigMapList)
 
 	// TODO the must-gather parser is consuming more resource than expected, need to be
@@ -118,6 +118,22 @@ func (brs *BaselineConfig) ReadReportSummaryIndexFromAPI() (*baselineIndex, erro
 	return index, nil
 }
 
+// FindBaselineByUUID returns the name of the baseline, in the summary index,
+// published with the run UUID, or empty when not found. Baselines published
+// after the last index update are not evaluated.
+func (brs *BaselineConfig) FindBaselineByUUID(uuid string) (string, error) {
+	index, err := brs.ReadReportSummaryIndexFromAPI()
+	if err != nil {
+		return "", err
+	}
+	for _, item := range index.Results {
+		if id, ok := item.Tags["uuid"].(string); ok && id == uuid {
+			return item.Name, nil
+		}
+	}
+	return "", nil
+}
+
 // ReadReportSummaryFromAPI reads the summary report from the external URL.
 func (brs *BaselineConfig) ReadReportSummaryFromAPI(path string) ([]byte, error) {
 	retryClient := retryablehttp.NewClient()
@@ -3,6 +3,7 @@ package baseline
 import (
 	"encoding/json"
 	"fmt"
+	"time"
 
 	log "github.com/sirupsen/logrus"
 )
@@ -68,7 +69,64 @@ func (bd *BaselineData) GetSetupTags() (map[string]interface{}, error) {
 	}
 	fmt.Println(obj["setup"].(map[string]interface{}))
 	tags = obj["setup"].(map[string]interface{})["api"].(map[string]interface{})
+	// User-defined run tags (opct run --tag) are flattened to be used by
+	// search and grouping in the index, without overriding the setup metadata.
+	if userTags, ok := tags["tags"].(map[string]interface{}); ok {
+		delete(tags, "tags")
+		for k, v := range userTags {
+			if _, ok := tags[k]; ok {
+				log.Warnf("BaselineData/GetSetupTags() ignoring run tag %q, conflicting with the setup metadata", k)
+				continue
+			}
+			tags[k] = v
+		}
+	}
 	// tags = obj["setup"].(map[string]interface{})["api"].(map[string]string)
 	// fmt.Println(s)
 	return tags, nil
 }
+
+// baselineRuntime is the subset of the ReportData with the execution timings
+// and the setup metadata.
+type baselineRuntime struct {
+	Summary struct {
+		Runtime struct {
+			Plugins map[string]string `json:"plugins"`
+		} `json:"runtime"`
+	} `json:"summary"`
+	Setup struct {
+		API struct {
+			OpenShiftRelease string `json:"openshiftRelease"`
+			PlatformType     string `json:"platformType"`
+		} `json:"api"`
+	} `json:"setup"`
+}
+
+// GetPluginRuntimes returns the execution time of each plugin, derived from
+// the meta/run.log in the report summary.
+func (bd *BaselineData) GetPluginRuntimes() (map[string]time.Duration, error) {
+	var obj baselineRuntime
+	if err := json.Unmarshal(bd.raw, &obj); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal baseline data: %w", err)
+	}
+	runtimes := make(map[string]time.Duration, len(obj.Summary.Runtime.Plugins))
+	for name, value := range obj.Summary.Runtime.Plugins {
+		d, err := time.ParseDuration(value)
+		if err != nil || d <= 0 {
+			log.Debugf("BaselineData/GetPluginRuntimes() ignoring invalid runtime %q of plugin %s", value, name)
+			continue
+		}
+		runtimes[name] = d
+	}
+	return runtimes, nil
+}
+
+// GetSetupPlatform returns the OpenShift release and the platform type of
+// the report summary.
+func (bd *BaselineData) GetSetupPlatform() (release, platform string, err error) {
+	var obj baselineRuntime
+	if err := json.Unmarshal(bd.raw, &obj); err != nil {
+		return "", "", fmt.Errorf("failed to unmarshal baseline data: %w", err)
+	}
+	return obj.Setup.API.OpenShiftRelease, obj.Setup.API.PlatformType, nil
+}
@@ -19,8 +19,11 @@ import (
 	"github.com/redhat-openshift-ecosystem/provider-certification-tool/internal/opct/plugin"
 	"github.com/redhat-openshift-ecosystem/provider-certification-tool/internal/opct/summary"
 	"github.com/redhat-openshift-ecosystem/provider-certification-tool/internal/openshift/mustgather"
+	"github.com/redhat-openshift-ecosystem/provider-certification-tool/pkg/images"
+	"github.com/redhat-openshift-ecosystem/provider-certification-tool/pkg/runid"
 	log "github.com/sirupsen/logrus"
 	"github.com/vmware-tanzu/sonobuoy/pkg/discovery"
+	"sigs.k8s.io/yaml"
 )
 
 const (
@@ -59,6 +62,58 @@ type ReportResult struct {
 	ErrorCounters    *archive.ErrorCounter    `json:"errorCounters,omitempty"`
 	Runtime          *ReportRuntime           `json:"runtime,omitempty"`
 	Nodes            []*summary.Node          `json:"nodes,omitempty"`
+
+	// SkippedPlugins is the list of plugins intentionally not selected to run.
+	SkippedPlugins []string `json:"skippedPlugins,omitempty"`
+
+	// Partial is set when the results were retrieved from a running
+	// environment, MissingPlugins is the list of plugins without results,
+	// and InProgressPlugins the list of plugins with incomplete results.
+	Partial           bool     `json:"partial,omitempty"`
+	MissingPlugins    []string `json:"missingPlugins,omitempty"`
+	InProgressPlugins []string `json:"inProgressPlugins,omitempty"`
+}
+
+// IsPluginSkipped returns true when the plugin was intentionally not selected
+// to run, differing of the plugin which is missing in the results.
+func (rt *ReportResult) IsPluginSkipped(name string) bool {
+	if rt == nil {
+		return false
+	}
+	for _, p := range rt.SkippedPlugins {
+		if p == name {
+			return true
+		}
+	}
+	return false
+}
+
+// IsPluginMissing returns true when the plugin has no results in the partial
+// archive.
+func (rt *ReportResult) IsPluginMissing(name string) bool {
+	if rt == nil || !rt.Partial {
+		return false
+	}
+	for _, p := range rt.MissingPlugins {
+		if p == name {
+			return true
+		}
+	}
+	return false
+}
+
+// IsPluginInProgress returns true when the plugin was running when the partial
+// archive was retrieved, thus its results are incomplete.
+func (rt *ReportResult) IsPluginInProgress(name string) bool {
+	if rt == nil || !rt.Partial {
+		return false
+	}
+	for _, p := range rt.InProgressPlugins {
+		if p == name {
+			return true
+		}
+	}
+	return false
 }
 
 func (rt *ReportResult) GetPlugins() []string {
@@ -96,6 +151,23 @@ type ReportSummaryRuntime struct {
 type ReportSummaryTests struct {
 	Archive     string `json:"archive"`
 	ArchiveDiff string `json:"archiveDiff,omitempty"`
+
+	// Integrity is the verification of the archive by the integrity manifest.
+	Integrity *ReportIntegrity `json:"integrity,omitempty"`
+}
+
+const (
+	IntegrityVerified    = "verified"
+	IntegrityFailed      = "failed"
+	IntegrityNotVerified = "not-verified"
+)
+
+// ReportIntegrity is the verification of the archive against the integrity
+// manifest written by retrieve.
+type ReportIntegrity struct {
+	Status  string `json:"status"`
+	Message string `json:"message,omitempty"`
+	SHA256  string `json:"sha256,omitempty"`
 }
 
 type ReportSummaryAlerts struct {
@@ -301,6 +373,15 @@ type ReportSetupAPI struct {
 	ProviderName     string `json:"providerName,omitempty"`
 	InfraTopology    string `json:"infraTopology,omitempty"`
 	Workflow         string `json:"workflow,omitempty"`
+
+	// Images are the digests of the images used by the run, when pinned.
+	Images []*images.LockImage `json:"images,omitempty"`
+
+	// Tags are the user-defined tags of the run (opct run --tag).
+	Tags map[string]string `json:"tags,omitempty"`
+
+	// RunProfile is the effective profile, in YAML, used to launch the run.
+	RunProfile string `json:"runProfile,omitempty"`
 }
 
 type ReportRuntime struct {
@@ -383,6 +464,15 @@ func (re *ReportData) Populate(cs *summary.ConsolidatedSummary) error {
 	return nil
 }
 
+// healthPerc returns the percentage of healthy items, zero when the health
+// summary is missing, e.g. in partial archives.
+func healthPerc(healthy, total int) float64 {
+	if total == 0 {
+		return 0
+	}
+	return float64(100 * healthy / total)
+}
+
 // populateSource reads the loaded data, creating a report data for each result
 // data source (provider and/or baseline).
 func (re *ReportData) populateSource(rs *summary.ResultSummary) error {
@@ -395,6 +485,11 @@ func (re *ReportData) populateSource(rs *summary.ResultSummary) error {
 		reResult = re.Provider
 		reResult.MustGatherInfo = rs.MustGather
 	}
+	if rs.Partial != nil {
+		reResult.Partial = true
+		reResult.MissingPlugins = rs.Partial.MissingPlugins()
+		reResult.InProgressPlugins = rs.Partial.InProgressPlugins()
+	}
 	// Version
 	v, err := rs.GetOpenShift().GetClusterVersion()
 	if err != nil {
@@ -449,10 +544,10 @@ func (re *ReportData) populateSource(rs *summary.ResultSummary) error {
 	reResult.ClusterHealth = &ReportClusterHealth{
 		NodeHealthTotal: sbCluster.NodeHealth.Total,
 		NodeHealthy:     sbCluster.NodeHealth.Healthy,
-		NodeHealthPerc:  float64(100 * sbCluster.NodeHealth.Healthy / sbCluster.NodeHealth.Total),
+		NodeHealthPerc:  healthPerc(sbCluster.NodeHealth.Healthy, sbCluster.NodeHealth.Total),
 		PodHealthTotal:  sbCluster.PodHealth.Total,
 		PodHealthy:      sbCluster.PodHealth.Healthy,
-		PodHealthPerc:   float64(100 * sbCluster.PodHealth.Healthy / sbCluster.PodHealth.Total),
+		PodHealthPerc:   healthPerc(sbCluster.PodHealth.Healthy, sbCluster.PodHealth.Total),
 	}
 	for _, dt := range sbCluster.PodHealth.Details {
 		if !dt.Healthy {
@@ -534,14 +629,37 @@ func (re *ReportData) populateSource(rs *summary.ResultSummary) error {
 		ts = strings.Replace(ts, "Z", "", -1)
 		re.Setup.API.SummaryName = fmt.Sprintf("%s_%s_%s.json", re.Setup.API.OpenShiftRelease, re.Setup.API.PlatformType, ts)
 	}
+	// The run ID is set by the CLI when the environment is created, archives
+	// created by older versions are identified by the Sonobuoy aggregator UUID.
 	for i := range reResult.Runtime.ServerConfig {
 		if reResult.Runtime.ServerConfig[i].Name == "UUID" {
 			re.Setup.API.UUID = reResult.Runtime.ServerConfig[i].Value
 		}
 	}
+	if tags := archive.ParseRunTags(reResult.Runtime.OpctConfig); len(tags) > 0 {
+		re.Setup.API.Tags = tags
+	}
 	for i := range reResult.Runtime.OpctConfig {
-		if reResult.Runtime.OpctConfig[i].Name == "run-mode" {
-			re.Setup.API.Workflow = reResult.Runtime.ServerConfig[i].Value
+		switch reResult.Runtime.OpctConfig[i].Name {
+		case "run-mode":
+			re.Setup.API.Workflow = reResult.Runtime.OpctConfig[i].Value
+		case archive.RunProfileKey:
+			re.Setup.API.RunProfile = reResult.Runtime.OpctConfig[i].Value
+		case runid.ConfigMapKey:
+			if reResult.Runtime.OpctConfig[i].Value != "" {
+				re.Setup.API.UUID = reResult.Runtime.OpctConfig[i].Value
+			}
+		case images.LockConfigMapKey:
+			lock := &images.Lock{}
+			if err := yaml.Unmarshal([]byte(reResult.Runtime.OpctConfig[i].Value), lock); err != nil {
+				log.Warnf("unable to parse the image lock: %v", err)
+				continue
+			}
+			re.Setup.API.Images = lock.Images
+		case "plugins-skipped":
+			if reResult.Runtime.OpctConfig[i].Value != "" {
+				reResult.SkippedPlugins = strings.Split(reResult.Runtime.OpctConfig[i].Value, ",")
+			}
 		}
 	}
 	return nil
@@ -92,6 +92,10 @@ type Check struct {
 
 	Test func() CheckResult `json:"-"`
 
+	// Plugin is the plugin evaluated by the check. The check is skipped
+	// when the plugin was intentionally not selected to run.
+	Plugin string `json:"plugin,omitempty"`
+
 	// Priority is the priority to execute the check.
 	// 0 is higher.
 	Priority uint64
@@ -113,6 +117,7 @@ func AcceptanceCheckFail() CheckResultName {
 // CheckSummary aggregates the checks.
 type CheckSummary struct {
 	baseURL string
+	report  *ReportData
 	Checks  []*Check `json:"checks"`
 }
 
@@ -129,6 +134,7 @@ func NewCheckSummary(re *ReportData) *CheckSummary {
 	checkSum := &CheckSummary{
 		Checks:  []*Check{},
 		baseURL: fmt.Sprintf("%s%s", baseURL, docsRulesPath),
+		report:  re,
 	}
 	// Cluster Checks
 	checkSum.Checks = append(checkSum.Checks, &Check{
@@ -167,8 +173,9 @@ func NewCheckSummary(re *ReportData) *CheckSummary {
 	})
 	// Plugins Checks
 	checkSum.Checks = append(checkSum.Checks, &Check{
-		ID:   CheckID001,
-		Name: "Kubernetes Conformance [10-openshift-kube-conformance] must pass 100%",
+		ID:     CheckID001,
+		Name:   "Kubernetes Conformance [10-openshift-kube-conformance] must pass 100%",
+		Plugin: plugin.PluginNameKubernetesConformance,
 		Test: func() CheckResult {
 			res := CheckResult{Name: CheckResultNameFail, Target: "Priority==0|Total!=Failed"}
 			prefix := "Check Failed - " + CheckID001
@@ -193,8 +200,9 @@ func NewCheckSummary(re *ReportData) *CheckSummary {
 		},
 	})
 	checkSum.Checks = append(checkSum.Checks, &Check{
-		ID:   CheckID004,
-		Name: "OpenShift Conformance [20-openshift-conformance-validated]: Pass ratio must be >=98.5%",
+		ID:     CheckID004,
+		Name:   "OpenShift Conformance [20-openshift-conformance-validated]: Pass ratio must be >=98.5%",
+		Plugin: plugin.PluginNameOpenShiftConformance,
 		Test: func() CheckResult {
 			prefix := "Check Failed - " + CheckID004
 			res := CheckResult{
@@ -227,8 +235,9 @@ func NewCheckSummary(re *ReportData) *CheckSummary {
 		},
 	})
 	checkSum.Checks = append(checkSum.Checks, &Check{
-		ID:   CheckID005,
-		Name: "OpenShift Conformance Validation [20]: Filter Priority Requirement >= 99.5%",
+		ID:     CheckID005,
+		Name:   "OpenShift Conformance Validation [20]: Filter Priority Requirement >= 99.5%",
+		Plugin: plugin.PluginNameOpenShiftConformance,
 		Test: func() CheckResult {
 			prefix := "Check Failed - " + CheckID005
 			target := 0.5
@@ -265,8 +274,9 @@ func NewCheckSummary(re *ReportData) *CheckSummary {
 		},
 	})
 	checkSum.Checks = append(checkSum.Checks, &Check{
-		ID:   "OPCT-005B",
-		Name: "OpenShift Conformance Validation [20]: Required to Pass After Filtering",
+		ID:     "OPCT-005B",
+		Name:   "OpenShift Conformance Validation [20]: Required to Pass After Filtering",
+		Plugin: plugin.PluginNameOpenShiftConformance,
 		Test: func() CheckResult {
 			prefix := "Check OPCT-005B Failed"
 			target := 0.50
@@ -441,8 +451,9 @@ func NewCheckSummary(re *ReportData) *CheckSummary {
 		},
 	})
 	checkSum.Checks = append(checkSum.Checks, &Check{
-		ID:   "OPCT-003",
-		Name: "Plugin Collector [99-openshift-artifacts-collector] must pass",
+		ID:     "OPCT-003",
+		Name:   "Plugin Collector [99-openshift-artifacts-collector] must pass",
+		Plugin: plugin.PluginNameArtifactsCollector,
 		Test: func() CheckResult {
 			prefix := "Check OPCT-003 Failed"
 			res := CheckResult{Name: CheckResultNameFail, Target: "passed", Actual: "N/A"}
@@ -465,8 +476,9 @@ func NewCheckSummary(re *ReportData) *CheckSummary {
 		},
 	})
 	checkSum.Checks = append(checkSum.Checks, &Check{
-		ID:   "OPCT-002",
-		Name: "Plugin Conformance Upgrade [05-openshift-cluster-upgrade] must pass",
+		ID:     "OPCT-002",
+		Name:   "Plugin Conformance Upgrade [05-openshift-cluster-upgrade] must pass",
+		Plugin: plugin.PluginNameOpenShiftUpgrade,
 		Test: func() CheckResult {
 			prefix := "Check OPCT-002 Failed"
 			res := CheckResult{Name: CheckResultNameFail, Target: "passed"}
@@ -609,6 +621,9 @@ func NewCheckSummary(re *ReportData) *CheckSummary {
 			}
 			invalidPluginIds := []string{}
 			for _, plugin := range checkPlugins {
+				if re.Provider.IsPluginSkipped(plugin) || re.Provider.IsPluginMissing(plugin) || re.Provider.IsPluginInProgress(plugin) {
+					continue
+				}
 				if _, ok := re.Provider.Plugins[plugin]; !ok {
 					return res
 				}
@@ -633,7 +648,8 @@ func NewCheckSummary(re *ReportData) *CheckSummary {
 	checkSum.Checks = append(checkSum.Checks, &Check{
 		ID: CheckID023A,
 		// Should be greated than 300
-		Name: "Sanity [10-openshift-kube-conformance]: potential missing tests in suite",
+		Name:   "Sanity [10-openshift-kube-conformance]: potential missing tests in suite",
+		Plugin: plugin.PluginNameKubernetesConformance,
 		Test: func() CheckResult {
 			prefix := "Check Failed - " + CheckID023A
 			res := CheckResult{
@@ -658,7 +674,8 @@ func NewCheckSummary(re *ReportData) *CheckSummary {
 	checkSum.Checks = append(checkSum.Checks, &Check{
 		ID: CheckID023B,
 		// Should be greated than 3000
-		Name: "Sanity [20-openshift-conformance-validated]: potential missing tests in suite",
+		Name:   "Sanity [20-openshift-conformance-validated]: potential missing tests in suite",
+		Plugin: plugin.PluginNameOpenShiftConformance,
 		Test: func() CheckResult {
 			prefix := "Check Failed - " + CheckID023B
 			res := CheckResult{
@@ -949,6 +966,33 @@ func (csum *CheckSummary) GetCheckResults() ([]*SLOOutput, []*SLOOutput, []*SLOO
 
 func (csum *CheckSummary) Run() error {
 	for _, check := range csum.Checks {
+		if check.Plugin != "" && csum.report != nil && csum.report.Provider.IsPluginSkipped(check.Plugin) {
+			check.Result = CheckResult{
+				Name:    CheckResultNameSkip,
+				Message: fmt.Sprintf("plugin %s was not selected to run", check.Plugin),
+				Target:  "N/A",
+				Actual:  "skipped",
+			}
+			continue
+		}
+		if check.Plugin != "" && csum.report != nil && csum.report.Provider.IsPluginMissing(check.Plugin) {
+			check.Result = CheckResult{
+				Name:    CheckResultNameSkip,
+				Message: fmt.Sprintf("plugin %s has no results in the partial archive", check.Plugin),
+				Target:  "N/A",
+				Actual:  "missing",
+			}
+			continue
+		}
+		if check.Plugin != "" && csum.report != nil && csum.report.Provider.IsPluginInProgress(check.Plugin) {
+			check.Result = CheckResult{
+				Name:    CheckResultNameSkip,
+				Message: fmt.Sprintf("plugin %s is in progress, the results in the partial archive are incomplete", check.Plugin),
+				Target:  "N/A",
+				Actual:  "in-progress",
+			}
+			continue
+		}
 		check.Result = check.Test()
 	}
 	return nil
@@ -10,6 +10,8 @@ import (
 	"testing"
 
 	"github.com/stretchr/testify/assert"
+
+	"github.com/redhat-openshift-ecosystem/provider-certification-tool/internal/opct/plugin"
 )
 
 func TestNewCheckSummary(t *testing.T) {
@@ -22,3 +24,35 @@ func TestNewCheckSummary(t *testing.T) {
 		assert.Equal(t, true, len(check.Name) <= 88, "Check Name must not be higher than 88 characters: %s", check.Name)
 	}
 }
+
+func TestCheckSummaryRunPartial(t *testing.T) {
+	re := &ReportData{Provider: &ReportResult{
+		Partial:           true,
+		MissingPlugins:    []string{plugin.PluginNameOpenShiftConformance},
+		InProgressPlugins: []string{plugin.PluginNameArtifactsCollector},
+		SkippedPlugins:    []string{plugin.PluginNameOpenShiftUpgrade},
+	}}
+	passed := func() CheckResult { return CheckResult{Name: CheckResultNamePass} }
+	csum := &CheckSummary{report: re, Checks: []*Check{
+		{ID: "OPCT-A", Plugin: plugin.PluginNameKubernetesConformance, Test: passed},
+		{ID: "OPCT-B", Plugin: plugin.PluginNameOpenShiftConformance, Test: passed},
+		{ID: "OPCT-C", Plugin: plugin.PluginNameOpenShiftUpgrade, Test: passed},
+		{ID: "OPCT-D", Test: passed},
+		{ID: "OPCT-E", Plugin: plugin.PluginNameArtifactsCollector, Test: passed},
+	}}
+	assert.NoError(t, csum.Run())
+
+	assert.Equal(t, CheckResultNamePass, csum.Checks[0].Result.Name)
+	assert.Equal(t, CheckResultNameSkip, csum.Checks[1].Result.Name)
+	assert.Equal(t, "plugin 20-openshift-conformance-validated has no results in the partial archive", csum.Checks[1].Result.Message)
+	assert.Equal(t, CheckResultNameSkip, csum.Checks[2].Result.Name)
+	assert.Equal(t, "plugin 05-openshift-cluster-upgrade was not selected to run", csum.Checks[2].Result.Message)
+	assert.Equal(t, CheckResultNamePass, csum.Checks[3].Result.Name)
+	assert.Equal(t, CheckResultNameSkip, csum.Checks[4].Result.Name)
+	assert.Equal(t, "plugin 99-openshift-artifacts-collector is in progress, the results in the partial archive are incomplete", csum.Checks[4].Result.Message)
+
+	// Missing and in progress plugins are ignored in complete archives.
+	re.Provider.Partial = false
+	assert.False(t, re.Provider.IsPluginMissing(plugin.PluginNameOpenShiftConformance))
+	assert.False(t, re.Provider.IsPluginInProgress(plugin.PluginNameArtifactsCollector))
+}
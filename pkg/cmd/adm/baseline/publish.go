@@ -152,9 +152,24 @@ func baselinePublishCmdRun(cmd *cobra.Command, args []string) {
 		log.Errorf("error unmarshalling metadata: %v", err)
 	}
 	log.Infof("Baseline metadata: %v", meta)
+
+	// The run UUID identifies the execution, rejecting results already published.
+	if re.Setup.API.UUID == "" {
+		log.Warn("Baseline without UUID, unable to check if it is already published")
+	} else {
+		name, err := brs.FindBaselineByUUID(re.Setup.API.UUID)
+		if err != nil {
+			log.Fatalf("error checking if the baseline is already published: %v", err)
+		}
+		if name != "" {
+			if !baselinePublishArgs.dryRun {
+				log.Fatalf("baseline rejected, UUID %s is already published as %s", re.Setup.API.UUID, name)
+			}
+			log.Warnf("DRY-RUN mode: UUID %s is already published as %s", re.Setup.API.UUID, name)
+		}
+	}
+
 	log.Infof("Uploading baseline to storage")
-	// TODO: check if the baseline already exists. It should check the unique
-	// id other than the bucket name. The UUID is a good candidate.
 	err = brs.UploadBaseline(archive, saveDirectory, meta, baselinePublishArgs.dryRun)
 	if err != nil {
 		log.Fatalf("error uploading baseline: %v", err)
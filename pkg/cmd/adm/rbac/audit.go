@@ -0,0 +1,177 @@
+package rbac
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+
+	table "github.com/jedib0t/go-pretty/v6/table"
+	log "github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+	rbacv1 "k8s.io/api/rbac/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/yaml"
+
+	efs "github.com/redhat-openshift-ecosystem/provider-certification-tool/internal/assets"
+	"github.com/redhat-openshift-ecosystem/provider-certification-tool/pkg"
+	"github.com/redhat-openshift-ecosystem/provider-certification-tool/pkg/rbac"
+)
+
+type rbacAuditInput struct {
+	profile string
+	rules   string
+	user    string
+	output  string
+}
+
+var rbacAuditArgs rbacAuditInput
+var rbacAuditCmd = &cobra.Command{
+	Use: "audit <must-gather-directory>",
+	Example: `opct adm rbac audit ./must-gather.local --profile privileged --output rules > opct-rbac-rules.yaml
+opct adm rbac audit ./must-gather.local --profile scoped --rules opct-rbac-rules.yaml`,
+	Short: "Compare the RBAC profile with the permissions used in a previous run.",
+	Long: `Compare the permissions granted by the RBAC profile with the API requests
+made by the validation environment, read from the API server audit logs
+collected by the must-gather (oc adm must-gather -- /usr/bin/gather_audit_logs).
+
+The audit reports the permissions used but not granted by the profile, the
+requests denied by the API server, and the rules granted but never used.
+
+The scoped profile is audited with the rules shipped for every embedded
+plugin, extended by --rules.
+
+The output 'rules' prints the ClusterRole granting only the permissions used,
+which is the input of 'opct run --rbac-profile=scoped --rbac-rules'.`,
+	Args: cobra.ExactArgs(1),
+	RunE: rbacAuditRun,
+}
+
+func init() {
+	rbacAuditCmd.Flags().StringVar(&rbacAuditArgs.profile, "profile", rbac.ProfileScoped, "RBAC profile to be audited. Available: privileged, scoped")
+	rbacAuditCmd.Flags().StringVar(&rbacAuditArgs.rules, "rules", "", "ClusterRole with the plugin rules granted by the scoped profile in addition to the rules shipped for the plugins, created with '--output rules'.")
+	rbacAuditCmd.Flags().StringVar(&rbacAuditArgs.user, "user", "", "User of the validation environment in the audit logs. Default: the service account of the validation environment namespace.")
+	rbacAuditCmd.Flags().StringVarP(&rbacAuditArgs.output, "output", "o", "table", "Output format. Available: table, json, rules")
+}
+
+func rbacAuditRun(cmd *cobra.Command, args []string) error {
+	if err := rbac.ValidateProfile(rbacAuditArgs.profile); err != nil {
+		return err
+	}
+	switch rbacAuditArgs.output {
+	case "table", "json", "rules":
+	default:
+		return fmt.Errorf("invalid output format %q, allowed values: table, json, rules", rbacAuditArgs.output)
+	}
+	if rbacAuditArgs.user == "" {
+		rbacAuditArgs.user = fmt.Sprintf("system:serviceaccount:%s:%s", pkg.GetNamespace(), pkg.SonobuoyServiceAccountName)
+	}
+	rules := rbac.PrivilegedRules()
+	if rbacAuditArgs.profile == rbac.ProfileScoped && rbacAuditArgs.output != "rules" {
+		pluginRules, _, err := rbac.DefaultPluginRules(efs.GetData(), nil)
+		if err != nil {
+			return err
+		}
+		if rbacAuditArgs.rules != "" {
+			fileRules, err := rbac.ReadRules(rbacAuditArgs.rules)
+			if err != nil {
+				return err
+			}
+			pluginRules = append(pluginRules, fileRules...)
+		}
+		if rules, err = rbac.ScopedRules(pluginRules); err != nil {
+			return err
+		}
+	}
+
+	// Logs are written to stdout, only the table output is mixed with them.
+	auditor := rbac.NewAuditor(rbacAuditArgs.user)
+	if rbacAuditArgs.output == "table" {
+		log.Infof("Reading audit logs from %s...", args[0])
+	}
+	if err := auditor.ReadMustGather(args[0]); err != nil {
+		return err
+	}
+	report := auditor.Audit(rules)
+
+	if rbacAuditArgs.output == "rules" {
+		if report.Events == 0 {
+			return fmt.Errorf("no audit events found for the user %s", rbacAuditArgs.user)
+		}
+		return printRules(cmd, report)
+	}
+	if rbacAuditArgs.output == "json" {
+		data, err := json.MarshalIndent(report, "", "  ")
+		if err != nil {
+			return err
+		}
+		fmt.Fprintln(cmd.OutOrStdout(), string(data))
+		return nil
+	}
+	if report.Events == 0 {
+		log.Warnf("No audit events found for the user %s", rbacAuditArgs.user)
+	}
+	printAuditReport(report)
+	return nil
+}
+
+func printAuditReport(report *rbac.AuditReport) {
+	printUsage := func(title string, usage []*rbac.Usage) {
+		tb := table.NewWriter()
+		tb.SetOutputMirror(os.Stdout)
+		tb.SetTitle(fmt.Sprintf("%s (%d)", title, len(usage)))
+		tb.AppendHeader(table.Row{"Verb", "API Group", "Resource / URL", "Requests"})
+		for _, u := range usage {
+			resource := u.Resource
+			if u.NonResourceURL != "" {
+				resource = u.NonResourceURL
+			}
+			tb.AppendRow(table.Row{u.Verb, u.APIGroup, resource, u.Count})
+		}
+		tb.Render()
+	}
+	printUsage("Used but not granted", report.NotGranted)
+	printUsage("Denied by the API server", report.Forbidden)
+
+	tb := table.NewWriter()
+	tb.SetOutputMirror(os.Stdout)
+	tb.SetTitle(fmt.Sprintf("Granted but unused (%d)", len(report.Unused)))
+	tb.AppendHeader(table.Row{"Verbs", "API Groups", "Resources / URLs"})
+	for _, rule := range report.Unused {
+		tb.AppendRow(table.Row{strings.Join(rule.Verbs, ","), strings.Join(rule.APIGroups, ","), ruleResources(&rule)})
+	}
+	tb.Render()
+	fmt.Printf("\nAudit events: %d, permissions used: %d\n", report.Events, len(report.Used))
+}
+
+// printRules prints the ClusterRole granting the permissions used, without
+// the requests denied by the API server.
+func printRules(cmd *cobra.Command, report *rbac.AuditReport) error {
+	cr := rbacv1.ClusterRole{
+		TypeMeta: metav1.TypeMeta{
+			APIVersion: rbacv1.SchemeGroupVersion.String(),
+			Kind:       "ClusterRole",
+		},
+		ObjectMeta: metav1.ObjectMeta{
+			Name: pkg.GetPrivilegedClusterRole(),
+		},
+		Rules: rbac.RulesFromUsage(report.Used),
+	}
+	data, err := yaml.Marshal(cr)
+	if err != nil {
+		return err
+	}
+	fmt.Fprint(cmd.OutOrStdout(), string(data))
+	return nil
+}
+
+func ruleResources(rule *rbacv1.PolicyRule) string {
+	if len(rule.NonResourceURLs) > 0 {
+		return strings.Join(rule.NonResourceURLs, ",")
+	}
+	resources := strings.Join(rule.Resources, ",")
+	if len(rule.ResourceNames) > 0 {
+		resources += fmt.Sprintf(" (%s)", strings.Join(rule.ResourceNames, ","))
+	}
+	return resources
+}
@@ -0,0 +1,26 @@
+package rbac
+
+import (
+	log "github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+)
+
+var rbacCmd = &cobra.Command{
+	Use:   "rbac",
+	Short: "Administrative commands to review the RBAC of the validation environment.",
+	Run: func(cmd *cobra.Command, args []string) {
+		if len(args) == 0 {
+			if err := cmd.Help(); err != nil {
+				log.Errorf("error loading help(): %v", err)
+			}
+		}
+	},
+}
+
+func init() {
+	rbacCmd.AddCommand(rbacAuditCmd)
+}
+
+func NewCmdRBAC() *cobra.Command {
+	return rbacCmd
+}
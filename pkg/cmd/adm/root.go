@@ -2,6 +2,7 @@ package adm
 
 import (
 	"github.com/redhat-openshift-ecosystem/provider-certification-tool/pkg/cmd/adm/baseline"
+	"github.com/redhat-openshift-ecosystem/provider-certification-tool/pkg/cmd/adm/rbac"
 
 	log "github.com/sirupsen/logrus"
 	"github.com/spf13/cobra"
@@ -24,6 +25,7 @@ func init() {
 	admCmd.AddCommand(parseEtcdLogsCmd)
 	admCmd.AddCommand(baseline.NewCmdBaseline())
 	admCmd.AddCommand(setupNodeCmd)
+	admCmd.AddCommand(rbac.NewCmdRBAC())
 }
 
 func NewCmdAdm() *cobra.Command {
@@ -3,8 +3,12 @@ package adm
 import (
 	"context"
 	"fmt"
+	"strings"
 
+	mcfgclientset "github.com/openshift/client-go/machineconfiguration/clientset/versioned"
+	"github.com/redhat-openshift-ecosystem/provider-certification-tool/pkg"
 	"github.com/redhat-openshift-ecosystem/provider-certification-tool/pkg/client"
+	"github.com/redhat-openshift-ecosystem/provider-certification-tool/pkg/upgrade"
 	log "github.com/sirupsen/logrus"
 	"github.com/spf13/cobra"
 	v1 "k8s.io/api/core/v1"
@@ -13,35 +17,41 @@ import (
 )
 
 type setupNodeInput struct {
-	nodeName string
-	yes      bool
+	nodeNames   []string
+	count       int
+	yes         bool
+	upgradePool bool
 }
 
 var setupNodeArgs setupNodeInput
 var setupNodeCmd = &cobra.Command{
 	Use:     "setup-node",
-	Example: "opct adm setup-node",
+	Example: "opct adm setup-node\n  opct adm setup-node --count 2\n  opct adm setup-node --node worker-0,worker-1",
 	Short:   "Setup the node for the validation process.",
 	Run:     setupNodeRun,
 }
 
 func init() {
 	setupNodeCmd.Flags().BoolVarP(&setupNodeArgs.yes, "yes", "y", false, "Node to set required label and taints")
-	setupNodeCmd.Flags().StringVar(&setupNodeArgs.nodeName, "node", "", "Node to set required label and taints")
+	setupNodeCmd.Flags().StringSliceVar(&setupNodeArgs.nodeNames, "node", nil, "Nodes to set required label and taints. Comma-separated list or repeated flag.")
+	setupNodeCmd.Flags().IntVar(&setupNodeArgs.count, "count", 1, "Number of nodes to be discovered when --node is not set.")
+	setupNodeCmd.Flags().BoolVar(&setupNodeArgs.upgradePool, "upgrade-pool", false, "Create the MachineConfigPool required by the upgrade mode, pausing it once the dedicated nodes are updated.")
 }
 
-func discoverNode(clientset kubernetes.Interface) (string, error) {
+// discoverNodes returns the worker nodes to be used by the validation process,
+// preferring the nodes not running Prometheus.
+func discoverNodes(clientset kubernetes.Interface, count int) ([]string, error) {
 	// list all pods with label prometheus=k8s in namespace openshift-monitoring
 	pods, err := clientset.CoreV1().Pods("openshift-monitoring").List(context.TODO(), metav1.ListOptions{
 		LabelSelector: "prometheus=k8s",
 	})
 	if err != nil {
-		log.Fatalf("Failed to list Prometheus pods on namespace openshift-monitoring: %v", err)
+		return nil, fmt.Errorf("failed to list Prometheus pods on namespace openshift-monitoring: %w", err)
 	}
 
 	// get the node running on those pods
 	if len(pods.Items) < 1 {
-		log.Fatalf("Expected at least 1 Prometheus pod, got %d. Use --name to manually set the node.", len(pods.Items))
+		return nil, fmt.Errorf("expected at least 1 Prometheus pod, got %d. Use --node to manually set the nodes", len(pods.Items))
 	}
 	nodesRunningPrometheus := map[string]struct{}{}
 	for _, pod := range pods.Items {
@@ -54,16 +64,60 @@ func discoverNode(clientset kubernetes.Interface) (string, error) {
 		LabelSelector: "node-role.kubernetes.io/worker=",
 	})
 	if err != nil {
-		log.Fatalf("Failed to list nodes: %v", err)
+		return nil, fmt.Errorf("failed to list nodes: %w", err)
+	}
+	if len(nodes.Items) < count {
+		return nil, fmt.Errorf("expected at least %d worker nodes, got %d", count, len(nodes.Items))
+	}
+	selected := []string{}
+	for _, node := range nodes.Items {
+		if _, ok := nodesRunningPrometheus[node.Name]; !ok && len(selected) < count {
+			selected = append(selected, node.Name)
+		}
 	}
 	for _, node := range nodes.Items {
-		if _, ok := nodesRunningPrometheus[node.Name]; !ok {
-			return node.Name, nil
+		if len(selected) == count {
+			break
+		}
+		if _, ok := nodesRunningPrometheus[node.Name]; ok {
+			log.Warnf("No node available to run the validation process, using %s", node.Name)
+			selected = append(selected, node.Name)
 		}
 	}
-	forceNode := nodes.Items[0].Name
-	log.Warnf("No node available to run the validation process, using %s", forceNode)
-	return forceNode, nil
+	return selected, nil
+}
+
+// setupNode sets the label and the taint required by the dedicated node,
+// keeping the existing taint.
+func setupNode(clientset kubernetes.Interface, name string) error {
+	node, err := clientset.CoreV1().Nodes().Get(context.TODO(), name, metav1.GetOptions{})
+	if err != nil {
+		return fmt.Errorf("failed to get node %s: %w", name, err)
+	}
+	if node.ObjectMeta.Labels == nil {
+		node.ObjectMeta.Labels = map[string]string{}
+	}
+	node.ObjectMeta.Labels[pkg.DedicatedNodeRoleLabel] = ""
+
+	hasTaint := false
+	for _, taint := range node.Spec.Taints {
+		if taint.Key == pkg.DedicatedNodeRoleLabel && taint.Effect == v1.TaintEffectNoSchedule {
+			hasTaint = true
+			break
+		}
+	}
+	if !hasTaint {
+		node.Spec.Taints = append(node.Spec.Taints, v1.Taint{
+			Key:    pkg.DedicatedNodeRoleLabel,
+			Value:  "",
+			Effect: v1.TaintEffectNoSchedule,
+		})
+	}
+	_, err = clientset.CoreV1().Nodes().Update(context.TODO(), node, metav1.UpdateOptions{})
+	if err != nil {
+		return fmt.Errorf("failed to update node %s: %w", name, err)
+	}
+	return nil
 }
 
 func setupNodeRun(cmd *cobra.Command, args []string) {
@@ -72,22 +126,21 @@ func setupNodeRun(cmd *cobra.Command, args []string) {
 		log.Fatalf("Failed to create Kubernetes client: %v", err)
 	}
 
-	if setupNodeArgs.nodeName == "" {
-		setupNodeArgs.nodeName, err = discoverNode(kclient)
+	if len(setupNodeArgs.nodeNames) == 0 {
+		if setupNodeArgs.count < 1 {
+			log.Fatalf("Invalid --count %d, at least one node must be set", setupNodeArgs.count)
+		}
+		setupNodeArgs.nodeNames, err = discoverNodes(kclient, setupNodeArgs.count)
 		if err != nil {
-			log.Fatalf("Failed to discover node: %v", err)
+			log.Fatalf("Failed to discover nodes: %v", err)
 		}
 	}
-	log.Infof("Setting up node %s...", setupNodeArgs.nodeName)
-
-	node, err := kclient.CoreV1().Nodes().Get(context.TODO(), setupNodeArgs.nodeName, metav1.GetOptions{})
-	if err != nil {
-		log.Fatalf("Failed to get node %s: %v", setupNodeArgs.nodeName, err)
-	}
+	nodes := strings.Join(setupNodeArgs.nodeNames, ", ")
+	log.Infof("Setting up nodes %s...", nodes)
 
 	// Ask if the user wants to proceed with applying changes to the node
 	if !setupNodeArgs.yes {
-		fmt.Printf("Are you sure you want to apply changes to node %s? (y/n): ", setupNodeArgs.nodeName)
+		fmt.Printf("Are you sure you want to apply changes to nodes %s? (y/n): ", nodes)
 		var response string
 		_, err := fmt.Scanln(&response)
 		if err != nil {
@@ -99,16 +152,28 @@ func setupNodeRun(cmd *cobra.Command, args []string) {
 		}
 	}
 
-	// Create the labels map
-	node.ObjectMeta.Labels["node-role.kubernetes.io/tests"] = ""
-	node.Spec.Taints = append(node.Spec.Taints, v1.Taint{
-		Key:    "node-role.kubernetes.io/tests",
-		Value:  "",
-		Effect: v1.TaintEffectNoSchedule,
-	})
-	// Update the node labels
-	_, err = kclient.CoreV1().Nodes().Update(context.TODO(), node, metav1.UpdateOptions{})
+	for _, name := range setupNodeArgs.nodeNames {
+		if err := setupNode(kclient, name); err != nil {
+			log.Fatalf("Failed to setup node: %v", err)
+		}
+		log.Infof("Node %s is ready to run the validation process", name)
+	}
+
+	if !setupNodeArgs.upgradePool {
+		return
+	}
+	restConfig, err := client.CreateRestConfig()
 	if err != nil {
-		log.Fatalf("Failed to update node labels: %v", err)
+		log.Fatalf("Failed to create Kubernetes client config: %v", err)
+	}
+	mcClient, err := mcfgclientset.NewForConfig(restConfig)
+	if err != nil {
+		log.Fatalf("Failed to create MachineConfiguration client: %v", err)
+	}
+	if _, err := upgrade.EnsureMachineConfigPool(context.TODO(), mcClient); err != nil {
+		log.Fatalf("Failed to setup MachineConfigPool: %v", err)
+	}
+	if err := upgrade.WaitForMachineConfigPool(context.TODO(), mcClient, upgrade.DefaultMachineConfigPoolWaitTimeout); err != nil {
+		log.Fatalf("Failed to wait for MachineConfigPool: %v", err)
 	}
 }
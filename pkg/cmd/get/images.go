@@ -1,14 +1,58 @@
 package get
 
 import (
+	"context"
 	"fmt"
+	"io"
+	"os"
+	"path"
+	"sort"
+	"strings"
 
+	configv1 "github.com/openshift/api/config/v1"
+	operatorv1alpha1 "github.com/openshift/api/operator/v1alpha1"
 	"github.com/redhat-openshift-ecosystem/provider-certification-tool/pkg"
+	imgs "github.com/redhat-openshift-ecosystem/provider-certification-tool/pkg/images"
+	log "github.com/sirupsen/logrus"
 	"github.com/spf13/cobra"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/yaml"
+)
+
+const (
+	imagesFormatList     = "list"
+	imagesFormatImageSet = "imageset"
+	imagesFormatIDMS     = "idms"
+	imagesFormatICSP     = "icsp"
+	imagesFormatSkopeo   = "skopeo"
+	imagesFormatLock     = "lock"
+
+	// imageMirrorSetName is the name of the objects created to mirror the images.
+	imageMirrorSetName = "opct"
 )
 
 type imageOptions struct {
-	ToRepository string
+	ToRepository        string
+	Format              string
+	OpenShiftTestsImage string
+}
+
+// image is an image used by OPCT, and the name of the image in the mirror
+// repository.
+type image struct {
+	// Source is the image, repository and tag.
+	Source string
+
+	// Mirror is the path of the image in the mirror repository. The images
+	// are mirrored to the same layout expected by 'opct run --image-repository'.
+	Mirror string
+
+	// TestImage is set to the e2e images renamed in the mirror to the name
+	// expected by openshift-tests, which pulls them from the mirror by the
+	// new name. The mirror sets redirect the pulls of the source image
+	// keeping the tag, and skopeo sync keeps the name of the repository,
+	// thus the e2e images are not part of these formats.
+	TestImage bool
 }
 
 var options *imageOptions
@@ -16,54 +60,289 @@ var options *imageOptions
 var imagesCmd = &cobra.Command{
 	Use:   "images",
 	Short: "Print images used by OPCT.",
-	Run:   runGetImages,
+	Long: `Print images used by OPCT.
+
+The format flag generates the configuration to mirror the images to a disconnected registry:
+  list:     image names, or 'source mirror' mapping when --to-repository is set (oc image mirror -f).
+  imageset: oc-mirror ImageSetConfiguration.
+  idms:     ImageDigestMirrorSet and ImageTagMirrorSet for the --to-repository mirror.
+  icsp:     ImageContentSourcePolicy for the --to-repository mirror (OpenShift 4.12 and older).
+  skopeo:   skopeo sync YAML source (skopeo sync --src yaml --dest docker <file> <mirror>).
+  lock:     image digests resolved from the registries, used by 'opct run --image-lock-file'.
+
+The images are mirrored to the layout expected by 'opct run --image-repository',
+<mirror>/<name>:<tag>. The e2e images renamed in the mirror are not supported by
+the skopeo, idms and icsp formats, mirror them with the list format.`,
+	Example: `  opct get images --to-repository registry.example.io:5000/opct
+  opct get images --format imageset > imageset-config.yaml
+  opct get images --format idms --to-repository registry.example.io:5000/opct | oc create -f -`,
+	RunE: runGetImages,
 }
 
 func init() {
 	options = new(imageOptions)
 	imagesCmd.Flags().StringVar(&options.ToRepository, "to-repository", "", "Show images with format to mirror to repository. Example: registry.example.io:5000")
+	imagesCmd.Flags().StringVar(&options.Format, "format", imagesFormatList, "Output format. Available: list, imageset, idms, icsp, skopeo, lock")
+	imagesCmd.Flags().StringVar(&options.OpenShiftTestsImage, "openshift-tests-image", pkg.OpenShiftTestsImage, "openshift-tests image used by the plugins. The default image is served by the cluster from the release payload, thus not mirrored.")
+}
+
+// getImages returns the images used by OPCT.
+func getImages(opts *imageOptions) []image {
+	images := []image{
+		// Sonobuoy
+		{Source: fmt.Sprintf("%s/%s", pkg.DefaultToolsRepository, pkg.SonobuoyImage), Mirror: pkg.SonobuoyImage},
+		// Plugins
+		{Source: fmt.Sprintf("%s/%s", pkg.DefaultToolsRepository, pkg.PluginsImage), Mirror: pkg.PluginsImage},
+		{Source: fmt.Sprintf("%s/%s", pkg.DefaultToolsRepository, pkg.CollectorImage), Mirror: pkg.CollectorImage},
+		{Source: fmt.Sprintf("%s/%s", pkg.DefaultToolsRepository, pkg.MustGatherMonitoringImage), Mirror: pkg.MustGatherMonitoringImage},
+		// etcdfio
+		{Source: "quay.io/openshift-scale/etcd-perf:latest", Mirror: "etcd-perf:latest"},
+		// test's specific images (not related with OPCT), mirrored with the
+		// name expected by openshift-tests.
+		{Source: "registry.k8s.io/pause:3.8", Mirror: "ocp-cert:e2e-28-registry-k8s-io-pause-3-8-aP7uYsw5XCmoDy5W", TestImage: true},
+	}
+
+	// The openshift-tests image served by the internal registry is part of
+	// the release payload, mirrored with the platform images.
+	if opts.OpenShiftTestsImage != "" && !strings.HasPrefix(opts.OpenShiftTestsImage, "image-registry.openshift-image-registry.svc") {
+		name := opts.OpenShiftTestsImage[strings.LastIndex(opts.OpenShiftTestsImage, "/")+1:]
+		images = append(images, image{Source: opts.OpenShiftTestsImage, Mirror: name})
+	}
+	return images
+}
+
+// splitImage returns the repository and the tag, or digest, of the image.
+func splitImage(img string) (string, string) {
+	if idx := strings.Index(img, "@"); idx > 0 {
+		return img[:idx], img[idx+1:]
+	}
+	if idx := strings.LastIndex(img, ":"); idx > strings.LastIndex(img, "/") {
+		return img[:idx], img[idx+1:]
+	}
+	return img, "latest"
+}
+
+func runGetImages(cmd *cobra.Command, args []string) error {
+	return writeImages(os.Stdout, options)
+}
+
+// splitMirroredImages returns the images mirrored by the tools keeping the
+// name of the repository, and the images renamed in the mirror.
+func splitMirroredImages(images []image) ([]image, []image) {
+	kept, renamed := []image{}, []image{}
+	for _, img := range images {
+		repo, tag := splitImage(img.Source)
+		if img.TestImage || img.Mirror != fmt.Sprintf("%s:%s", path.Base(repo), tag) {
+			renamed = append(renamed, img)
+			continue
+		}
+		kept = append(kept, img)
+	}
+	return kept, renamed
+}
+
+// writeRenamedImages comments the images not supported by the format.
+func writeRenamedImages(w io.Writer, format string, images []image) {
+	if len(images) == 0 {
+		return
+	}
+	fmt.Fprintf(w, "# The images below are renamed in the mirror, not supported by the %s format.\n", format)
+	fmt.Fprintf(w, "# Mirror them with 'opct get images --to-repository <mirror>' and 'oc image mirror -f':\n")
+	for _, img := range images {
+		fmt.Fprintf(w, "#   %s <mirror>/%s\n", img.Source, img.Mirror)
+	}
 }
 
-func generateImage(repo, name string) string {
-	if options.ToRepository == "" {
-		return fmt.Sprintf("%s/%s", repo, name)
-	} else {
-		from := fmt.Sprintf("%s/%s", repo, name)
-		to := fmt.Sprintf("%s/%s", options.ToRepository, name)
-		return fmt.Sprintf("%s %s", from, to)
+func writeImages(w io.Writer, opts *imageOptions) error {
+	images := getImages(opts)
+	switch opts.Format {
+	case imagesFormatList:
+		for _, img := range images {
+			if opts.ToRepository == "" {
+				fmt.Fprintln(w, img.Source)
+				continue
+			}
+			fmt.Fprintf(w, "%s %s/%s\n", img.Source, opts.ToRepository, img.Mirror)
+		}
+		return nil
+	case imagesFormatImageSet:
+		return writeYAML(w, newImageSetConfiguration(images))
+	case imagesFormatSkopeo:
+		kept, renamed := splitMirroredImages(images)
+		writeRenamedImages(w, opts.Format, renamed)
+		return writeYAML(w, newSkopeoSync(kept))
+	case imagesFormatLock:
+		// Only the images used by 'opct run' are pinned.
+		toolImages := []image{}
+		for _, img := range images {
+			if !img.TestImage {
+				toolImages = append(toolImages, img)
+			}
+		}
+		lock, err := newImageLock(context.TODO(), imgs.NewRegistryResolver(), toolImages)
+		if err != nil {
+			return err
+		}
+		return writeYAML(w, lock)
+	case imagesFormatIDMS, imagesFormatICSP:
+		if opts.ToRepository == "" {
+			return fmt.Errorf("--to-repository must be set with --format=%s", opts.Format)
+		}
+		kept, renamed := splitMirroredImages(images)
+		writeRenamedImages(w, opts.Format, renamed)
+		mirrors := newRepositoryMirrors(kept, opts.ToRepository)
+		if opts.Format == imagesFormatICSP {
+			return writeYAML(w, newImageContentSourcePolicy(mirrors))
+		}
+		log.Debugf("ImageDigestMirrorSet applies to images pulled by digest, ImageTagMirrorSet is created to the images pulled by tag")
+		if err := writeYAML(w, newImageDigestMirrorSet(mirrors)); err != nil {
+			return err
+		}
+		return writeYAML(w, newImageTagMirrorSet(mirrors))
 	}
+	return fmt.Errorf("invalid format %q, allowed values: %s", opts.Format,
+		strings.Join([]string{imagesFormatList, imagesFormatImageSet, imagesFormatIDMS, imagesFormatICSP, imagesFormatSkopeo, imagesFormatLock}, ", "))
 }
 
-func runGetImages(cmd *cobra.Command, args []string) {
-	images := []string{}
+// newImageLock resolves the digest of the images.
+func newImageLock(ctx context.Context, resolver imgs.Resolver, images []image) (*imgs.Lock, error) {
+	lock := imgs.NewLock()
+	for _, img := range images {
+		digest, err := resolver.Resolve(ctx, img.Source)
+		if err != nil {
+			return nil, err
+		}
+		lock.Add(img.Source, digest)
+	}
+	return lock, nil
+}
 
-	// Sonobuoy
-	images = append(images, generateImage(pkg.DefaultToolsRepository, pkg.SonobuoyImage))
+func writeYAML(w io.Writer, obj interface{}) error {
+	data, err := yaml.Marshal(obj)
+	if err != nil {
+		return err
+	}
+	_, err = fmt.Fprintf(w, "---\n%s", data)
+	return err
+}
 
-	// Plugins
-	images = append(images, generateImage(pkg.DefaultToolsRepository, pkg.PluginsImage))
-	images = append(images, generateImage(pkg.DefaultToolsRepository, pkg.CollectorImage))
-	images = append(images, generateImage(pkg.DefaultToolsRepository, pkg.MustGatherMonitoringImage))
+// imageSetConfiguration is the oc-mirror (v2) configuration to mirror
+// additional images.
+type imageSetConfiguration struct {
+	Kind       string `json:"kind"`
+	APIVersion string `json:"apiVersion"`
+	Mirror     struct {
+		AdditionalImages []imageSetImage `json:"additionalImages"`
+	} `json:"mirror"`
+}
+
+// imageSetImage is an additional image. oc-mirror keeps the path of the
+// source repository unless the target repository and tag are set.
+type imageSetImage struct {
+	Name       string `json:"name"`
+	TargetRepo string `json:"targetRepo,omitempty"`
+	TargetTag  string `json:"targetTag,omitempty"`
+}
 
-	// etcdfio
-	img_etcdfio := "quay.io/openshift-scale/etcd-perf:latest"
-	if options.ToRepository == "" {
-		images = append(images, img_etcdfio)
-	} else {
-		to := fmt.Sprintf("%s/%s", options.ToRepository, "etcd-perf:latest")
-		images = append(images, fmt.Sprintf("%s %s", img_etcdfio, to))
+func newImageSetConfiguration(images []image) *imageSetConfiguration {
+	cfg := &imageSetConfiguration{
+		Kind:       "ImageSetConfiguration",
+		APIVersion: "mirror.openshift.io/v2alpha1",
 	}
+	for _, img := range images {
+		repo, tag := splitImage(img.Mirror)
+		cfg.Mirror.AdditionalImages = append(cfg.Mirror.AdditionalImages, imageSetImage{
+			Name:       img.Source,
+			TargetRepo: repo,
+			TargetTag:  tag,
+		})
+	}
+	return cfg
+}
+
+// skopeoRegistry is a registry in the skopeo sync YAML source.
+type skopeoRegistry struct {
+	Images map[string][]string `json:"images"`
+}
 
-	// test's specific images (not related with OPCT)
-	img_e2epause := "registry.k8s.io/pause:3.8"
-	if options.ToRepository == "" {
-		images = append(images, img_e2epause)
-	} else {
-		to := fmt.Sprintf("%s/%s", options.ToRepository, "ocp-cert:e2e-28-registry-k8s-io-pause-3-8-aP7uYsw5XCmoDy5W")
-		images = append(images, fmt.Sprintf("%s %s", img_e2epause, to))
+// newSkopeoSync creates the skopeo sync YAML source. Unless --scoped is set,
+// skopeo mirrors the images using the last path component of the repository,
+// e.g. quay.io/opct/sonobuoy to <mirror>/sonobuoy, the layout expected by
+// --image-repository. Images renamed in the mirror must not be included.
+func newSkopeoSync(images []image) map[string]*skopeoRegistry {
+	registries := map[string]*skopeoRegistry{}
+	for _, img := range images {
+		repo, tag := splitImage(img.Source)
+		parts := strings.SplitN(repo, "/", 2)
+		if len(parts) != 2 {
+			continue
+		}
+		if _, ok := registries[parts[0]]; !ok {
+			registries[parts[0]] = &skopeoRegistry{Images: map[string][]string{}}
+		}
+		registries[parts[0]].Images[parts[1]] = append(registries[parts[0]].Images[parts[1]], tag)
 	}
+	return registries
+}
 
-	for _, image := range images {
-		fmt.Println(image)
+// newRepositoryMirrors returns the mirror repository of each source repository.
+func newRepositoryMirrors(images []image, toRepository string) map[string]string {
+	mirrors := map[string]string{}
+	for _, img := range images {
+		source, _ := splitImage(img.Source)
+		mirror, _ := splitImage(fmt.Sprintf("%s/%s", toRepository, img.Mirror))
+		mirrors[source] = mirror
+	}
+	return mirrors
+}
+
+func sortedSources(mirrors map[string]string) []string {
+	sources := make([]string, 0, len(mirrors))
+	for source := range mirrors {
+		sources = append(sources, source)
+	}
+	sort.Strings(sources)
+	return sources
+}
+
+func newImageDigestMirrorSet(mirrors map[string]string) *configv1.ImageDigestMirrorSet {
+	obj := &configv1.ImageDigestMirrorSet{
+		TypeMeta:   metav1.TypeMeta{APIVersion: configv1.GroupVersion.String(), Kind: "ImageDigestMirrorSet"},
+		ObjectMeta: metav1.ObjectMeta{Name: imageMirrorSetName},
+	}
+	for _, source := range sortedSources(mirrors) {
+		obj.Spec.ImageDigestMirrors = append(obj.Spec.ImageDigestMirrors, configv1.ImageDigestMirrors{
+			Source:  source,
+			Mirrors: []configv1.ImageMirror{configv1.ImageMirror(mirrors[source])},
+		})
+	}
+	return obj
+}
+
+func newImageTagMirrorSet(mirrors map[string]string) *configv1.ImageTagMirrorSet {
+	obj := &configv1.ImageTagMirrorSet{
+		TypeMeta:   metav1.TypeMeta{APIVersion: configv1.GroupVersion.String(), Kind: "ImageTagMirrorSet"},
+		ObjectMeta: metav1.ObjectMeta{Name: imageMirrorSetName},
+	}
+	for _, source := range sortedSources(mirrors) {
+		obj.Spec.ImageTagMirrors = append(obj.Spec.ImageTagMirrors, configv1.ImageTagMirrors{
+			Source:  source,
+			Mirrors: []configv1.ImageMirror{configv1.ImageMirror(mirrors[source])},
+		})
+	}
+	return obj
+}
+
+func newImageContentSourcePolicy(mirrors map[string]string) *operatorv1alpha1.ImageContentSourcePolicy {
+	obj := &operatorv1alpha1.ImageContentSourcePolicy{
+		TypeMeta:   metav1.TypeMeta{APIVersion: operatorv1alpha1.GroupVersion.String(), Kind: "ImageContentSourcePolicy"},
+		ObjectMeta: metav1.ObjectMeta{Name: imageMirrorSetName},
+	}
+	for _, source := range sortedSources(mirrors) {
+		obj.Spec.RepositoryDigestMirrors = append(obj.Spec.RepositoryDigestMirrors, operatorv1alpha1.RepositoryDigestMirrors{
+			Source:  source,
+			Mirrors: []string{mirrors[source]},
+		})
 	}
+	return obj
 }
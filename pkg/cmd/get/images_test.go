@@ -0,0 +1,78 @@
+package get
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/redhat-openshift-ecosystem/provider-certification-tool/pkg"
+)
+
+func Test_writeImages(t *testing.T) {
+	mirror := "mirror.example.com:5000/opct"
+	tests := []struct {
+		name    string
+		opts    *imageOptions
+		want    []string
+		notWant []string
+		wantErr bool
+	}{{
+		name: "list",
+		opts: &imageOptions{Format: imagesFormatList},
+		want: []string{pkg.GetSonobuoyImage() + "\n", "registry.k8s.io/pause:3.8\n"},
+	}, {
+		name: "list to repository",
+		opts: &imageOptions{Format: imagesFormatList, ToRepository: mirror},
+		want: []string{pkg.GetPluginsImage() + " " + mirror + "/" + pkg.PluginsImage + "\n"},
+	}, {
+		name: "imageset",
+		opts: &imageOptions{Format: imagesFormatImageSet},
+		want: []string{
+			"kind: ImageSetConfiguration",
+			"- name: " + pkg.GetCollectorImage(),
+			"- name: registry.k8s.io/pause:3.8\n    targetRepo: ocp-cert\n    targetTag: e2e-28-registry-k8s-io-pause-3-8-aP7uYsw5XCmoDy5W\n",
+			"- name: quay.io/openshift-scale/etcd-perf:latest\n    targetRepo: etcd-perf\n",
+		},
+	}, {
+		name:    "idms",
+		opts:    &imageOptions{Format: imagesFormatIDMS, ToRepository: mirror},
+		want:    []string{"kind: ImageDigestMirrorSet", "kind: ImageTagMirrorSet", "source: quay.io/opct/sonobuoy", "- " + mirror + "/sonobuoy\n", "#   registry.k8s.io/pause:3.8 <mirror>/ocp-cert:e2e-28"},
+		notWant: []string{"source: registry.k8s.io/pause"},
+	}, {
+		name:    "idms without repository",
+		opts:    &imageOptions{Format: imagesFormatIDMS},
+		wantErr: true,
+	}, {
+		name: "icsp",
+		opts: &imageOptions{Format: imagesFormatICSP, ToRepository: mirror},
+		want: []string{"kind: ImageContentSourcePolicy", "source: quay.io/opct/must-gather-monitoring"},
+	}, {
+		name:    "skopeo",
+		opts:    &imageOptions{Format: imagesFormatSkopeo, OpenShiftTestsImage: "quay.io/example/tests:v1"},
+		want:    []string{"quay.io:\n", "opct/plugin-openshift-tests:\n", "example/tests:\n    - v1\n", "#   registry.k8s.io/pause:3.8 <mirror>/ocp-cert:e2e-28"},
+		notWant: []string{"registry.k8s.io:\n"},
+	}, {
+		name:    "invalid format",
+		opts:    &imageOptions{Format: "unknown"},
+		wantErr: true,
+	}}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			var out bytes.Buffer
+			err := writeImages(&out, test.opts)
+			if test.wantErr {
+				assert.Error(t, err)
+				return
+			}
+			assert.NoError(t, err)
+			for _, want := range test.want {
+				assert.Contains(t, out.String(), want)
+			}
+			for _, notWant := range test.notWant {
+				assert.NotContains(t, out.String(), notWant)
+			}
+		})
+	}
+}
@@ -6,6 +6,7 @@ import (
 	"os"
 	"path/filepath"
 	"sort"
+	"strings"
 
 	"github.com/pkg/errors"
 	"github.com/spf13/cobra"
@@ -18,6 +19,7 @@ import (
 	"github.com/redhat-openshift-ecosystem/provider-certification-tool/internal/opct/plugin"
 	"github.com/redhat-openshift-ecosystem/provider-certification-tool/internal/opct/summary"
 	"github.com/redhat-openshift-ecosystem/provider-certification-tool/internal/report"
+	"github.com/redhat-openshift-ecosystem/provider-certification-tool/pkg/retrieve"
 	log "github.com/sirupsen/logrus"
 	"github.com/vmware-tanzu/sonobuoy/pkg/errlog"
 )
@@ -119,7 +121,7 @@ func NewCmdReport() *cobra.Command {
 	)
 	cmd.Flags().BoolVarP(
 		&data.force, "force", "f", false,
-		"Force to continue the execution, skipping deprecation warnings.",
+		"Force to continue the execution, skipping deprecation warnings and archive integrity failures.",
 	)
 	return cmd
 }
@@ -132,8 +134,45 @@ func checkFlags(input *Input) {
 	}
 }
 
+// SaveReport creates the report from the results archive, showing it in the
+// CLI and saving the results to the directory saveTo, without serving it.
+func SaveReport(archive, saveTo string, verbose bool) (*report.ReportData, error) {
+	return generateReport(&Input{archive: archive, saveTo: saveTo, verbose: verbose, serverSkip: true})
+}
+
 // processResult reads the artifacts and show it as an report format.
 func processResult(input *Input) error {
+	if _, err := generateReport(input); err != nil {
+		return err
+	}
+	if input.saveTo != "" && input.saveOnly {
+		os.Exit(0)
+	}
+
+	// start http server to serve static report
+	if input.saveTo != "" && !input.serverSkip {
+		fs := http.FileServer(http.Dir(input.saveTo))
+		// TODO: redirect home to the  opct-reporet.html (or rename to index.html) without
+		// affecting the fileserver.
+		http.Handle("/", fs)
+
+		log.Infof("The report web UI can be accessed at http://%s", input.serverAddress)
+		if err := http.ListenAndServe(input.serverAddress, nil); err != nil {
+			log.Fatalf("Unable to start the report server at address %s: %v", input.serverAddress, err)
+		}
+	}
+	if input.saveTo != "" && input.serverSkip {
+		log.Infof("The report server is not enabled (--server-skip=true)., you'll need to navigate it locallly")
+		log.Infof("To read the report open your browser and navigate to the path file://%s", input.saveTo)
+		log.Infof("To get started open the report file://%s/index.html.", input.saveTo)
+	}
+
+	return nil
+}
+
+// generateReport reads the artifacts, showing the report in the CLI and
+// saving the results when saveTo is set.
+func generateReport(input *Input) (*report.ReportData, error) {
 	log.Println("Creating report...")
 	timers := metrics.NewTimers()
 	timers.Add("report-total")
@@ -155,6 +194,11 @@ Additionally, if you want to skip the BaselineAPI filter, use --skip-baseline-ap
 		}
 	}
 
+	integrity, err := verifyArchive(input.archive, input.force)
+	if err != nil {
+		return nil, err
+	}
+
 	cs := summary.NewConsolidatedSummary(&summary.ConsolidatedSummaryInput{
 		Verbose:     input.verbose,
 		Timers:      timers,
@@ -165,53 +209,55 @@ Additionally, if you want to skip the BaselineAPI filter, use --skip-baseline-ap
 
 	log.Debug("Processing results")
 	if err := cs.Process(); err != nil {
-		return fmt.Errorf("error processing results: %v", err)
+		return nil, fmt.Errorf("error processing results: %v", err)
 	}
 
 	re := report.NewReportData(input.embedData)
 	log.Debug("Processing report")
 	if err := re.Populate(cs); err != nil {
-		return fmt.Errorf("error populating report: %v", err)
+		return nil, fmt.Errorf("error populating report: %v", err)
 	}
+	re.Summary.Tests.Integrity = integrity
 
 	// show report in CLI
 	if err := showReportCLI(re, input.verbose); err != nil {
-		return fmt.Errorf("error showing aggregated summary: %v", err)
+		return nil, fmt.Errorf("error showing aggregated summary: %v", err)
 	}
 
 	if input.saveTo != "" {
 		// TODO: ConsolidatedSummary should be migrated to SaveResults
 		if err := cs.SaveResults(input.saveTo); err != nil {
-			return fmt.Errorf("error saving consolidated summary results: %v", err)
+			return nil, fmt.Errorf("error saving consolidated summary results: %v", err)
 		}
 		timers.Add("report-total")
 		if err := re.SaveResults(input.saveTo); err != nil {
-			return fmt.Errorf("error saving report results: %v", err)
-		}
-		if input.saveOnly {
-			os.Exit(0)
+			return nil, fmt.Errorf("error saving report results: %v", err)
 		}
 	}
 
-	// start http server to serve static report
-	if input.saveTo != "" && !input.serverSkip {
-		fs := http.FileServer(http.Dir(input.saveTo))
-		// TODO: redirect home to the  opct-reporet.html (or rename to index.html) without
-		// affecting the fileserver.
-		http.Handle("/", fs)
+	return re, nil
+}
 
-		log.Infof("The report web UI can be accessed at http://%s", input.serverAddress)
-		if err := http.ListenAndServe(input.serverAddress, nil); err != nil {
-			log.Fatalf("Unable to start the report server at address %s: %v", input.serverAddress, err)
+// verifyArchive checks the archive against the integrity manifest written by
+// retrieve. Archives truncated or modified are not reported unless force is
+// set, archives without manifest are reported without verification.
+func verifyArchive(archive string, force bool) (*report.ReportIntegrity, error) {
+	m, err := retrieve.VerifyManifest(archive)
+	switch {
+	case errors.Is(err, retrieve.ErrManifestNotFound):
+		log.Warnf("Integrity manifest %s not found, the archive integrity is not verified", filepath.Base(retrieve.ManifestPath(archive)))
+		return &report.ReportIntegrity{Status: report.IntegrityNotVerified, Message: err.Error()}, nil
+	case err != nil && m == nil:
+		return nil, fmt.Errorf("unable to verify the archive integrity: %w", err)
+	case err != nil:
+		if !force {
+			return nil, fmt.Errorf("archive integrity check failed: %w. Collect the archive again, or set --force to report it anyway", err)
 		}
+		log.Errorf("Archive integrity check failed: %v", err)
+		return &report.ReportIntegrity{Status: report.IntegrityFailed, Message: err.Error(), SHA256: m.SHA256}, nil
 	}
-	if input.saveTo != "" && input.serverSkip {
-		log.Infof("The report server is not enabled (--server-skip=true)., you'll need to navigate it locallly")
-		log.Infof("To read the report open your browser and navigate to the path file://%s", input.saveTo)
-		log.Infof("To get started open the report file://%s/index.html.", input.saveTo)
-	}
-
-	return nil
+	log.Infof("Archive integrity verified by the manifest (SHA-256 %s)", m.SHA256)
+	return &report.ReportIntegrity{Status: report.IntegrityVerified, SHA256: m.SHA256}, nil
 }
 
 func showReportCLI(report *report.ReportData, verbose bool) error {
@@ -235,6 +281,12 @@ func showReportAggregatedSummary(re *report.ReportData) error {
 
 	// Using go-table
 	archive := filepath.Base(re.Summary.Tests.Archive)
+	if it := re.Summary.Tests.Integrity; it != nil {
+		archive = fmt.Sprintf("%s (integrity: %s)", archive, it.Status)
+	}
+	if re.Provider != nil && re.Provider.Partial {
+		archive = fmt.Sprintf("%s\n >> PARTIAL results, plugins without results: %v, plugins in progress: %v", archive, re.Provider.MissingPlugins, re.Provider.InProgressPlugins)
+	}
 	if re.Baseline != nil {
 		archive = fmt.Sprintf("%s\n >> Diff from: %s", archive, filepath.Base(re.Summary.Tests.ArchiveDiff))
 	}
@@ -332,6 +384,64 @@ func showReportAggregatedSummary(re *report.ReportData) error {
 		tbPBas.AppendSeparator()
 	}
 
+	// Section: Run ID
+	if re.Setup != nil && re.Setup.API != nil && re.Setup.API.UUID != "" {
+		tbProv.AppendRows([]table.Row{{"Run ID:", re.Setup.API.UUID}})
+		tbProv.AppendSeparator()
+		if baselineProcessed {
+			tbPBas.AppendRows([]table.Row{{"Run ID:", re.Setup.API.UUID, ""}})
+			tbPBas.AppendSeparator()
+		}
+	}
+
+	// Section: User-defined run tags
+	if re.Setup != nil && re.Setup.API != nil && len(re.Setup.API.Tags) > 0 {
+		keys := make([]string, 0, len(re.Setup.API.Tags))
+		for k := range re.Setup.API.Tags {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+		rowsProv = []table.Row{{"Tags:", ""}}
+		rowsPBas = []table.Row{{"Tags:", "", ""}}
+		for _, k := range keys {
+			rowsProv = append(rowsProv, table.Row{" - " + k, re.Setup.API.Tags[k]})
+			rowsPBas = append(rowsPBas, table.Row{" - " + k, re.Setup.API.Tags[k], ""})
+		}
+		tbProv.AppendRows(rowsProv)
+		tbProv.AppendSeparator()
+		if baselineProcessed {
+			tbPBas.AppendRows(rowsPBas)
+			tbPBas.AppendSeparator()
+		}
+	}
+
+	// Section: Run profile requested to the environment
+	if re.Setup != nil && re.Setup.API != nil && re.Setup.API.RunProfile != "" {
+		profile := strings.TrimSpace(re.Setup.API.RunProfile)
+		tbProv.AppendRows([]table.Row{{"Run Profile:", profile}})
+		tbProv.AppendSeparator()
+		if baselineProcessed {
+			tbPBas.AppendRows([]table.Row{{"Run Profile:", profile, ""}})
+			tbPBas.AppendSeparator()
+		}
+	}
+
+	// Section: Images pinned by digest
+	if re.Setup != nil && re.Setup.API != nil && len(re.Setup.API.Images) > 0 {
+		rowsProv = []table.Row{{"Images (pinned):", ""}}
+		rowsPBas = []table.Row{{"Images (pinned):", "", ""}}
+		for _, img := range re.Setup.API.Images {
+			rowsProv = append(rowsProv, table.Row{" " + img.Image, img.Digest})
+			rowsPBas = append(rowsPBas, table.Row{" " + img.Image, img.Digest, ""})
+		}
+		tbProv.AppendRows(rowsProv)
+		tbProv.AppendSeparator()
+		if baselineProcessed {
+			tbPBas.AppendRows(rowsPBas)
+			tbPBas.AppendSeparator()
+		}
+	}
+
 	// Section: Environment state
 	rowsProv = []table.Row{{"Plugin summary:", "Status [Total/Passed/Failed/Skipped] (timeout)"}}
 	if baselineProcessed {
@@ -2,17 +2,25 @@ package destroy
 
 import (
 	"context"
+	"errors"
+	"fmt"
 	"regexp"
 	"time"
 
+	mcfgclientset "github.com/openshift/client-go/machineconfiguration/clientset/versioned"
 	log "github.com/sirupsen/logrus"
 	"github.com/spf13/cobra"
 	sonobuoyclient "github.com/vmware-tanzu/sonobuoy/pkg/client"
+	v1 "k8s.io/api/core/v1"
+	kerrors "k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/client-go/kubernetes"
 
 	"github.com/redhat-openshift-ecosystem/provider-certification-tool/pkg"
 	"github.com/redhat-openshift-ecosystem/provider-certification-tool/pkg/client"
+	"github.com/redhat-openshift-ecosystem/provider-certification-tool/pkg/inventory"
+	"github.com/redhat-openshift-ecosystem/provider-certification-tool/pkg/runid"
+	"github.com/redhat-openshift-ecosystem/provider-certification-tool/pkg/upgrade"
 )
 
 const (
@@ -21,12 +29,29 @@ const (
 )
 
 type DestroyOptions struct {
+	deleteMCP bool
+
+	// dryRun lists the objects which would be deleted, without deleting them.
+	dryRun bool
+
+	// allTestNamespaces deletes every namespace matching NonOpenShiftNamespace,
+	// instead of the test namespaces of the run.
+	allTestNamespaces bool
+
+	// inventory is the list of objects created by the run, nil when the
+	// environment was created by a version without inventory.
+	inventory *inventory.Inventory
 }
 
 func NewDestroyOptions() *DestroyOptions {
 	return &DestroyOptions{}
 }
 
+// SetDeleteMCP sets whether the MachineConfigPool of the upgrade mode is deleted.
+func (d *DestroyOptions) SetDeleteMCP(deleteMCP bool) {
+	d.deleteMCP = deleteMCP
+}
+
 func NewCmdDestroy() *cobra.Command {
 	o := NewDestroyOptions()
 	cmd := &cobra.Command{
@@ -43,22 +68,11 @@ func NewCmdDestroy() *cobra.Command {
 				log.Error(err)
 				return err
 			}
+			runid.SetLoggerFromCluster(cmd.Context(), kclient)
 
-			err = o.DeleteSonobuoyEnv(sclient)
-			if err != nil {
-				log.Warn(err)
-			}
-
-			log.Info("removing non-openshift NS...")
-			err = o.DeleteTestNamespaces(kclient)
-			if err != nil {
-				log.Warn(err)
-			}
-
-			log.Info("restoring privileged environment...")
-			err = o.RestoreSCC(kclient)
-			if err != nil {
-				log.Warn(err)
+			o.Destroy(cmd.Context(), kclient, sclient)
+			if o.dryRun {
+				return nil
 			}
 
 			log.Info("Destroy done!")
@@ -66,64 +80,343 @@ func NewCmdDestroy() *cobra.Command {
 		},
 	}
 
+	cmd.Flags().BoolVar(&o.deleteMCP, "delete-mcp", false, "Delete the MachineConfigPool created by opct to the upgrade mode. Pools not labeled app.kubernetes.io/managed-by=opct are kept. Pending updates will be applied to the dedicated nodes.")
+	cmd.Flags().BoolVar(&o.dryRun, "dry-run", false, "List the objects which would be deleted, without deleting them.")
+	cmd.Flags().BoolVar(&o.allTestNamespaces, "all-test-namespaces", false, "Delete every namespace matching 'e2e-.*', including namespaces not created by the validation environment. Use only in dedicated clusters.")
+
 	return cmd
 }
 
+// Destroy removes the validation environment. Failures are logged, allowing
+// the remaining resources to be removed.
+func (d *DestroyOptions) Destroy(ctx context.Context, kclient kubernetes.Interface, sclient sonobuoyclient.Interface) {
+	if err := d.LoadInventory(ctx, kclient); err != nil {
+		log.Warn(err)
+	}
+	if d.dryRun {
+		objects, err := d.Plan(ctx, kclient)
+		if err != nil {
+			log.Warn(err)
+		}
+		log.Infof("Dry run: %d objects would be deleted", len(objects))
+		for _, o := range objects {
+			log.Infof("  %s", o)
+		}
+		return
+	}
+
+	// The test namespaces are selected by the creation of the environment
+	// namespace, before it is deleted.
+	testNamespaces, err := d.TestNamespaces(ctx, kclient)
+	if err != nil {
+		log.Warn(err)
+	}
+
+	err = d.DeleteSonobuoyEnv(sclient)
+	if err != nil {
+		log.Warn(err)
+	}
+
+	log.Info("removing non-openshift NS...")
+	d.deleteNamespaces(ctx, kclient, testNamespaces)
+
+	log.Info("restoring privileged environment...")
+	err = d.RestoreSCC(kclient)
+	if err != nil {
+		log.Warn(err)
+	}
+
+	if d.deleteMCP {
+		log.Info("removing upgrade MachineConfigPool...")
+		err = d.DeleteMachineConfigPool(ctx)
+		if err != nil {
+			log.Warn(err)
+		}
+	} else if d.inventory.Has(inventory.KindMachineConfigPool, "", pkg.MachineConfigPoolName) {
+		log.Infof("MachineConfigPool %s created by the run is kept, use --delete-mcp to remove it", pkg.MachineConfigPoolName)
+	}
+}
+
+// LoadInventory reads the objects created by the run.
+func (d *DestroyOptions) LoadInventory(ctx context.Context, kclient kubernetes.Interface) error {
+	inv, err := inventory.Load(ctx, kclient)
+	if err != nil {
+		return err
+	}
+	if inv == nil {
+		log.Warnf("Inventory not found, the environment was created by a previous version: the default objects are removed")
+	}
+	d.inventory = inv
+	return nil
+}
+
+// Plan returns the objects deleted by the destroy: the environment namespace
+// with the objects in it, the test namespaces, the RBAC objects and the
+// MachineConfigPool when --delete-mcp is set.
+func (d *DestroyOptions) Plan(ctx context.Context, kclient kubernetes.Interface) ([]inventory.Object, error) {
+	objects := []inventory.Object{}
+	ns := pkg.GetNamespace()
+	if _, err := kclient.CoreV1().Namespaces().Get(ctx, ns, metav1.GetOptions{}); err == nil {
+		objects = append(objects, inventory.Object{Kind: inventory.KindNamespace, Name: ns})
+		nsObjects, err := namespaceObjects(ctx, kclient, ns)
+		if err != nil {
+			return objects, err
+		}
+		objects = append(objects, nsObjects...)
+	} else if !kerrors.IsNotFound(err) {
+		return objects, err
+	}
+
+	testNamespaces, err := d.TestNamespaces(ctx, kclient)
+	if err != nil {
+		return objects, err
+	}
+	for _, name := range testNamespaces {
+		objects = append(objects, inventory.Object{Kind: inventory.KindNamespace, Name: name})
+	}
+
+	for _, name := range d.clusterRoles() {
+		if _, err := kclient.RbacV1().ClusterRoles().Get(ctx, name, metav1.GetOptions{}); err == nil {
+			objects = append(objects, inventory.Object{Kind: inventory.KindClusterRole, Name: name})
+		}
+	}
+	for _, name := range d.clusterRoleBindings() {
+		if _, err := kclient.RbacV1().ClusterRoleBindings().Get(ctx, name, metav1.GetOptions{}); err == nil {
+			objects = append(objects, inventory.Object{Kind: inventory.KindClusterRoleBinding, Name: name})
+		}
+	}
+	if d.deleteMCP {
+		objects = append(objects, inventory.Object{Kind: inventory.KindMachineConfigPool, Name: pkg.MachineConfigPoolName})
+	}
+	return objects, nil
+}
+
+// namespaceObjects returns the objects created by the run and by Sonobuoy in
+// the namespace, deleted with it.
+func namespaceObjects(ctx context.Context, kclient kubernetes.Interface, ns string) ([]inventory.Object, error) {
+	objects := []inventory.Object{}
+	opts := metav1.ListOptions{}
+	pods, err := kclient.CoreV1().Pods(ns).List(ctx, opts)
+	if err != nil {
+		return nil, err
+	}
+	for _, o := range pods.Items {
+		objects = append(objects, inventory.Object{Kind: "Pod", Name: o.Name, Namespace: ns})
+	}
+	services, err := kclient.CoreV1().Services(ns).List(ctx, opts)
+	if err != nil {
+		return nil, err
+	}
+	for _, o := range services.Items {
+		objects = append(objects, inventory.Object{Kind: "Service", Name: o.Name, Namespace: ns})
+	}
+	cms, err := kclient.CoreV1().ConfigMaps(ns).List(ctx, opts)
+	if err != nil {
+		return nil, err
+	}
+	for _, o := range cms.Items {
+		objects = append(objects, inventory.Object{Kind: inventory.KindConfigMap, Name: o.Name, Namespace: ns})
+	}
+	sas, err := kclient.CoreV1().ServiceAccounts(ns).List(ctx, opts)
+	if err != nil {
+		return nil, err
+	}
+	for _, o := range sas.Items {
+		objects = append(objects, inventory.Object{Kind: inventory.KindServiceAccount, Name: o.Name, Namespace: ns})
+	}
+	return objects, nil
+}
+
+// clusterRoles returns the ClusterRoles created by the run.
+func (d *DestroyOptions) clusterRoles() []string {
+	if d.inventory == nil {
+		return []string{pkg.GetPrivilegedClusterRole()}
+	}
+	return objectNames(d.inventory.ByKind(inventory.KindClusterRole))
+}
+
+// clusterRoleBindings returns the ClusterRoleBindings created by the run.
+func (d *DestroyOptions) clusterRoleBindings() []string {
+	if d.inventory == nil {
+		return []string{pkg.GetPrivilegedClusterRoleBinding()}
+	}
+	return objectNames(d.inventory.ByKind(inventory.KindClusterRoleBinding))
+}
+
+func objectNames(objects []inventory.Object) []string {
+	names := []string{}
+	for _, o := range objects {
+		names = append(names, o.Name)
+	}
+	return names
+}
+
 // DeleteSonobuoyEnv initiates deletion of Sonobuoy environment and waits until completion.
 func (d *DestroyOptions) DeleteSonobuoyEnv(sclient sonobuoyclient.Interface) error {
 	deleteConfig := &sonobuoyclient.DeleteConfig{
-		Namespace: pkg.CertificationNamespace,
+		Namespace: pkg.GetNamespace(),
 		Wait:      DeleteSonobuoyEnvWaitTime,
 	}
 
 	return sclient.Delete(deleteConfig)
 }
 
-// DeleteTestNamespaces deletes any non-openshift namespace.
+// DeleteTestNamespaces deletes the namespaces created by the tests of the run.
 func (d *DestroyOptions) DeleteTestNamespaces(kclient kubernetes.Interface) error {
+	namespaces, err := d.TestNamespaces(context.TODO(), kclient)
+	if err != nil {
+		return err
+	}
+	d.deleteNamespaces(context.TODO(), kclient, namespaces)
+	return nil
+}
+
+// TestNamespaces returns the namespaces created by the tests of the run,
+// recognized by the inventory. Every namespace matching NonOpenShiftNamespace
+// is returned when --all-test-namespaces is set. Environments without
+// inventory are matched by the creation of the environment namespace.
+//
+// Namespaces not requested by the environment, created while other validation
+// environment was running, can be of either run and are kept.
+func (d *DestroyOptions) TestNamespaces(ctx context.Context, kclient kubernetes.Interface) ([]string, error) {
 	client := kclient.CoreV1()
 
 	// Get list of all namespaces (TODO is there way to filter these server-side?)
-	nsList, err := client.Namespaces().List(context.TODO(), metav1.ListOptions{})
+	nsList, err := client.Namespaces().List(ctx, metav1.ListOptions{})
 	if err != nil {
-		return err
+		return nil, err
 	}
 
+	inv := d.inventory
+	since := d.environmentCreation(ctx, kclient)
+	if !d.allTestNamespaces && inv == nil {
+		if since.IsZero() {
+			log.Warnf("The test namespaces are not removed without the inventory and the namespace %s, use --all-test-namespaces to remove every namespace matching %q", pkg.GetNamespace(), NonOpenShiftNamespace)
+			return nil, nil
+		}
+		log.Warnf("Inventory not found, removing the test namespaces created after the namespace %s", pkg.GetNamespace())
+		inv = &inventory.Inventory{Namespace: pkg.GetNamespace()}
+	}
+	environments := otherEnvironments(nsList.Items)
+
 	// Filter namespaces by name
 	var nonOpenShiftNamespaces []string
 	pattern := regexp.MustCompile(NonOpenShiftNamespace)
-	for _, ns := range nsList.Items {
-		if pattern.MatchString(ns.Name) {
-			log.Infof("stale namespace was found: %s, removing...", ns.Name)
-			nonOpenShiftNamespaces = append(nonOpenShiftNamespaces, ns.Name)
+	for i := range nsList.Items {
+		ns := &nsList.Items[i]
+		if !pattern.MatchString(ns.Name) {
+			continue
+		}
+		if !d.allTestNamespaces {
+			if !inv.IsTestNamespace(ns, since) {
+				log.Debugf("namespace %s was not created by the validation environment, skipping", ns.Name)
+				continue
+			}
+			if env := concurrentEnvironment(environments, ns); env != "" && !inv.IsRequestedByEnvironment(ns) {
+				log.Warnf("namespace %s was created while the validation environment %s was running, skipping: remove it manually or use --all-test-namespaces", ns.Name, env)
+				continue
+			}
+		}
+		log.Infof("stale namespace was found: %s", ns.Name)
+		nonOpenShiftNamespaces = append(nonOpenShiftNamespaces, ns.Name)
+	}
+	return nonOpenShiftNamespaces, nil
+}
+
+// otherEnvironments returns the namespaces of the validation environments
+// other than the current, labeled by Sonobuoy.
+func otherEnvironments(namespaces []v1.Namespace) []*v1.Namespace {
+	envs := []*v1.Namespace{}
+	for i := range namespaces {
+		ns := &namespaces[i]
+		if ns.Name == pkg.GetNamespace() || ns.Labels[pkg.SonobuoyLabelComponentName] != pkg.SonobuoyLabelComponentValue {
+			continue
+		}
+		envs = append(envs, ns)
+	}
+	return envs
+}
+
+// concurrentEnvironment returns the name of the validation environment created
+// before the namespace, or empty when there is none.
+func concurrentEnvironment(environments []*v1.Namespace, ns *v1.Namespace) string {
+	for _, env := range environments {
+		if env.CreationTimestamp.Time.Before(ns.CreationTimestamp.Time) {
+			return env.Name
 		}
 	}
+	return ""
+}
+
+// environmentCreation returns the creation time of the environment namespace,
+// set by the cluster clock, defaulting to the creation of the inventory.
+func (d *DestroyOptions) environmentCreation(ctx context.Context, kclient kubernetes.Interface) time.Time {
+	ns, err := kclient.CoreV1().Namespaces().Get(ctx, pkg.GetNamespace(), metav1.GetOptions{})
+	if err == nil {
+		return ns.CreationTimestamp.Time
+	}
+	if d.inventory != nil {
+		return d.inventory.CreatedAt
+	}
+	return time.Time{}
+}
 
-	// Delete filtered namespaces
-	for _, ns := range nonOpenShiftNamespaces {
-		err := client.Namespaces().Delete(context.TODO(), ns, metav1.DeleteOptions{})
+// deleteNamespaces deletes the namespaces, logging the failures.
+func (d *DestroyOptions) deleteNamespaces(ctx context.Context, kclient kubernetes.Interface, namespaces []string) {
+	for _, ns := range namespaces {
+		log.Infof("removing namespace %s...", ns)
+		err := kclient.CoreV1().Namespaces().Delete(ctx, ns, metav1.DeleteOptions{})
 		if err != nil {
 			log.WithError(err).Warnf("error deleting namespace %s", ns)
 		}
 	}
+}
 
-	return nil
+// DeleteMachineConfigPool removes the MachineConfigPool used in upgrade mode.
+func (d *DestroyOptions) DeleteMachineConfigPool(ctx context.Context) error {
+	restConfig, err := client.CreateRestConfig()
+	if err != nil {
+		return err
+	}
+	mcClient, err := mcfgclientset.NewForConfig(restConfig)
+	if err != nil {
+		return err
+	}
+	return upgrade.DeleteMachineConfigPool(ctx, mcClient)
 }
 
+// RestoreSCC deletes the ClusterRoles and ClusterRoleBindings created by the
+// run, granting the privileged environment. Objects not found are ignored, and
+// the errors of every object are returned.
 func (d *DestroyOptions) RestoreSCC(kclient kubernetes.Interface) error {
 	client := kclient.RbacV1()
+	var errs []error
 
-	err := client.ClusterRoles().Delete(context.TODO(), pkg.PrivilegedClusterRole, metav1.DeleteOptions{})
-	if err != nil {
-		return err
+	for _, name := range d.clusterRoles() {
+		err := client.ClusterRoles().Delete(context.TODO(), name, metav1.DeleteOptions{})
+		if kerrors.IsNotFound(err) {
+			log.Infof("ClusterRole %s not found, skipping", name)
+			continue
+		}
+		if err != nil {
+			errs = append(errs, fmt.Errorf("error deleting ClusterRole %s: %w", name, err))
+			continue
+		}
+		log.Infof("Deleted %s ClusterRole", name)
 	}
-	log.Infof("Deleted %s ClusterRole", pkg.PrivilegedClusterRole)
 
-	err = client.ClusterRoleBindings().Delete(context.TODO(), pkg.PrivilegedClusterRoleBinding, metav1.DeleteOptions{})
-	if err != nil {
-		return err
+	for _, name := range d.clusterRoleBindings() {
+		err := client.ClusterRoleBindings().Delete(context.TODO(), name, metav1.DeleteOptions{})
+		if kerrors.IsNotFound(err) {
+			log.Infof("ClusterRoleBinding %s not found, skipping", name)
+			continue
+		}
+		if err != nil {
+			errs = append(errs, fmt.Errorf("error deleting ClusterRoleBinding %s: %w", name, err))
+			continue
+		}
+		log.Infof("Deleted %s ClusterRoleBinding", name)
 	}
-	log.Infof("Deleted %s ClusterRoleBinding", pkg.PrivilegedClusterRoleBinding)
 
-	return nil
+	return errors.Join(errs...)
 }
@@ -2,62 +2,204 @@ package destroy
 
 import (
 	"context"
+	"errors"
+	"sort"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 	v1 "k8s.io/api/core/v1"
+	rbacv1 "k8s.io/api/rbac/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/client-go/kubernetes/fake"
+	k8stesting "k8s.io/client-go/testing"
+
+	"github.com/redhat-openshift-ecosystem/provider-certification-tool/pkg"
+	"github.com/redhat-openshift-ecosystem/provider-certification-tool/pkg/inventory"
 )
 
 func Test_DeleteTestNamespaces(t *testing.T) {
+	created := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	newNamespace := func(name string, age time.Duration, e2e bool, requester string) *v1.Namespace {
+		ns := &v1.Namespace{ObjectMeta: metav1.ObjectMeta{
+			Name:              name,
+			CreationTimestamp: metav1.NewTime(created.Add(age)),
+			Labels:            map[string]string{},
+			Annotations:       map[string]string{},
+		}}
+		if e2e {
+			ns.Labels[inventory.E2EFrameworkLabel] = "csi"
+			ns.Labels[inventory.E2ERunLabel] = "3b0f8c1e-5a4e-4c55-9f3d-2d6b1f3a8c41"
+		}
+		if requester != "" {
+			ns.Annotations[inventory.RequesterAnnotation] = requester
+		}
+		return ns
+	}
+	inv := inventory.New("id")
+	inv.CreatedAt = created
+
+	namespaces := []*v1.Namespace{
+		newNamespace(pkg.GetNamespace(), 0, false, ""),
+		newNamespace("openshift-cluster-version", -time.Hour, false, ""),
+		newNamespace("mytestns", time.Hour, false, ""),
+		newNamespace("e2e-csi-run", time.Hour, true, ""),
+		newNamespace("e2e-test-csi-project", time.Hour, false, "e2e-test-csi-project-user"),
+		newNamespace("e2e-other-team", time.Hour, true, "system:serviceaccount:other:e2e"),
+		newNamespace("e2e-before-run", -time.Hour, true, ""),
+		newNamespace("e2e-not-framework", time.Hour, false, ""),
+		newNamespace("e2e-requested-by-run", 2*time.Hour, false, "system:serviceaccount:"+pkg.GetNamespace()+":sonobuoy-serviceaccount"),
+	}
+
+	// Validation environment created in other namespace while the tests of
+	// the run were running.
+	otherEnvironment := newNamespace("opct-other", 90*time.Minute, false, "")
+	otherEnvironment.Labels[pkg.SonobuoyLabelComponentName] = pkg.SonobuoyLabelComponentValue
+	lateNamespace := newNamespace("e2e-csi-late", 2*time.Hour, true, "")
+
 	tests := []struct {
-		name               string
-		namespaces         []string
-		expectedNamespaces []string
-	}{{
-		"remove e2e namespace",
-		[]string{"openshift-cluster-version", "mytestns", "e2e-csi"},
-		[]string{"openshift-cluster-version", "mytestns"},
-	}, {
-		"remove no namespaces",
-		[]string{"openshift-cluster-version", "mytestns", "openshift"},
-		[]string{"openshift-cluster-version", "openshift", "mytestns"},
-	}}
+		name              string
+		inventory         *inventory.Inventory
+		allTestNamespaces bool
+		extra             []*v1.Namespace
+		withoutEnv        bool
+		expectedDeleted   []string
+	}{
+		{
+			name:            "remove test namespaces of the run",
+			inventory:       inv,
+			expectedDeleted: []string{"e2e-csi-run", "e2e-requested-by-run", "e2e-test-csi-project"},
+		},
+		{
+			name:            "keep test namespaces created while other environment was running",
+			inventory:       inv,
+			extra:           []*v1.Namespace{otherEnvironment, lateNamespace},
+			expectedDeleted: []string{"e2e-csi-run", "e2e-requested-by-run", "e2e-test-csi-project"},
+		},
+		{
+			name:            "remove test namespaces created after the environment without inventory",
+			expectedDeleted: []string{"e2e-csi-run", "e2e-requested-by-run", "e2e-test-csi-project"},
+		},
+		{
+			name:            "remove no namespaces without inventory and environment",
+			withoutEnv:      true,
+			expectedDeleted: []string{},
+		},
+		{
+			name:              "remove all e2e namespaces",
+			allTestNamespaces: true,
+			expectedDeleted:   []string{"e2e-before-run", "e2e-csi-run", "e2e-not-framework", "e2e-other-team", "e2e-requested-by-run", "e2e-test-csi-project"},
+		},
+	}
 
 	for _, test := range tests {
-		// Setup initial namespaces
-		var namespaces []runtime.Object
-		for _, ns := range test.namespaces {
-			namespaces = append(namespaces, &v1.Namespace{
-				ObjectMeta: metav1.ObjectMeta{
-					Name: ns,
-				},
-			})
-		}
-
-		// Get Clientset and initialize with namespaces
-		clientset := fake.NewSimpleClientset(namespaces...)
-		d := DestroyOptions{}
+		t.Run(test.name, func(t *testing.T) {
+			// Get Clientset and initialize with namespaces
+			objects := []runtime.Object{}
+			created := []string{}
+			for _, ns := range append(append([]*v1.Namespace{}, namespaces...), test.extra...) {
+				if test.withoutEnv && ns.Name == pkg.GetNamespace() {
+					continue
+				}
+				objects = append(objects, ns.DeepCopy())
+				created = append(created, ns.Name)
+			}
+			clientset := fake.NewSimpleClientset(objects...)
+			d := DestroyOptions{inventory: test.inventory, allTestNamespaces: test.allTestNamespaces}
 
-		// Delete namespaces
-		err := d.DeleteTestNamespaces(clientset)
-		assert.Nil(t, err)
+			// Delete namespaces
+			err := d.DeleteTestNamespaces(clientset)
+			assert.Nil(t, err)
 
-		// Get actual list of namespaces after deletion
-		result, err := clientset.CoreV1().Namespaces().List(context.TODO(), metav1.ListOptions{})
-		assert.Nil(t, err)
+			// Get actual list of namespaces after deletion
+			result, err := clientset.CoreV1().Namespaces().List(context.TODO(), metav1.ListOptions{})
+			assert.Nil(t, err)
+			remaining := map[string]bool{}
+			for _, ns := range result.Items {
+				remaining[ns.Name] = true
+			}
 
-		// Compare results
-		for _, ns := range test.expectedNamespaces {
-			found := false
-			for _, actualNs := range result.Items {
-				if ns == actualNs.Name {
-					found = true
+			// Compare results
+			deleted := []string{}
+			for _, name := range created {
+				if !remaining[name] {
+					deleted = append(deleted, name)
 				}
 			}
-			assert.True(t, found, "could not find expected namespaces")
+			sort.Strings(deleted)
+			assert.Equal(t, test.expectedDeleted, deleted)
+		})
+	}
+}
+
+func Test_Plan(t *testing.T) {
+	ns := pkg.GetNamespace()
+	inv := inventory.New("id")
+	inv.Add(inventory.KindNamespace, "", ns)
+	inv.Add(inventory.KindClusterRole, "", pkg.GetPrivilegedClusterRole())
+	inv.Add(inventory.KindClusterRoleBinding, "", pkg.GetPrivilegedClusterRoleBinding())
+
+	clientset := fake.NewSimpleClientset(
+		&v1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: ns}},
+		&v1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "e2e-other-team"}},
+		&v1.Pod{ObjectMeta: metav1.ObjectMeta{Name: "sonobuoy", Namespace: ns}},
+		&v1.ConfigMap{ObjectMeta: metav1.ObjectMeta{Name: inventory.ConfigMapName, Namespace: ns}},
+		&rbacv1.ClusterRole{ObjectMeta: metav1.ObjectMeta{Name: pkg.GetPrivilegedClusterRole()}},
+		&rbacv1.ClusterRoleBinding{ObjectMeta: metav1.ObjectMeta{Name: pkg.GetPrivilegedClusterRoleBinding()}},
+	)
+	d := DestroyOptions{inventory: inv, dryRun: true}
+	objects, err := d.Plan(context.TODO(), clientset)
+	assert.NoError(t, err)
+
+	got := []string{}
+	for _, o := range objects {
+		got = append(got, o.String())
+	}
+	assert.Equal(t, []string{
+		"Namespace " + ns,
+		"Pod " + ns + "/sonobuoy",
+		"ConfigMap " + ns + "/" + inventory.ConfigMapName,
+		"ClusterRole " + pkg.GetPrivilegedClusterRole(),
+		"ClusterRoleBinding " + pkg.GetPrivilegedClusterRoleBinding(),
+	}, got)
+
+	// The dry run must not delete objects.
+	_, err = clientset.CoreV1().Namespaces().Get(context.TODO(), ns, metav1.GetOptions{})
+	assert.NoError(t, err)
+}
+
+func Test_RestoreSCC(t *testing.T) {
+	inv := inventory.New("id")
+	inv.Add(inventory.KindClusterRole, "", "opct-missing")
+	inv.Add(inventory.KindClusterRole, "", pkg.GetPrivilegedClusterRole())
+	inv.Add(inventory.KindClusterRoleBinding, "", "opct-failing")
+	inv.Add(inventory.KindClusterRoleBinding, "", pkg.GetPrivilegedClusterRoleBinding())
+
+	clientset := fake.NewSimpleClientset(
+		&rbacv1.ClusterRole{ObjectMeta: metav1.ObjectMeta{Name: pkg.GetPrivilegedClusterRole()}},
+		&rbacv1.ClusterRoleBinding{ObjectMeta: metav1.ObjectMeta{Name: "opct-failing"}},
+		&rbacv1.ClusterRoleBinding{ObjectMeta: metav1.ObjectMeta{Name: pkg.GetPrivilegedClusterRoleBinding()}},
+	)
+	clientset.PrependReactor("delete", "clusterrolebindings", func(action k8stesting.Action) (bool, runtime.Object, error) {
+		if action.(k8stesting.DeleteAction).GetName() == "opct-failing" {
+			return true, nil, errors.New("forbidden")
 		}
+		return false, nil, nil
+	})
+
+	d := DestroyOptions{inventory: inv}
+	err := d.RestoreSCC(clientset)
+	assert.ErrorContains(t, err, "error deleting ClusterRoleBinding opct-failing: forbidden")
+	assert.NotContains(t, err.Error(), "opct-missing")
+
+	// The objects after the missing and failing ones are deleted.
+	crs, err := clientset.RbacV1().ClusterRoles().List(context.TODO(), metav1.ListOptions{})
+	assert.NoError(t, err)
+	assert.Empty(t, crs.Items)
+	crbs, err := clientset.RbacV1().ClusterRoleBindings().List(context.TODO(), metav1.ListOptions{})
+	assert.NoError(t, err)
+	if assert.Len(t, crbs.Items, 1) {
+		assert.Equal(t, "opct-failing", crbs.Items[0].Name)
 	}
 }
@@ -0,0 +1,138 @@
+package images
+
+import (
+	"fmt"
+	"os"
+	"regexp"
+	"sort"
+	"strings"
+
+	"sigs.k8s.io/yaml"
+)
+
+const (
+	LockAPIVersion = "opct.openshift.io/v1alpha1"
+	LockKind       = "ImageLock"
+
+	// LockConfigMapKey is the key in the opct-version ConfigMap storing the
+	// lock of the images used by the run.
+	LockConfigMapKey = "images-lock"
+)
+
+var digestRegex = regexp.MustCompile(`^sha256:[a-f0-9]{64}$`)
+
+// Lock holds the digest of the images used by a run.
+type Lock struct {
+	APIVersion string       `json:"apiVersion"`
+	Kind       string       `json:"kind"`
+	Images     []*LockImage `json:"images"`
+}
+
+// LockImage is the digest of an image reference.
+type LockImage struct {
+	// Image is the reference by tag, e.g. quay.io/opct/sonobuoy:v0.57.1.
+	Image string `json:"image"`
+
+	// Digest is the manifest digest of the image.
+	Digest string `json:"digest"`
+}
+
+// NewLock creates an empty lock.
+func NewLock() *Lock {
+	return &Lock{APIVersion: LockAPIVersion, Kind: LockKind, Images: []*LockImage{}}
+}
+
+// LoadLock reads the lock file, validating it.
+func LoadLock(file string) (*Lock, error) {
+	data, err := os.ReadFile(file)
+	if err != nil {
+		return nil, fmt.Errorf("unable to read image lock file: %w", err)
+	}
+	lock := &Lock{}
+	if err := yaml.UnmarshalStrict(data, lock); err != nil {
+		return nil, fmt.Errorf("unable to parse image lock file %s: %w", file, err)
+	}
+	if err := lock.Validate(); err != nil {
+		return nil, fmt.Errorf("invalid image lock file %s: %w", file, err)
+	}
+	return lock, nil
+}
+
+// Validate checks the kind and the digests of the lock.
+func (l *Lock) Validate() error {
+	if l.APIVersion != LockAPIVersion {
+		return fmt.Errorf("unsupported apiVersion %q, want %q", l.APIVersion, LockAPIVersion)
+	}
+	if l.Kind != LockKind {
+		return fmt.Errorf("unsupported kind %q, want %q", l.Kind, LockKind)
+	}
+	for _, img := range l.Images {
+		if !digestRegex.MatchString(img.Digest) {
+			return fmt.Errorf("invalid digest %q of image %s", img.Digest, img.Image)
+		}
+	}
+	return nil
+}
+
+// Add sets the digest of the image.
+func (l *Lock) Add(image, digest string) {
+	for _, img := range l.Images {
+		if img.Image == image {
+			img.Digest = digest
+			return
+		}
+	}
+	l.Images = append(l.Images, &LockImage{Image: image, Digest: digest})
+	sort.Slice(l.Images, func(i, j int) bool { return l.Images[i].Image < l.Images[j].Image })
+}
+
+// Digest returns the digest of the image locked by the same reference.
+func (l *Lock) Digest(image string) (string, bool) {
+	for _, img := range l.Images {
+		if img.Image == image {
+			return img.Digest, true
+		}
+	}
+	return "", false
+}
+
+// MirrorDigest returns the digest of the image copied from the source
+// repository to the mirror repository, thus a lock created for the source
+// images can be used by the images rewritten by --image-repository.
+func (l *Lock) MirrorDigest(image, mirror, source string) (string, bool) {
+	name, ok := strings.CutPrefix(image, strings.TrimSuffix(mirror, "/")+"/")
+	if !ok {
+		return "", false
+	}
+	return l.Digest(strings.TrimSuffix(source, "/") + "/" + name)
+}
+
+// String returns the lock as YAML.
+func (l *Lock) String() string {
+	data, err := yaml.Marshal(l)
+	if err != nil {
+		return ""
+	}
+	return string(data)
+}
+
+// Pin returns the image referenced by digest, e.g. quay.io/opct/sonobuoy@sha256:<digest>.
+func Pin(image, digest string) string {
+	return fmt.Sprintf("%s@%s", Repository(image), digest)
+}
+
+// IsPinned returns true when the image is referenced by digest.
+func IsPinned(image string) bool {
+	return strings.Contains(image, "@")
+}
+
+// Repository returns the image reference without tag and digest.
+func Repository(image string) string {
+	if idx := strings.Index(image, "@"); idx > 0 {
+		image = image[:idx]
+	}
+	if idx := strings.LastIndex(image, ":"); idx > strings.LastIndex(image, "/") {
+		image = image[:idx]
+	}
+	return image
+}
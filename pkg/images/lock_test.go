@@ -0,0 +1,118 @@
+package images
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+var (
+	testDigest  = "sha256:" + strings.Repeat("a", 64)
+	testDigest2 = "sha256:" + strings.Repeat("b", 64)
+)
+
+func TestPin(t *testing.T) {
+	tests := []struct {
+		name  string
+		image string
+		want  string
+	}{
+		{name: "tag", image: "quay.io/opct/sonobuoy:v0.57.1", want: "quay.io/opct/sonobuoy@" + testDigest},
+		{name: "no tag", image: "quay.io/opct/sonobuoy", want: "quay.io/opct/sonobuoy@" + testDigest},
+		{name: "registry port", image: "mirror.local:5000/opct/sonobuoy:v0.57.1", want: "mirror.local:5000/opct/sonobuoy@" + testDigest},
+		{name: "pinned", image: "quay.io/opct/sonobuoy@" + testDigest2, want: "quay.io/opct/sonobuoy@" + testDigest},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			assert.Equal(t, tc.want, Pin(tc.image, testDigest))
+		})
+	}
+}
+
+func TestLockDigest(t *testing.T) {
+	lock := NewLock()
+	lock.Add("quay.io/opct/sonobuoy:v0.57.1", testDigest)
+	lock.Add("quay.io/opct/plugin-openshift-tests:v0.5.0", testDigest2)
+
+	digest, ok := lock.Digest("quay.io/opct/sonobuoy:v0.57.1")
+	assert.True(t, ok)
+	assert.Equal(t, testDigest, digest)
+
+	// images with the same name and tag in other repositories are not matched.
+	_, ok = lock.Digest("mirror.local:5000/opct/plugin-openshift-tests:v0.5.0")
+	assert.False(t, ok)
+
+	_, ok = lock.Digest("quay.io/opct/sonobuoy:v0.56.0")
+	assert.False(t, ok)
+
+	assert.Equal(t, "quay.io/opct/plugin-openshift-tests:v0.5.0", lock.Images[0].Image)
+}
+
+func TestLockMirrorDigest(t *testing.T) {
+	lock := NewLock()
+	lock.Add("quay.io/opct/plugin-openshift-tests:v0.5.0", testDigest)
+	lock.Add("quay.io/other/plugin-openshift-tests:v0.5.0", testDigest2)
+
+	tests := []struct {
+		name   string
+		image  string
+		mirror string
+		want   string
+		found  bool
+	}{
+		{name: "mirrored", image: "mirror.local:5000/opct/plugin-openshift-tests:v0.5.0", mirror: "mirror.local:5000/opct", want: testDigest, found: true},
+		{name: "mirror with trailing slash", image: "mirror.local:5000/opct/plugin-openshift-tests:v0.5.0", mirror: "mirror.local:5000/opct/", want: testDigest, found: true},
+		{name: "outside the mirror", image: "other.local/opct/plugin-openshift-tests:v0.5.0", mirror: "mirror.local:5000/opct"},
+		{name: "tag not locked", image: "mirror.local:5000/opct/plugin-openshift-tests:v0.6.0", mirror: "mirror.local:5000/opct"},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			digest, ok := lock.MirrorDigest(tc.image, tc.mirror, "quay.io/opct")
+			assert.Equal(t, tc.found, ok)
+			assert.Equal(t, tc.want, digest)
+		})
+	}
+}
+
+func TestLoadLock(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+		wantErr string
+	}{
+		{
+			name:    "valid",
+			content: NewLock().String(),
+		},
+		{
+			name:    "invalid kind",
+			content: "apiVersion: " + LockAPIVersion + "\nkind: Other\nimages: []\n",
+			wantErr: "unsupported kind",
+		},
+		{
+			name:    "invalid digest",
+			content: "apiVersion: " + LockAPIVersion + "\nkind: " + LockKind + "\nimages:\n- image: quay.io/opct/sonobuoy:v0.57.1\n  digest: sha256:1234\n",
+			wantErr: "invalid digest",
+		},
+		{
+			name:    "unknown field",
+			content: "apiVersion: " + LockAPIVersion + "\nkind: " + LockKind + "\nimage: []\n",
+			wantErr: "unable to parse",
+		},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			file := filepath.Join(t.TempDir(), "images-lock.yaml")
+			assert.NoError(t, os.WriteFile(file, []byte(tc.content), 0644))
+			_, err := LoadLock(file)
+			if tc.wantErr == "" {
+				assert.NoError(t, err)
+				return
+			}
+			assert.ErrorContains(t, err, tc.wantErr)
+		})
+	}
+}
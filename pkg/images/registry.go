@@ -0,0 +1,203 @@
+package images
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+const (
+	dockerHubRegistry = "registry-1.docker.io"
+	registryTimeout   = 30 * time.Second
+)
+
+// manifestMediaTypes are the accepted manifest types, preferring the index
+// to keep the digest of multi-arch images.
+var manifestMediaTypes = []string{
+	"application/vnd.oci.image.index.v1+json",
+	"application/vnd.docker.distribution.manifest.list.v2+json",
+	"application/vnd.oci.image.manifest.v1+json",
+	"application/vnd.docker.distribution.manifest.v2+json",
+}
+
+// Resolver resolves the digest of an image reference.
+type Resolver interface {
+	Resolve(ctx context.Context, image string) (string, error)
+}
+
+// RegistryResolver resolves the digest from the registry API (v2) with
+// anonymous access.
+type RegistryResolver struct {
+	Client *http.Client
+}
+
+// NewRegistryResolver creates the resolver querying the registries.
+func NewRegistryResolver() *RegistryResolver {
+	return &RegistryResolver{Client: &http.Client{Timeout: registryTimeout}}
+}
+
+// parseReference returns the registry host, repository and tag of the image.
+func parseReference(image string) (string, string, string, error) {
+	if IsPinned(image) {
+		return "", "", "", fmt.Errorf("image %s is already pinned by digest", image)
+	}
+	repo := Repository(image)
+	tag := "latest"
+	if len(repo) < len(image) {
+		tag = image[len(repo)+1:]
+	}
+	parts := strings.SplitN(repo, "/", 2)
+	if len(parts) == 1 || (!strings.ContainsAny(parts[0], ".:") && parts[0] != "localhost") {
+		if len(parts) == 1 {
+			return dockerHubRegistry, "library/" + repo, tag, nil
+		}
+		return dockerHubRegistry, repo, tag, nil
+	}
+	if parts[0] == "docker.io" {
+		parts[0] = dockerHubRegistry
+	}
+	return parts[0], parts[1], tag, nil
+}
+
+// Resolve returns the manifest digest of the image.
+func (r *RegistryResolver) Resolve(ctx context.Context, image string) (string, error) {
+	host, repo, tag, err := parseReference(image)
+	if err != nil {
+		return "", err
+	}
+	manifestURL := fmt.Sprintf("https://%s/v2/%s/manifests/%s", host, repo, tag)
+
+	var token string
+	resp, err := r.request(ctx, http.MethodHead, manifestURL, token)
+	if err != nil {
+		return "", fmt.Errorf("unable to resolve image %s: %w", image, err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode == http.StatusUnauthorized {
+		token, err = r.token(ctx, resp.Header.Get("WWW-Authenticate"), repo)
+		if err != nil {
+			return "", fmt.Errorf("unable to authenticate to resolve image %s: %w", image, err)
+		}
+		resp, err = r.request(ctx, http.MethodHead, manifestURL, token)
+		if err != nil {
+			return "", fmt.Errorf("unable to resolve image %s: %w", image, err)
+		}
+		resp.Body.Close()
+	}
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("unable to resolve image %s: registry returned %s", image, resp.Status)
+	}
+	digest := resp.Header.Get("Docker-Content-Digest")
+	if digest == "" {
+		// Registries are not required to return the digest to HEAD requests.
+		return r.digestFromManifest(ctx, manifestURL, token, image)
+	}
+	if !digestRegex.MatchString(digest) {
+		return "", fmt.Errorf("unable to resolve image %s: invalid digest %q", image, digest)
+	}
+	return digest, nil
+}
+
+func (r *RegistryResolver) request(ctx context.Context, method, url, token string) (*http.Response, error) {
+	req, err := http.NewRequestWithContext(ctx, method, url, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Accept", strings.Join(manifestMediaTypes, ", "))
+	if token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+	return r.Client.Do(req)
+}
+
+// digestFromManifest calculates the digest of the manifest content.
+func (r *RegistryResolver) digestFromManifest(ctx context.Context, manifestURL, token, image string) (string, error) {
+	resp, err := r.request(ctx, http.MethodGet, manifestURL, token)
+	if err != nil {
+		return "", fmt.Errorf("unable to resolve image %s: %w", image, err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("unable to resolve image %s: registry returned %s", image, resp.Status)
+	}
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return "", fmt.Errorf("unable to resolve image %s: %w", image, err)
+	}
+	return fmt.Sprintf("sha256:%x", sha256.Sum256(data)), nil
+}
+
+// token requests an anonymous pull token from the authorization server
+// described by the challenge, e.g.: Bearer realm="https://auth",service="registry".
+func (r *RegistryResolver) token(ctx context.Context, challenge, repo string) (string, error) {
+	if !strings.HasPrefix(challenge, "Bearer ") {
+		return "", fmt.Errorf("unsupported authentication challenge %q, use an image lock file", challenge)
+	}
+	params := map[string]string{}
+	for _, item := range strings.Split(strings.TrimPrefix(challenge, "Bearer "), ",") {
+		kv := strings.SplitN(strings.TrimSpace(item), "=", 2)
+		if len(kv) == 2 {
+			params[kv[0]] = strings.Trim(kv[1], `"`)
+		}
+	}
+	if params["realm"] == "" {
+		return "", fmt.Errorf("missing realm in the authentication challenge %q", challenge)
+	}
+	query := url.Values{}
+	if params["service"] != "" {
+		query.Set("service", params["service"])
+	}
+	query.Set("scope", fmt.Sprintf("repository:%s:pull", repo))
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, params["realm"]+"?"+query.Encode(), nil)
+	if err != nil {
+		return "", err
+	}
+	resp, err := r.Client.Do(req)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("authorization server returned %s", resp.Status)
+	}
+	body := struct {
+		Token       string `json:"token"`
+		AccessToken string `json:"access_token"`
+	}{}
+	if err := json.NewDecoder(resp.Body).Decode(&body); err != nil {
+		return "", err
+	}
+	if body.Token != "" {
+		return body.Token, nil
+	}
+	return body.AccessToken, nil
+}
+
+// LockResolver resolves the digest from a lock file, used in offline runs.
+type LockResolver struct {
+	Lock *Lock
+
+	// Mirror is the repository the images were copied to from the Source
+	// repository, set when the images are rewritten by --image-repository.
+	Mirror string
+	Source string
+}
+
+// Resolve returns the digest of the image in the lock.
+func (r *LockResolver) Resolve(ctx context.Context, image string) (string, error) {
+	digest, ok := r.Lock.Digest(image)
+	if !ok && r.Mirror != "" {
+		digest, ok = r.Lock.MirrorDigest(image, r.Mirror, r.Source)
+	}
+	if !ok {
+		return "", fmt.Errorf("image %s not found in the image lock file", image)
+	}
+	return digest, nil
+}
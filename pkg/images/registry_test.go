@@ -0,0 +1,84 @@
+package images
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseReference(t *testing.T) {
+	tests := []struct {
+		image string
+		host  string
+		repo  string
+		tag   string
+	}{
+		{image: "quay.io/opct/sonobuoy:v0.57.1", host: "quay.io", repo: "opct/sonobuoy", tag: "v0.57.1"},
+		{image: "busybox", host: dockerHubRegistry, repo: "library/busybox", tag: "latest"},
+		{image: "docker.io/library/busybox:1.36", host: dockerHubRegistry, repo: "library/busybox", tag: "1.36"},
+		{image: "localhost:5000/opct/sonobuoy:v0.57.1", host: "localhost:5000", repo: "opct/sonobuoy", tag: "v0.57.1"},
+	}
+	for _, tc := range tests {
+		t.Run(tc.image, func(t *testing.T) {
+			host, repo, tag, err := parseReference(tc.image)
+			assert.NoError(t, err)
+			assert.Equal(t, tc.host, host)
+			assert.Equal(t, tc.repo, repo)
+			assert.Equal(t, tc.tag, tag)
+		})
+	}
+	_, _, _, err := parseReference("quay.io/opct/sonobuoy@" + testDigest)
+	assert.Error(t, err)
+}
+
+func TestRegistryResolver(t *testing.T) {
+	var server *httptest.Server
+	server = httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.URL.Path == "/token":
+			assert.Equal(t, "repository:opct/sonobuoy:pull", r.URL.Query().Get("scope"))
+			fmt.Fprint(w, `{"token":"secret"}`)
+		case r.Header.Get("Authorization") != "Bearer secret":
+			w.Header().Set("WWW-Authenticate", fmt.Sprintf(`Bearer realm="%s/token",service="test"`, server.URL))
+			w.WriteHeader(http.StatusUnauthorized)
+		case r.URL.Path == "/v2/opct/sonobuoy/manifests/v0.57.1":
+			w.Header().Set("Docker-Content-Digest", testDigest)
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+
+	host := strings.TrimPrefix(server.URL, "https://")
+	resolver := &RegistryResolver{Client: server.Client()}
+
+	digest, err := resolver.Resolve(context.TODO(), host+"/opct/sonobuoy:v0.57.1")
+	assert.NoError(t, err)
+	assert.Equal(t, testDigest, digest)
+
+	_, err = resolver.Resolve(context.TODO(), host+"/opct/sonobuoy:v0.0.0")
+	assert.ErrorContains(t, err, "404")
+}
+
+func TestLockResolver(t *testing.T) {
+	lock := NewLock()
+	lock.Add("quay.io/opct/sonobuoy:v0.57.1", testDigest)
+
+	resolver := &LockResolver{Lock: lock}
+	digest, err := resolver.Resolve(context.TODO(), "quay.io/opct/sonobuoy:v0.57.1")
+	assert.NoError(t, err)
+	assert.Equal(t, testDigest, digest)
+
+	_, err = resolver.Resolve(context.TODO(), "mirror.local/opct/sonobuoy:v0.57.1")
+	assert.ErrorContains(t, err, "not found in the image lock file")
+
+	resolver = &LockResolver{Lock: lock, Mirror: "mirror.local/opct", Source: "quay.io/opct"}
+	digest, err = resolver.Resolve(context.TODO(), "mirror.local/opct/sonobuoy:v0.57.1")
+	assert.NoError(t, err)
+	assert.Equal(t, testDigest, digest)
+}
@@ -0,0 +1,220 @@
+// Package inventory records the objects created by the run in the validation
+// environment, allowing the destroy to remove exactly the objects of the run.
+package inventory
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+
+	v1 "k8s.io/api/core/v1"
+	kerrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+
+	"github.com/redhat-openshift-ecosystem/provider-certification-tool/pkg"
+	"github.com/redhat-openshift-ecosystem/provider-certification-tool/pkg/runid"
+)
+
+const (
+	// ConfigMapName is the ConfigMap storing the inventory in the namespace
+	// of the validation environment.
+	ConfigMapName = "opct-inventory"
+	// ConfigMapKey is the key of the inventory in the ConfigMap.
+	ConfigMapKey = "inventory.json"
+
+	// RequesterAnnotation is set by OpenShift to the namespaces created by a
+	// project request, with the user requesting it.
+	RequesterAnnotation = "openshift.io/requester"
+	// E2EFrameworkLabel and E2ERunLabel are set by the Kubernetes e2e
+	// framework to the namespaces created by the tests.
+	E2EFrameworkLabel = "e2e-framework"
+	E2ERunLabel       = "e2e-run"
+
+	// openShiftTestUserSuffix is the suffix of the user created by the
+	// OpenShift e2e suites to request the project of a test, named
+	// <namespace>-user.
+	openShiftTestUserSuffix = "-user"
+
+	// serviceAccountRequesterPrefix is the prefix of the requester when the
+	// project is requested by a service account.
+	serviceAccountRequesterPrefix = "system:serviceaccount:"
+)
+
+// Kinds of the objects recorded in the inventory.
+const (
+	KindNamespace          = "Namespace"
+	KindServiceAccount     = "ServiceAccount"
+	KindConfigMap          = "ConfigMap"
+	KindClusterRole        = "ClusterRole"
+	KindClusterRoleBinding = "ClusterRoleBinding"
+	KindMachineConfigPool  = "MachineConfigPool"
+)
+
+var (
+	// reTestNamespace matches the namespaces created by the e2e tests.
+	reTestNamespace = regexp.MustCompile(`^e2e-.*`)
+	// reOpenShiftTestNamespace matches the projects created by the OpenShift
+	// e2e suites, e2e-test-<base name>-<random suffix>.
+	reOpenShiftTestNamespace = regexp.MustCompile(`^e2e-test-.*`)
+)
+
+// Object is the reference of an object created by the run.
+type Object struct {
+	Kind      string `json:"kind"`
+	Name      string `json:"name"`
+	Namespace string `json:"namespace,omitempty"`
+}
+
+// String returns the object reference, e.g. ConfigMap opct/opct-inventory.
+func (o Object) String() string {
+	if o.Namespace == "" {
+		return fmt.Sprintf("%s %s", o.Kind, o.Name)
+	}
+	return fmt.Sprintf("%s %s/%s", o.Kind, o.Namespace, o.Name)
+}
+
+// Inventory is the list of objects created by the run.
+type Inventory struct {
+	RunID     string    `json:"runId,omitempty"`
+	Namespace string    `json:"namespace"`
+	CreatedAt time.Time `json:"createdAt"`
+	Objects   []Object  `json:"objects"`
+}
+
+// New creates the inventory of the run in the environment namespace.
+func New(runID string) *Inventory {
+	return &Inventory{
+		RunID:     runID,
+		Namespace: pkg.GetNamespace(),
+		CreatedAt: time.Now().UTC().Truncate(time.Second),
+		Objects:   []Object{},
+	}
+}
+
+// Add records the object, ignoring objects already recorded.
+func (inv *Inventory) Add(kind, namespace, name string) {
+	if inv.Has(kind, namespace, name) {
+		return
+	}
+	inv.Objects = append(inv.Objects, Object{Kind: kind, Name: name, Namespace: namespace})
+}
+
+// Has returns true when the object is recorded.
+func (inv *Inventory) Has(kind, namespace, name string) bool {
+	if inv == nil {
+		return false
+	}
+	for _, o := range inv.Objects {
+		if o.Kind == kind && o.Namespace == namespace && o.Name == name {
+			return true
+		}
+	}
+	return false
+}
+
+// ByKind returns the objects of the kind, in the order they were created.
+func (inv *Inventory) ByKind(kind string) []Object {
+	objects := []Object{}
+	if inv == nil {
+		return objects
+	}
+	for _, o := range inv.Objects {
+		if o.Kind == kind {
+			objects = append(objects, o)
+		}
+	}
+	return objects
+}
+
+// IsTestNamespace returns true when the namespace was created by the e2e
+// suites after the validation environment:
+//   - namespaces requested by a service account of the environment namespace;
+//   - namespaces of the Kubernetes e2e framework, labeled with e2e-framework
+//     and e2e-run, and not created by a project request;
+//   - projects of the OpenShift e2e suites, e2e-test-.*, requested by the user
+//     <namespace>-user created by the test.
+//
+// The tests don't label the namespaces with the run, only the namespaces
+// requested by the environment are known to be created by the run, the others
+// also match the namespaces of e2e executions running at the same time.
+func (inv *Inventory) IsTestNamespace(ns *v1.Namespace, since time.Time) bool {
+	if inv == nil || !reTestNamespace.MatchString(ns.Name) {
+		return false
+	}
+	if ns.CreationTimestamp.Time.Before(since) {
+		return false
+	}
+	if inv.IsRequestedByEnvironment(ns) {
+		return true
+	}
+	requester, requested := ns.Annotations[RequesterAnnotation]
+	if requested {
+		return reOpenShiftTestNamespace.MatchString(ns.Name) && requester == ns.Name+openShiftTestUserSuffix
+	}
+	_, framework := ns.Labels[E2EFrameworkLabel]
+	_, run := ns.Labels[E2ERunLabel]
+	return framework && run
+}
+
+// IsRequestedByEnvironment returns true when the namespace was requested by a
+// service account of the environment namespace, e.g. the Sonobuoy service
+// account running the tests.
+func (inv *Inventory) IsRequestedByEnvironment(ns *v1.Namespace) bool {
+	if inv == nil {
+		return false
+	}
+	return strings.HasPrefix(ns.Annotations[RequesterAnnotation], serviceAccountRequesterPrefix+inv.Namespace+":")
+}
+
+// IsTestNamespaceName returns true when the name matches the namespaces
+// created by the e2e tests.
+func IsTestNamespaceName(name string) bool {
+	return reTestNamespace.MatchString(name)
+}
+
+// Save creates or updates the inventory ConfigMap, recording it.
+func Save(ctx context.Context, kclient kubernetes.Interface, inv *Inventory) error {
+	inv.Add(KindConfigMap, inv.Namespace, ConfigMapName)
+	data, err := json.Marshal(inv)
+	if err != nil {
+		return err
+	}
+	cm := &v1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      ConfigMapName,
+			Namespace: inv.Namespace,
+			Labels:    runid.Labels(inv.RunID),
+		},
+		Data: map[string]string{ConfigMapKey: string(data)},
+	}
+	cms := kclient.CoreV1().ConfigMaps(inv.Namespace)
+	_, err = cms.Create(ctx, cm, metav1.CreateOptions{})
+	if kerrors.IsAlreadyExists(err) {
+		_, err = cms.Update(ctx, cm, metav1.UpdateOptions{})
+	}
+	if err != nil {
+		return fmt.Errorf("unable to save the inventory: %w", err)
+	}
+	return nil
+}
+
+// Load reads the inventory of the validation environment, returning nil when
+// the environment was created by a version without inventory.
+func Load(ctx context.Context, kclient kubernetes.Interface) (*Inventory, error) {
+	cm, err := kclient.CoreV1().ConfigMaps(pkg.GetNamespace()).Get(ctx, ConfigMapName, metav1.GetOptions{})
+	if err != nil {
+		if kerrors.IsNotFound(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	inv := &Inventory{}
+	if err := json.Unmarshal([]byte(cm.Data[ConfigMapKey]), inv); err != nil {
+		return nil, fmt.Errorf("unable to parse the inventory: %w", err)
+	}
+	return inv, nil
+}
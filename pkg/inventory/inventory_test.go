@@ -0,0 +1,167 @@
+package inventory
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+	"sigs.k8s.io/yaml"
+
+	"github.com/redhat-openshift-ecosystem/provider-certification-tool/pkg"
+	"github.com/redhat-openshift-ecosystem/provider-certification-tool/pkg/runid"
+)
+
+func TestSaveLoad(t *testing.T) {
+	kclient := fake.NewSimpleClientset()
+
+	inv, err := Load(context.TODO(), kclient)
+	assert.NoError(t, err)
+	assert.Nil(t, inv, "environment created without inventory")
+
+	inv = New("id")
+	inv.Add(KindNamespace, "", pkg.GetNamespace())
+	inv.Add(KindNamespace, "", pkg.GetNamespace())
+	require.NoError(t, Save(context.TODO(), kclient, inv))
+
+	// Saving again updates the inventory.
+	inv.Add(KindClusterRole, "", pkg.GetPrivilegedClusterRole())
+	require.NoError(t, Save(context.TODO(), kclient, inv))
+
+	got, err := Load(context.TODO(), kclient)
+	require.NoError(t, err)
+	assert.Equal(t, "id", got.RunID)
+	assert.Equal(t, []Object{
+		{Kind: KindNamespace, Name: pkg.GetNamespace()},
+		{Kind: KindConfigMap, Name: ConfigMapName, Namespace: pkg.GetNamespace()},
+		{Kind: KindClusterRole, Name: pkg.GetPrivilegedClusterRole()},
+	}, got.Objects)
+	assert.Len(t, got.ByKind(KindNamespace), 1)
+
+	cm, err := kclient.CoreV1().ConfigMaps(pkg.GetNamespace()).Get(context.TODO(), ConfigMapName, metav1.GetOptions{})
+	require.NoError(t, err)
+	assert.Equal(t, "id", cm.Labels[runid.Label])
+}
+
+// Namespaces as created in the cluster by the e2e suites.
+const (
+	originProjectManifest = `apiVersion: v1
+kind: Namespace
+metadata:
+  annotations:
+    openshift.io/description: ""
+    openshift.io/display-name: ""
+    openshift.io/requester: e2e-test-router-metrics-qmgxk-user
+    openshift.io/sa.scc.mcs: s0:c27,c14
+    openshift.io/sa.scc.supplemental-groups: 1000730000/10000
+    openshift.io/sa.scc.uid-range: 1000730000/10000
+  creationTimestamp: "2024-01-01T13:00:00Z"
+  labels:
+    kubernetes.io/metadata.name: e2e-test-router-metrics-qmgxk
+    pod-security.kubernetes.io/audit: restricted
+    pod-security.kubernetes.io/audit-version: v1.24
+    pod-security.kubernetes.io/warn: restricted
+    pod-security.kubernetes.io/warn-version: v1.24
+  name: e2e-test-router-metrics-qmgxk
+spec:
+  finalizers:
+  - kubernetes
+`
+	frameworkNamespaceManifest = `apiVersion: v1
+kind: Namespace
+metadata:
+  annotations:
+    openshift.io/sa.scc.mcs: s0:c28,c17
+    openshift.io/sa.scc.supplemental-groups: 1000780000/10000
+    openshift.io/sa.scc.uid-range: 1000780000/10000
+  creationTimestamp: "2024-01-01T13:00:00Z"
+  labels:
+    e2e-framework: statefulset
+    e2e-run: 3b0f8c1e-5a4e-4c55-9f3d-2d6b1f3a8c41
+    kubernetes.io/metadata.name: e2e-statefulset-5932
+    pod-security.kubernetes.io/enforce: baseline
+  name: e2e-statefulset-5932
+spec:
+  finalizers:
+  - kubernetes
+`
+)
+
+func TestIsTestNamespace(t *testing.T) {
+	since := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	load := func(manifest string) *v1.Namespace {
+		ns := &v1.Namespace{}
+		require.NoError(t, yaml.Unmarshal([]byte(manifest), ns))
+		return ns
+	}
+	tests := []struct {
+		name   string
+		base   string
+		mutate func(ns *v1.Namespace)
+		want   bool
+	}{
+		{
+			name: "project of the OpenShift e2e suites",
+			base: originProjectManifest,
+			want: true,
+		},
+		{
+			name: "namespace of the Kubernetes e2e framework",
+			base: frameworkNamespaceManifest,
+			want: true,
+		},
+		{
+			name:   "project created before the environment",
+			base:   originProjectManifest,
+			mutate: func(ns *v1.Namespace) { ns.CreationTimestamp = metav1.NewTime(since.Add(-time.Minute)) },
+		},
+		{
+			name:   "project requested by other user",
+			base:   originProjectManifest,
+			mutate: func(ns *v1.Namespace) { ns.Annotations[RequesterAnnotation] = "alice" },
+		},
+		{
+			name: "project requested by other user with e2e labels",
+			base: frameworkNamespaceManifest,
+			mutate: func(ns *v1.Namespace) {
+				ns.Annotations[RequesterAnnotation] = "system:serviceaccount:team:e2e"
+			},
+		},
+		{
+			name: "namespace requested by the environment",
+			base: frameworkNamespaceManifest,
+			mutate: func(ns *v1.Namespace) {
+				ns.Labels = nil
+				ns.Annotations[RequesterAnnotation] = "system:serviceaccount:" + pkg.GetNamespace() + ":sonobuoy-serviceaccount"
+			},
+			want: true,
+		},
+		{
+			name:   "namespace without the e2e run",
+			base:   frameworkNamespaceManifest,
+			mutate: func(ns *v1.Namespace) { delete(ns.Labels, E2ERunLabel) },
+		},
+		{
+			name:   "namespace not matching e2e-.*",
+			base:   frameworkNamespaceManifest,
+			mutate: func(ns *v1.Namespace) { ns.Name = "statefulset-5932" },
+		},
+	}
+	inv := New("id")
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			ns := load(test.base)
+			if test.mutate != nil {
+				test.mutate(ns)
+			}
+			assert.Equal(t, test.want, inv.IsTestNamespace(ns, since))
+		})
+	}
+
+	var nilInventory *Inventory
+	assert.False(t, nilInventory.IsTestNamespace(load(originProjectManifest), since))
+}
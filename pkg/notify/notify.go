@@ -0,0 +1,211 @@
+// Package notify sends the lifecycle events of the validation environment to
+// a webhook, allowing long executions to be followed without watching the
+// status.
+package notify
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+	"github.com/spf13/pflag"
+
+	"github.com/redhat-openshift-ecosystem/provider-certification-tool/pkg"
+)
+
+const (
+	EventPluginsScheduled = "plugins-scheduled"
+	EventPluginFinished   = "plugin-finished"
+	EventPluginFailed     = "plugin-failed"
+	EventResultsReady     = "results-ready"
+	EventReportChecks     = "report-checks"
+
+	FormatJSON  = "json"
+	FormatSlack = "slack"
+
+	// EnvWebhook is the environment variable with the webhook URL, used when
+	// the flag is not set to keep the URL out of the command line.
+	EnvWebhook = "OPCT_NOTIFY_WEBHOOK"
+
+	flagWebhook = "notify-webhook"
+	flagFormat  = "notify-format"
+
+	webhookTimeout = 10 * time.Second
+)
+
+// Event is a lifecycle event of the validation environment.
+type Event struct {
+	Type      string    `json:"event"`
+	RunID     string    `json:"runId,omitempty"`
+	Namespace string    `json:"namespace"`
+	Time      time.Time `json:"time"`
+	Message   string    `json:"message"`
+
+	// Plugin is the plugin of the plugin events.
+	Plugin string `json:"plugin,omitempty"`
+
+	// Plugins are the plugins scheduled.
+	Plugins []string `json:"plugins,omitempty"`
+
+	// Checks is the summary of the report checks.
+	Checks *ChecksSummary `json:"checks,omitempty"`
+}
+
+// ChecksSummary is the number of report checks by result.
+type ChecksSummary struct {
+	Pass int `json:"pass"`
+	Fail int `json:"fail"`
+	Warn int `json:"warn"`
+}
+
+// NewEvent creates the event of the validation environment.
+func NewEvent(eventType, runID, message string) *Event {
+	return &Event{
+		Type:      eventType,
+		RunID:     runID,
+		Namespace: pkg.GetNamespace(),
+		Time:      time.Now().UTC().Truncate(time.Second),
+		Message:   message,
+	}
+}
+
+// slackPayload is the message of Slack incoming webhooks.
+type slackPayload struct {
+	Text string `json:"text"`
+}
+
+// Notifier posts the events to the webhook.
+type Notifier struct {
+	URL    string
+	Format string
+	Client *http.Client
+}
+
+// NewNotifier creates the notifier posting to the webhook URL in the format.
+func NewNotifier(webhook, format string) (*Notifier, error) {
+	if format == "" {
+		format = FormatJSON
+	}
+	if format != FormatJSON && format != FormatSlack {
+		return nil, fmt.Errorf("invalid notify format %q, allowed values: %s, %s", format, FormatJSON, FormatSlack)
+	}
+	u, err := url.Parse(webhook)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return nil, fmt.Errorf("invalid notify webhook URL, an http(s) URL is expected")
+	}
+	return &Notifier{
+		URL:    webhook,
+		Format: format,
+		Client: &http.Client{Timeout: webhookTimeout},
+	}, nil
+}
+
+// AddFlags adds the notification flags to the command.
+func AddFlags(fs *pflag.FlagSet) {
+	fs.String(flagWebhook, "", fmt.Sprintf("Webhook URL to notify the lifecycle events of the execution. Default: the environment variable %s.", EnvWebhook))
+	fs.String(flagFormat, FormatJSON, "Payload format of the webhook notifications. Available: json, slack.")
+}
+
+// NewNotifierFromFlags creates the notifier from the flags added by AddFlags,
+// returning nil when the webhook is not set.
+func NewNotifierFromFlags(fs *pflag.FlagSet) (*Notifier, error) {
+	webhook, err := fs.GetString(flagWebhook)
+	if err != nil {
+		return nil, err
+	}
+	if webhook == "" {
+		webhook = os.Getenv(EnvWebhook)
+	}
+	if webhook == "" {
+		return nil, nil
+	}
+	format, err := fs.GetString(flagFormat)
+	if err != nil {
+		return nil, err
+	}
+	return NewNotifier(webhook, format)
+}
+
+// Notify posts the event to the webhook. Notifications are best-effort,
+// failures are logged without interrupting the execution. Nil notifiers,
+// when the webhook is not set, are ignored.
+func (n *Notifier) Notify(ctx context.Context, ev *Event) {
+	if n == nil || ev == nil {
+		return
+	}
+	if err := n.Post(ctx, ev); err != nil {
+		log.Warnf("Unable to notify the event %s: %v", ev.Type, err)
+		return
+	}
+	log.Debugf("Event %s notified", ev.Type)
+}
+
+// Post posts the event to the webhook, returning error when the webhook does
+// not accept it.
+func (n *Notifier) Post(ctx context.Context, ev *Event) error {
+	var payload interface{} = ev
+	if n.Format == FormatSlack {
+		payload = &slackPayload{Text: SlackText(ev)}
+	}
+	data, err := json.Marshal(payload)
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, n.URL, bytes.NewReader(data))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	client := n.Client
+	if client == nil {
+		client = http.DefaultClient
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		// The URL may have credentials, e.g. Slack webhooks, the error is
+		// reported without it.
+		if uerr, ok := err.(*url.Error); ok {
+			err = uerr.Err
+		}
+		return err
+	}
+	defer resp.Body.Close()
+	_, _ = io.Copy(io.Discard, resp.Body)
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return fmt.Errorf("webhook returned status %d", resp.StatusCode)
+	}
+	return nil
+}
+
+// SlackText returns the message of the event to Slack.
+func SlackText(ev *Event) string {
+	icon := ":information_source:"
+	switch ev.Type {
+	case EventPluginFinished, EventResultsReady:
+		icon = ":white_check_mark:"
+	case EventPluginFailed:
+		icon = ":x:"
+	case EventReportChecks:
+		icon = ":white_check_mark:"
+		if ev.Checks != nil && ev.Checks.Fail > 0 {
+			icon = ":x:"
+		} else if ev.Checks != nil && ev.Checks.Warn > 0 {
+			icon = ":warning:"
+		}
+	}
+	lines := []string{fmt.Sprintf("%s *OPCT* %s", icon, ev.Message)}
+	details := []string{fmt.Sprintf("namespace `%s`", ev.Namespace)}
+	if ev.RunID != "" {
+		details = append(details, fmt.Sprintf("run ID `%s`", ev.RunID))
+	}
+	lines = append(lines, strings.Join(details, ", "))
+	return strings.Join(lines, "\n")
+}
@@ -0,0 +1,127 @@
+package notify
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/spf13/pflag"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_NewNotifier(t *testing.T) {
+	tests := []struct {
+		name    string
+		webhook string
+		format  string
+		want    string
+		wantErr bool
+	}{
+		{name: "default format", webhook: "https://hooks.example.com/x", want: FormatJSON},
+		{name: "slack", webhook: "https://hooks.slack.com/services/x", format: FormatSlack, want: FormatSlack},
+		{name: "invalid format", webhook: "https://hooks.example.com/x", format: "xml", wantErr: true},
+		{name: "invalid scheme", webhook: "ftp://hooks.example.com/x", wantErr: true},
+		{name: "missing host", webhook: "https:///x", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			n, err := NewNotifier(tt.webhook, tt.format)
+			if tt.wantErr {
+				assert.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, n.Format)
+		})
+	}
+}
+
+func Test_NewNotifierFromFlags(t *testing.T) {
+	newFlags := func(args ...string) *pflag.FlagSet {
+		fs := pflag.NewFlagSet("test", pflag.ContinueOnError)
+		AddFlags(fs)
+		require.NoError(t, fs.Parse(args))
+		return fs
+	}
+
+	t.Setenv(EnvWebhook, "")
+	n, err := NewNotifierFromFlags(newFlags())
+	assert.NoError(t, err)
+	assert.Nil(t, n, "notifier must not be created without webhook")
+
+	n, err = NewNotifierFromFlags(newFlags("--notify-webhook", "https://hooks.example.com/flag", "--notify-format", "slack"))
+	require.NoError(t, err)
+	assert.Equal(t, "https://hooks.example.com/flag", n.URL)
+	assert.Equal(t, FormatSlack, n.Format)
+
+	t.Setenv(EnvWebhook, "https://hooks.example.com/env")
+	n, err = NewNotifierFromFlags(newFlags())
+	require.NoError(t, err)
+	assert.Equal(t, "https://hooks.example.com/env", n.URL)
+
+	_, err = NewNotifierFromFlags(newFlags("--notify-format", "xml"))
+	assert.Error(t, err)
+}
+
+func Test_NotifierPost(t *testing.T) {
+	var body []byte
+	status := http.StatusOK
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, http.MethodPost, r.Method)
+		assert.Equal(t, "application/json", r.Header.Get("Content-Type"))
+		body, _ = io.ReadAll(r.Body)
+		w.WriteHeader(status)
+	}))
+	defer srv.Close()
+
+	ev := NewEvent(EventPluginFailed, "id", "plugin 20-openshift-conformance-validated failed")
+	ev.Plugin = "20-openshift-conformance-validated"
+
+	n, err := NewNotifier(srv.URL, FormatJSON)
+	require.NoError(t, err)
+	require.NoError(t, n.Post(context.Background(), ev))
+	got := &Event{}
+	require.NoError(t, json.Unmarshal(body, got))
+	assert.Equal(t, ev, got)
+
+	n.Format = FormatSlack
+	require.NoError(t, n.Post(context.Background(), ev))
+	slack := map[string]string{}
+	require.NoError(t, json.Unmarshal(body, &slack))
+	assert.Equal(t, SlackText(ev), slack["text"])
+	assert.Contains(t, slack["text"], ":x: *OPCT* plugin 20-openshift-conformance-validated failed")
+	assert.Contains(t, slack["text"], "run ID `id`")
+
+	status = http.StatusBadRequest
+	assert.EqualError(t, n.Post(context.Background(), ev), "webhook returned status 400")
+
+	// Failures must not interrupt the execution.
+	n.Notify(context.Background(), ev)
+	var nilNotifier *Notifier
+	nilNotifier.Notify(context.Background(), ev)
+}
+
+func Test_SlackText(t *testing.T) {
+	tests := []struct {
+		name   string
+		checks *ChecksSummary
+		want   string
+	}{
+		{name: "passed", checks: &ChecksSummary{Pass: 10}, want: ":white_check_mark:"},
+		{name: "warnings", checks: &ChecksSummary{Pass: 10, Warn: 1}, want: ":warning:"},
+		{name: "failures", checks: &ChecksSummary{Pass: 10, Warn: 1, Fail: 1}, want: ":x:"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ev := NewEvent(EventReportChecks, "", "report checks")
+			ev.Checks = tt.checks
+			text := SlackText(ev)
+			assert.Contains(t, text, tt.want+" *OPCT* report checks")
+			assert.NotContains(t, text, "run ID")
+		})
+	}
+}
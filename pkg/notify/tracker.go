@@ -0,0 +1,77 @@
+package notify
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/vmware-tanzu/sonobuoy/pkg/plugin/aggregation"
+)
+
+// Tracker detects the lifecycle events from the aggregator status, reporting
+// each event once while the status is watched.
+type Tracker struct {
+	RunID string
+
+	notified map[string]struct{}
+}
+
+// NewTracker creates the tracker of the run.
+func NewTracker(runID string) *Tracker {
+	return &Tracker{RunID: runID, notified: map[string]struct{}{}}
+}
+
+// Events returns the events not reported before: plugins finished or failed,
+// and the results ready to be retrieved when the aggregator completes.
+func (t *Tracker) Events(s *aggregation.Status) []*Event {
+	if s == nil {
+		return nil
+	}
+	plugins := make([]aggregation.PluginStatus, len(s.Plugins))
+	copy(plugins, s.Plugins)
+	sort.Slice(plugins, func(i, j int) bool {
+		return plugins[i].Plugin < plugins[j].Plugin
+	})
+
+	events := []*Event{}
+	for _, pl := range plugins {
+		var ev *Event
+		switch {
+		case pl.Status == aggregation.FailedStatus:
+			ev = NewEvent(EventPluginFailed, t.RunID, fmt.Sprintf("plugin %s failed%s", pl.Plugin, progressSuffix(pl)))
+		case pl.Status == aggregation.CompleteStatus || pl.ResultStatus != "":
+			ev = NewEvent(EventPluginFinished, t.RunID, fmt.Sprintf("plugin %s finished%s", pl.Plugin, progressSuffix(pl)))
+		default:
+			continue
+		}
+		ev.Plugin = pl.Plugin
+		if t.add(ev.Type + "/" + pl.Plugin) {
+			events = append(events, ev)
+		}
+	}
+
+	if s.Status == aggregation.CompleteStatus && t.add(EventResultsReady) {
+		events = append(events, NewEvent(EventResultsReady, t.RunID, "results are ready, collect them with 'opct retrieve'"))
+	}
+	return events
+}
+
+// add returns true when the key was not notified before.
+func (t *Tracker) add(key string) bool {
+	if _, ok := t.notified[key]; ok {
+		return false
+	}
+	t.notified[key] = struct{}{}
+	return true
+}
+
+// progressSuffix returns the result and the tests progress of the plugin.
+func progressSuffix(pl aggregation.PluginStatus) string {
+	suffix := ""
+	if pl.ResultStatus != "" {
+		suffix = fmt.Sprintf(" with result %s", pl.ResultStatus)
+	}
+	if p := pl.Progress; p != nil && p.Total > 0 {
+		suffix = fmt.Sprintf("%s (%d/%d tests, %d failures)", suffix, p.Completed, p.Total, len(p.Failures))
+	}
+	return suffix
+}
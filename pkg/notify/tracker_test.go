@@ -0,0 +1,57 @@
+package notify
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/vmware-tanzu/sonobuoy/pkg/plugin"
+	"github.com/vmware-tanzu/sonobuoy/pkg/plugin/aggregation"
+)
+
+func Test_TrackerEvents(t *testing.T) {
+	tracker := NewTracker("id")
+	eventTypes := func(events []*Event) []string {
+		types := []string{}
+		for _, ev := range events {
+			types = append(types, ev.Type+"/"+ev.Plugin)
+		}
+		return types
+	}
+
+	assert.Empty(t, tracker.Events(nil))
+
+	running := &aggregation.Status{
+		Status: aggregation.RunningStatus,
+		Plugins: []aggregation.PluginStatus{
+			{Plugin: "10-openshift-kube-conformance", Status: aggregation.RunningStatus},
+			{Plugin: "05-openshift-cluster-upgrade", Status: aggregation.CompleteStatus, ResultStatus: "passed"},
+		},
+	}
+	events := tracker.Events(running)
+	assert.Equal(t, []string{EventPluginFinished + "/05-openshift-cluster-upgrade"}, eventTypes(events))
+	assert.Equal(t, "id", events[0].RunID)
+	assert.Equal(t, "plugin 05-openshift-cluster-upgrade finished with result passed", events[0].Message)
+
+	// Events are notified once.
+	assert.Empty(t, tracker.Events(running))
+
+	complete := &aggregation.Status{
+		Status: aggregation.CompleteStatus,
+		Plugins: []aggregation.PluginStatus{
+			{
+				Plugin:       "10-openshift-kube-conformance",
+				Status:       aggregation.FailedStatus,
+				ResultStatus: "failed",
+				Progress:     &plugin.ProgressUpdate{Completed: 10, Total: 20, Failures: []string{"a"}},
+			},
+			{Plugin: "05-openshift-cluster-upgrade", Status: aggregation.CompleteStatus, ResultStatus: "passed"},
+		},
+	}
+	events = tracker.Events(complete)
+	assert.Equal(t, []string{
+		EventPluginFailed + "/10-openshift-kube-conformance",
+		EventResultsReady + "/",
+	}, eventTypes(events))
+	assert.Equal(t, "plugin 10-openshift-kube-conformance failed with result failed (10/20 tests, 1 failures)", events[0].Message)
+	assert.Empty(t, tracker.Events(complete))
+}
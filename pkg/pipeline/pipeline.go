@@ -0,0 +1,190 @@
+package pipeline
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	log "github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+
+	"github.com/redhat-openshift-ecosystem/provider-certification-tool/internal/report"
+	"github.com/redhat-openshift-ecosystem/provider-certification-tool/pkg/client"
+	reportcmd "github.com/redhat-openshift-ecosystem/provider-certification-tool/pkg/cmd/report"
+	"github.com/redhat-openshift-ecosystem/provider-certification-tool/pkg/destroy"
+	"github.com/redhat-openshift-ecosystem/provider-certification-tool/pkg/notify"
+	"github.com/redhat-openshift-ecosystem/provider-certification-tool/pkg/retrieve"
+	"github.com/redhat-openshift-ecosystem/provider-certification-tool/pkg/run"
+	"github.com/redhat-openshift-ecosystem/provider-certification-tool/pkg/runid"
+)
+
+// ExitCodeChecksFailed is the exit code when the pipeline completed and the
+// report has failed checks. Errors in any step exit with 1.
+const ExitCodeChecksFailed = 2
+
+// runExcludedFlags are the run flags not exposed by the pipeline, the status
+// is always watched until the execution completes.
+var runExcludedFlags = map[string]struct{}{
+	"watch":  {},
+	"render": {},
+}
+
+type PipelineOptions struct {
+	outputDir       string
+	saveTo          string
+	retrieveRetries int
+	destroy         bool
+	deleteMCP       bool
+	verbose         bool
+}
+
+func NewCmdPipeline() *cobra.Command {
+	o := &PipelineOptions{}
+	runCmd := run.NewCmdRun()
+
+	cmd := &cobra.Command{
+		Use:   "pipeline",
+		Short: "Run, collect and report the validation environment in one step",
+		Long: `Runs the validation environment, watching the status until completion, then
+collects the results archive, creates the report and, optionally, destroys the
+environment. The exit code reflects the report checks: 0 when all checks passed,
+2 when any check failed, and 1 when any step failed.`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			passed, err := o.Run(cmd.Context(), runCmd)
+			if err != nil {
+				log.WithError(err).Error("pipeline finished with errors")
+				return err
+			}
+			if !passed {
+				log.Errorf("pipeline finished with failed checks, review the report at %s", o.saveTo)
+				os.Exit(ExitCodeChecksFailed)
+			}
+			log.Info("Pipeline done! All checks passed.")
+			return nil
+		},
+	}
+
+	// Run options are shared with the run command.
+	runCmd.Flags().VisitAll(func(f *pflag.Flag) {
+		if _, ok := runExcludedFlags[f.Name]; ok {
+			return
+		}
+		cmd.Flags().AddFlag(f)
+	})
+	cmd.Flags().StringVar(&o.outputDir, "output-dir", ".", "Directory to save the results archive.")
+	cmd.Flags().StringVar(&o.saveTo, "save-to", "", "Directory to save the report. Default: the archive path without the extension.")
+	cmd.Flags().IntVar(&o.retrieveRetries, "retrieve-retries", retrieve.DefaultRetrieveRetryLimit, "Number of attempts to collect the results archive.")
+	cmd.Flags().BoolVar(&o.destroy, "destroy", false, "Destroy the validation environment after the results are collected.")
+	cmd.Flags().BoolVar(&o.deleteMCP, "delete-mcp", false, "Delete the MachineConfigPool created to the upgrade mode when destroying the environment.")
+	cmd.Flags().BoolVar(&o.verbose, "verbose", false, "Show test details of test failures in the report.")
+
+	return cmd
+}
+
+// Run executes the pipeline steps, returning true when all report checks
+// passed. The environment is destroyed, when requested, only when the results
+// were collected, keeping it to be inspected when the execution fails.
+func (o *PipelineOptions) Run(ctx context.Context, runCmd *cobra.Command) (bool, error) {
+	if o.retrieveRetries < 1 {
+		return false, fmt.Errorf("invalid --retrieve-retries %d, at least one attempt must be set", o.retrieveRetries)
+	}
+	if err := os.MkdirAll(o.outputDir, 0755); err != nil {
+		return false, fmt.Errorf("unable to create the output directory: %w", err)
+	}
+
+	// Notification flags are shared with the run command, which notifies the
+	// execution events. The pipeline notifies the report checks.
+	notifier, err := notify.NewNotifierFromFlags(runCmd.Flags())
+	if err != nil {
+		return false, err
+	}
+
+	log.Info("Pipeline step 1/4: running and watching the validation environment...")
+	if err := runCmd.Flags().Set("watch", "true"); err != nil {
+		return false, err
+	}
+	runCmd.SetContext(ctx)
+	if err := runCmd.PreRunE(runCmd, nil); err != nil {
+		return false, fmt.Errorf("pipeline step run failed: %w", err)
+	}
+	if err := runCmd.RunE(runCmd, nil); err != nil {
+		return false, fmt.Errorf("pipeline step run failed: %w", err)
+	}
+
+	log.Info("Pipeline step 2/4: collecting the results...")
+	kclient, sclient, err := client.CreateClients()
+	if err != nil {
+		return false, fmt.Errorf("pipeline step retrieve failed: %w", err)
+	}
+	files, err := retrieve.RetrieveResults(kclient, sclient, o.outputDir, o.retrieveRetries)
+	if err != nil {
+		return false, fmt.Errorf("pipeline step retrieve failed: %w", err)
+	}
+	archive, err := resultsArchive(files)
+	if err != nil {
+		return false, fmt.Errorf("pipeline step retrieve failed: %w", err)
+	}
+	if o.saveTo == "" {
+		o.saveTo = defaultReportDir(archive)
+	}
+	runID, err := runid.Get(ctx, kclient)
+	if err != nil {
+		log.Debugf("unable to read the run ID: %v", err)
+	}
+
+	log.Info("Pipeline step 3/4: creating the report...")
+	re, reportErr := reportcmd.SaveReport(archive, o.saveTo, o.verbose)
+	if reportErr == nil {
+		notifier.Notify(ctx, checksEvent(runID, re))
+	}
+
+	if o.destroy {
+		log.Info("Pipeline step 4/4: destroying the validation environment...")
+		d := destroy.NewDestroyOptions()
+		d.SetDeleteMCP(o.deleteMCP)
+		d.Destroy(ctx, kclient, sclient)
+	} else {
+		log.Info("Pipeline step 4/4: skipping destroy, use 'opct destroy' to remove the validation environment.")
+	}
+
+	if reportErr != nil {
+		return false, fmt.Errorf("pipeline step report failed: %w", reportErr)
+	}
+	if re.Checks != nil && len(re.Checks.Fail) > 0 {
+		return false, nil
+	}
+	return true, nil
+}
+
+// checksEvent returns the event with the summary of the report checks.
+func checksEvent(runID string, re *report.ReportData) *notify.Event {
+	checks := &notify.ChecksSummary{}
+	if re != nil && re.Checks != nil {
+		checks.Pass = len(re.Checks.Pass)
+		checks.Fail = len(re.Checks.Fail)
+		checks.Warn = len(re.Checks.Warn)
+	}
+	ev := notify.NewEvent(notify.EventReportChecks, runID, fmt.Sprintf("report checks: %d passed, %d failed, %d warnings", checks.Pass, checks.Fail, checks.Warn))
+	ev.Checks = checks
+	return ev
+}
+
+// resultsArchive returns the results archive from the files retrieved.
+func resultsArchive(files []string) (string, error) {
+	for _, file := range files {
+		if strings.HasSuffix(file, ".tar.gz") {
+			return file, nil
+		}
+	}
+	return "", errors.New("results archive not found in the retrieved files")
+}
+
+// defaultReportDir returns the report directory next to the archive, e.g.
+// opct_202401011200_<id>.tar.gz is reported to opct_202401011200_<id>.
+func defaultReportDir(archive string) string {
+	return filepath.Join(filepath.Dir(archive), strings.TrimSuffix(filepath.Base(archive), ".tar.gz"))
+}
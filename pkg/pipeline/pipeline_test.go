@@ -0,0 +1,49 @@
+package pipeline
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/redhat-openshift-ecosystem/provider-certification-tool/internal/report"
+	"github.com/redhat-openshift-ecosystem/provider-certification-tool/pkg/notify"
+)
+
+func Test_ResultsArchive(t *testing.T) {
+	archive, err := resultsArchive([]string{"results/opct_202401011200_e1b5.tar.gz"})
+	assert.NoError(t, err)
+	assert.Equal(t, "results/opct_202401011200_e1b5.tar.gz", archive)
+
+	_, err = resultsArchive([]string{})
+	assert.Error(t, err)
+}
+
+func Test_DefaultReportDir(t *testing.T) {
+	assert.Equal(t, "results/opct_202401011200_e1b5", defaultReportDir("results/opct_202401011200_e1b5.tar.gz"))
+	assert.Equal(t, "opct_202401011200_e1b5", defaultReportDir("opct_202401011200_e1b5.tar.gz"))
+}
+
+func Test_NewCmdPipelineFlags(t *testing.T) {
+	cmd := NewCmdPipeline()
+	for _, name := range []string{"mode", "tag", "output-dir", "save-to", "destroy", "notify-webhook", "notify-format"} {
+		assert.NotNil(t, cmd.Flags().Lookup(name), "flag %q must be available", name)
+	}
+	for name := range runExcludedFlags {
+		assert.Nil(t, cmd.Flags().Lookup(name), "flag %q must not be available", name)
+	}
+}
+
+func Test_ChecksEvent(t *testing.T) {
+	ev := checksEvent("id", &report.ReportData{Checks: &report.ReportChecks{
+		Fail: []*report.SLOOutput{{}},
+		Pass: []*report.SLOOutput{{}, {}, {}},
+		Warn: []*report.SLOOutput{{}, {}},
+	}})
+	assert.Equal(t, notify.EventReportChecks, ev.Type)
+	assert.Equal(t, "id", ev.RunID)
+	assert.Equal(t, &notify.ChecksSummary{Pass: 3, Fail: 1, Warn: 2}, ev.Checks)
+	assert.Equal(t, "report checks: 3 passed, 1 failed, 2 warnings", ev.Message)
+
+	ev = checksEvent("id", &report.ReportData{})
+	assert.Equal(t, &notify.ChecksSummary{}, ev.Checks)
+}
@@ -0,0 +1,254 @@
+package preflight
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	configv1 "github.com/openshift/api/config/v1"
+	operatorv1 "github.com/openshift/api/operator/v1"
+	sonobuoyclient "github.com/vmware-tanzu/sonobuoy/pkg/client"
+	corev1 "k8s.io/api/core/v1"
+	kerrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"github.com/redhat-openshift-ecosystem/provider-certification-tool/pkg"
+	"github.com/redhat-openshift-ecosystem/provider-certification-tool/pkg/proxy"
+	"github.com/redhat-openshift-ecosystem/provider-certification-tool/pkg/upgrade"
+)
+
+const (
+	CheckIDClusterOperators  = "cluster-operators"
+	CheckIDImageRegistry     = "image-registry"
+	CheckIDDedicatedNode     = "dedicated-node"
+	CheckIDNamespace         = "namespace"
+	CheckIDMachineConfigPool = "machineconfigpool"
+	CheckIDUpgradeTarget     = "upgrade-target"
+	CheckIDSonobuoy          = "sonobuoy"
+	CheckIDProxyRegistry     = "proxy-registry"
+)
+
+func init() {
+	Register(
+		&Check{
+			ID:          CheckIDClusterOperators,
+			Name:        "Cluster Operators must be available, not progressing and not degraded",
+			Severity:    SeverityError,
+			Remediation: "Check the status with 'oc get clusteroperators' and wait for the cluster to be stable.",
+			Run:         checkClusterOperators,
+		},
+		&Check{
+			ID:          CheckIDImageRegistry,
+			Name:        "Image Registry must be in Managed state",
+			Severity:    SeverityError,
+			Remediation: "The OpenShift Image Registry must be deployed, check the documentation to configure the registry storage: https://docs.openshift.com/container-platform/latest/registry/configuring-registry-operator.html",
+			Run:         checkImageRegistry,
+		},
+		&Check{
+			ID:       CheckIDDedicatedNode,
+			Name:     "Dedicated nodes must have the label and taint",
+			Severity: SeverityError,
+			Remediation: fmt.Sprintf("Set the label %q and the taint \"%s='':NoSchedule\" to each dedicated node, or run 'opct adm setup-node --count <nodes>'. "+
+				"Documentation: https://redhat-openshift-ecosystem.github.io/provider-certification-tool/user/#standard-env-setup-node",
+				pkg.DedicatedNodeRoleLabelSelector, pkg.DedicatedNodeRoleLabel),
+			Required: true,
+			Enabled:  func(o *Options) bool { return o.Dedicated },
+			Run:      checkDedicatedNode,
+		},
+		&Check{
+			ID:          CheckIDNamespace,
+			Name:        "Namespace of the validation environment must not exist",
+			Severity:    SeverityError,
+			Remediation: "Run 'opct destroy' to clean the environment and try again.",
+			Required:    true,
+			Run:         checkNamespace,
+		},
+		&Check{
+			ID:       CheckIDMachineConfigPool,
+			Name:     fmt.Sprintf("MachineConfigPool %q must exist and be paused in upgrade mode", pkg.MachineConfigPoolName),
+			Severity: SeverityError,
+			Remediation: "Run 'opct adm setup-node --upgrade-pool', or 'opct run --create-mcp', to create the paused MachineConfigPool. " +
+				"Documentation: https://redhat-openshift-ecosystem.github.io/provider-certification-tool/user/#standard-env-setup-mcp",
+			Required: true,
+			Enabled:  func(o *Options) bool { return o.Mode == "upgrade" },
+			Run:      checkMachineConfigPool,
+		},
+		&Check{
+			ID:       CheckIDUpgradeTarget,
+			Name:     "Upgrade target must be a newer release offered to the cluster",
+			Severity: SeverityError,
+			Remediation: "Check the updates offered to the cluster with 'oc adm upgrade' and set the release image, pinned by digest, " +
+				"with 'oc adm release info <version> -o jsonpath={.image}'. Releases not offered by the update channel require '--skip-checks=upgrade-target'.",
+			Required: true,
+			Enabled:  func(o *Options) bool { return o.Mode == "upgrade" },
+			Run:      checkUpgradeTarget,
+		},
+		&Check{
+			ID:          CheckIDSonobuoy,
+			Name:        "Sonobuoy requirements (API and DNS) must be satisfied",
+			Severity:    SeverityError,
+			Remediation: "Check the cluster DNS pods in the namespace openshift-dns and the Kubernetes API health.",
+			Run:         checkSonobuoy,
+		},
+		&Check{
+			ID:       CheckIDProxyRegistry,
+			Name:     "Image registry must be reachable through the cluster proxy",
+			Severity: SeverityWarning,
+			Remediation: "The plugin images are pulled by the nodes, and the tests reach external services through the cluster proxy. " +
+				"Allow the image registry in the proxy, or mirror the images and use '--image-repository'. " +
+				"The check runs from the client host, the result may differ from the cluster network.",
+			Run: checkProxyRegistry,
+		},
+	)
+}
+
+func checkClusterOperators(ctx context.Context, c *Clients, o *Options) error {
+	coList, err := c.Config.ConfigV1().ClusterOperators().List(ctx, metav1.ListOptions{})
+	if err != nil {
+		return err
+	}
+
+	// Each Cluster Operator should be available, not progressing, and not degraded
+	var msgs []string
+	for _, co := range coList.Items {
+		for _, cond := range co.Status.Conditions {
+			switch cond.Type {
+			case configv1.OperatorAvailable:
+				if cond.Status == configv1.ConditionFalse {
+					msgs = append(msgs, fmt.Sprintf("%s is unavailable", co.Name))
+				}
+			case configv1.OperatorProgressing:
+				if cond.Status == configv1.ConditionTrue {
+					msgs = append(msgs, fmt.Sprintf("%s is still progressing", co.Name))
+				}
+			case configv1.OperatorDegraded:
+				if cond.Status == configv1.ConditionTrue {
+					msgs = append(msgs, fmt.Sprintf("%s is in degraded state", co.Name))
+				}
+			}
+		}
+	}
+	if len(msgs) > 0 {
+		return errors.New(strings.Join(msgs, "; "))
+	}
+	return nil
+}
+
+func checkImageRegistry(ctx context.Context, c *Clients, o *Options) error {
+	irConfig, err := c.ImageRegistry.ImageregistryV1().Configs().Get(ctx, "cluster", metav1.GetOptions{})
+	if err != nil {
+		return err
+	}
+	if irConfig.Spec.ManagementState != operatorv1.Managed {
+		return fmt.Errorf("image registry management state is %q", irConfig.Spec.ManagementState)
+	}
+	return nil
+}
+
+func checkDedicatedNode(ctx context.Context, c *Clients, o *Options) error {
+	nodes, err := c.Kube.CoreV1().Nodes().List(ctx, metav1.ListOptions{
+		LabelSelector: pkg.DedicatedNodeRoleLabelSelector,
+	})
+	if err != nil {
+		return fmt.Errorf("error getting the Node list: %w", err)
+	}
+	if len(nodes.Items) == 0 {
+		return fmt.Errorf("missing dedicated node with label %q", pkg.DedicatedNodeRoleLabelSelector)
+	}
+	if len(nodes.Items) < o.DedicatedNodes {
+		return fmt.Errorf("found %d nodes with label %q, expected at least %d", len(nodes.Items), pkg.DedicatedNodeRoleLabelSelector, o.DedicatedNodes)
+	}
+	missing := []string{}
+	for _, node := range nodes.Items {
+		tainted := false
+		for _, taint := range node.Spec.Taints {
+			if taint.Key == pkg.DedicatedNodeRoleLabel && taint.Effect == corev1.TaintEffectNoSchedule {
+				tainted = true
+				break
+			}
+		}
+		if !tainted {
+			missing = append(missing, node.Name)
+		}
+	}
+	if len(missing) > 0 {
+		return fmt.Errorf("missing taint \"%s='':NoSchedule\" in the dedicated nodes: %s", pkg.DedicatedNodeRoleLabel, strings.Join(missing, ", "))
+	}
+	return nil
+}
+
+func checkNamespace(ctx context.Context, c *Clients, o *Options) error {
+	_, err := c.Kube.CoreV1().Namespaces().Get(ctx, pkg.GetNamespace(), metav1.GetOptions{})
+	if err == nil {
+		return fmt.Errorf("namespace %q already exists", pkg.GetNamespace())
+	}
+	if kerrors.IsNotFound(err) {
+		return nil
+	}
+	return err
+}
+
+func checkMachineConfigPool(ctx context.Context, c *Clients, o *Options) error {
+	poolList, err := c.MachineConfig.MachineconfigurationV1().MachineConfigPools().List(ctx, metav1.ListOptions{})
+	if err != nil {
+		return fmt.Errorf("getting MachineConfigPools failed: %w", err)
+	}
+	for i := range poolList.Items {
+		if poolList.Items[i].Name == pkg.MachineConfigPoolName {
+			return upgrade.ValidateMachineConfigPool(&poolList.Items[i])
+		}
+	}
+	return fmt.Errorf("MachineConfigPool %q not found", pkg.MachineConfigPoolName)
+}
+
+func checkUpgradeTarget(ctx context.Context, c *Clients, o *Options) error {
+	cv, err := c.Config.ConfigV1().ClusterVersions().Get(ctx, "version", metav1.GetOptions{})
+	if err != nil {
+		return fmt.Errorf("error getting the ClusterVersion: %w", err)
+	}
+	target, err := upgrade.ValidateUpgradeTarget(cv, o.UpgradeImage)
+	if err != nil {
+		return err
+	}
+	if len(target.Risks) > 0 {
+		release := target.Release.Version
+		if target.OutsideGraph {
+			release = target.Release.Image
+		}
+		return &Warning{Message: fmt.Sprintf("the upgrade to %s is not recommended to the cluster: %s", release, strings.Join(target.Risks, "; "))}
+	}
+	return nil
+}
+
+func checkSonobuoy(ctx context.Context, c *Clients, o *Options) error {
+	errs := c.Sonobuoy.PreflightChecks(&sonobuoyclient.PreflightConfig{
+		Namespace:           pkg.GetNamespace(),
+		DNSNamespace:        "openshift-dns",
+		DNSPodLabels:        []string{"dns.operator.openshift.io/daemonset-dns=default"},
+		PreflightChecksSkip: []string{"existingnamespace"}, // Namespace is validated by the namespace check
+	})
+	if len(errs) > 0 {
+		msgs := []string{}
+		for _, err := range errs {
+			msgs = append(msgs, err.Error())
+		}
+		return errors.New(strings.Join(msgs, "; "))
+	}
+	return nil
+}
+
+func checkProxyRegistry(ctx context.Context, c *Clients, o *Options) error {
+	p, err := proxy.GetClusterProxy(ctx, c.Config)
+	if err != nil {
+		return err
+	}
+	if p == nil {
+		return nil
+	}
+	repository := o.ImageRepository
+	if repository == "" {
+		repository = pkg.DefaultToolsRepository
+	}
+	return p.CheckRegistry(ctx, strings.SplitN(repository, "/", 2)[0])
+}
@@ -0,0 +1,316 @@
+package preflight
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+
+	table "github.com/jedib0t/go-pretty/v6/table"
+	coclient "github.com/openshift/client-go/config/clientset/versioned"
+	irclient "github.com/openshift/client-go/imageregistry/clientset/versioned"
+	mcfgclientset "github.com/openshift/client-go/machineconfiguration/clientset/versioned"
+	log "github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+	sonobuoyclient "github.com/vmware-tanzu/sonobuoy/pkg/client"
+	"k8s.io/client-go/kubernetes"
+
+	"github.com/redhat-openshift-ecosystem/provider-certification-tool/pkg/client"
+)
+
+type Severity string
+
+type Status string
+
+const (
+	// SeverityError checks block the execution when failed.
+	SeverityError Severity = "error"
+	// SeverityWarning checks report the failure without blocking the execution.
+	SeverityWarning Severity = "warning"
+
+	StatusPass Status = "pass"
+	StatusFail Status = "fail"
+	StatusWarn Status = "warn"
+	StatusSkip Status = "skip"
+)
+
+// Clients holds the API clients used by the checks.
+type Clients struct {
+	Kube          kubernetes.Interface
+	Config        coclient.Interface
+	ImageRegistry irclient.Interface
+	MachineConfig mcfgclientset.Interface
+	Sonobuoy      sonobuoyclient.Interface
+}
+
+// Options holds the run options evaluated by the checks.
+type Options struct {
+	// Mode is the run mode (regular or upgrade).
+	Mode string
+
+	// UpgradeImage is the target release image in upgrade mode.
+	UpgradeImage string
+
+	// ImageRepository is the mirror repository of the images, when set.
+	ImageRepository string
+
+	// Dedicated is set when the validation environment runs in dedicated nodes.
+	Dedicated bool
+
+	// DedicatedNodes is the minimum number of dedicated nodes.
+	DedicatedNodes int
+
+	// SkipChecks is the list of check IDs to skip.
+	SkipChecks []string
+
+	// Devel downgrades failures to warnings. It is not supported in the
+	// validation process.
+	Devel bool
+}
+
+// Check is a preflight check validating the cluster before the validation
+// environment is created.
+type Check struct {
+	// ID is the unique identifier of the check, used to skip it.
+	ID string `json:"id"`
+
+	// Name describes shortly the check.
+	Name string `json:"name"`
+
+	// Severity defines if the failure blocks the execution.
+	Severity Severity `json:"severity"`
+
+	// Remediation is the instruction to fix a failure.
+	Remediation string `json:"remediation"`
+
+	// Required checks can't be downgraded in devel mode.
+	Required bool `json:"-"`
+
+	// Enabled reports if the check applies for the options. Checks
+	// without Enabled are always evaluated.
+	Enabled func(o *Options) bool `json:"-"`
+
+	// Run executes the check, returning the error when failed.
+	Run func(ctx context.Context, c *Clients, o *Options) error `json:"-"`
+}
+
+// Warning is returned by the checks passing with a risk to be reported, the
+// result is a warning regardless of the severity of the check.
+type Warning struct {
+	Message string
+}
+
+func (w *Warning) Error() string {
+	return w.Message
+}
+
+// Result is the result of a check.
+type Result struct {
+	ID          string   `json:"id"`
+	Name        string   `json:"name"`
+	Severity    Severity `json:"severity"`
+	Status      Status   `json:"status"`
+	Message     string   `json:"message,omitempty"`
+	Remediation string   `json:"remediation,omitempty"`
+}
+
+type PreflightOptions struct {
+	Options
+	output string
+}
+
+func NewCmdPreflight() *cobra.Command {
+	o := &PreflightOptions{}
+	cmd := &cobra.Command{
+		Use:   "preflight",
+		Short: "Run the preflight checks validating the cluster before running",
+		Long:  `Runs the preflight checks used by 'opct run', reporting every result. The validation environment is not created.`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if o.output != "table" && o.output != "json" {
+				return fmt.Errorf("invalid output format %q, allowed values: table, json", o.output)
+			}
+			if err := ValidateSkipChecks(o.SkipChecks); err != nil {
+				return err
+			}
+			clients, err := NewClients()
+			if err != nil {
+				log.WithError(err).Error("error creating clients")
+				return err
+			}
+
+			results := Run(cmd.Context(), clients, &o.Options)
+			if o.output == "json" {
+				if err := PrintJSON(cmd.OutOrStdout(), results); err != nil {
+					return err
+				}
+			} else {
+				PrintTable(cmd.OutOrStdout(), results)
+			}
+			if HasFailures(results) {
+				return errors.New("one or more preflight checks failed")
+			}
+			return nil
+		},
+	}
+
+	cmd.Flags().StringVar(&o.Mode, "mode", "regular", "Run mode to be validated. Available: regular, upgrade")
+	cmd.Flags().StringVar(&o.UpgradeImage, "upgrade-to-image", "", "Target OpenShift Release Image to be validated in upgrade mode.")
+	cmd.Flags().StringVar(&o.ImageRepository, "image-repository", "", "Mirror repository of the images, validated to be reachable through the cluster proxy.")
+	cmd.Flags().BoolVar(&o.Dedicated, "dedicated", true, "Validate the dedicated test environment.")
+	cmd.Flags().IntVar(&o.DedicatedNodes, "dedicated-nodes", 1, "Minimum number of dedicated nodes to be validated.")
+	cmd.Flags().StringSliceVar(&o.SkipChecks, "skip-checks", nil, "Comma-separated list of check IDs to skip.")
+	cmd.Flags().StringVarP(&o.output, "output", "o", "table", "Output format. Available: table, json")
+
+	return cmd
+}
+
+var registry []*Check
+
+// Register adds checks to the registry. Checks are evaluated in the order
+// they are registered.
+func Register(checks ...*Check) {
+	registry = append(registry, checks...)
+}
+
+// Checks returns the registered checks.
+func Checks() []*Check {
+	return registry
+}
+
+// NewClients creates the clients used by the checks.
+func NewClients() (*Clients, error) {
+	restConfig, err := client.CreateRestConfig()
+	if err != nil {
+		return nil, err
+	}
+	kclient, sclient, err := client.CreateClients()
+	if err != nil {
+		return nil, err
+	}
+	oc, err := coclient.NewForConfig(restConfig)
+	if err != nil {
+		return nil, err
+	}
+	ir, err := irclient.NewForConfig(restConfig)
+	if err != nil {
+		return nil, err
+	}
+	mc, err := mcfgclientset.NewForConfig(restConfig)
+	if err != nil {
+		return nil, err
+	}
+	return &Clients{
+		Kube:          kclient,
+		Config:        oc,
+		ImageRegistry: ir,
+		MachineConfig: mc,
+		Sonobuoy:      sclient,
+	}, nil
+}
+
+// ValidateSkipChecks returns error when a check ID to skip is not registered.
+func ValidateSkipChecks(ids []string) error {
+	for _, id := range ids {
+		found := false
+		for _, check := range registry {
+			if check.ID == id {
+				found = true
+				break
+			}
+		}
+		if !found {
+			available := []string{}
+			for _, check := range registry {
+				available = append(available, check.ID)
+			}
+			return fmt.Errorf("unknown preflight check %q, available checks: %s", id, strings.Join(available, ", "))
+		}
+	}
+	return nil
+}
+
+// Run evaluates every registered check, returning the results.
+func Run(ctx context.Context, c *Clients, o *Options) []*Result {
+	skip := map[string]struct{}{}
+	for _, id := range o.SkipChecks {
+		skip[id] = struct{}{}
+	}
+	results := []*Result{}
+	for _, check := range registry {
+		res := &Result{
+			ID:          check.ID,
+			Name:        check.Name,
+			Severity:    check.Severity,
+			Status:      StatusPass,
+			Remediation: check.Remediation,
+		}
+		results = append(results, res)
+		if _, ok := skip[check.ID]; ok {
+			res.Status = StatusSkip
+			res.Message = "skipped by user"
+			continue
+		}
+		if check.Enabled != nil && !check.Enabled(o) {
+			res.Status = StatusSkip
+			res.Message = "not applicable"
+			continue
+		}
+		err := check.Run(ctx, c, o)
+		if err == nil {
+			continue
+		}
+		res.Message = err.Error()
+		var warning *Warning
+		switch {
+		case errors.As(err, &warning), check.Severity == SeverityWarning:
+			res.Status = StatusWarn
+		case o.Devel && !check.Required:
+			res.Status = StatusWarn
+			res.Message = fmt.Sprintf("DEVEL MODE, THIS IS NOT SUPPORTED: %s", res.Message)
+		default:
+			res.Status = StatusFail
+		}
+	}
+	return results
+}
+
+// HasFailures returns true when one or more checks have failed.
+func HasFailures(results []*Result) bool {
+	for _, res := range results {
+		if res.Status == StatusFail {
+			return true
+		}
+	}
+	return false
+}
+
+// PrintTable writes the results as a table, followed by the remediation of
+// each check not passed.
+func PrintTable(w io.Writer, results []*Result) {
+	tb := table.NewWriter()
+	tb.SetOutputMirror(w)
+	tb.AppendHeader(table.Row{"ID", "Severity", "Result", "Check", "Message"})
+	for _, res := range results {
+		tb.AppendRow(table.Row{res.ID, res.Severity, res.Status, res.Name, res.Message})
+	}
+	tb.Render()
+
+	for _, res := range results {
+		if res.Status != StatusFail && res.Status != StatusWarn {
+			continue
+		}
+		fmt.Fprintf(w, "\n[%s] %s\n  Remediation: %s\n", res.ID, res.Status, res.Remediation)
+	}
+}
+
+// PrintJSON writes the results as JSON.
+func PrintJSON(w io.Writer, results []*Result) error {
+	data, err := json.MarshalIndent(results, "", "  ")
+	if err != nil {
+		return err
+	}
+	_, err = fmt.Fprintln(w, string(data))
+	return err
+}
@@ -0,0 +1,244 @@
+package preflight
+
+import (
+	"context"
+	"testing"
+
+	configv1 "github.com/openshift/api/config/v1"
+	imageregistryv1 "github.com/openshift/api/imageregistry/v1"
+	mcfgv1 "github.com/openshift/api/machineconfiguration/v1"
+	operatorv1 "github.com/openshift/api/operator/v1"
+	cofake "github.com/openshift/client-go/config/clientset/versioned/fake"
+	irfake "github.com/openshift/client-go/imageregistry/clientset/versioned/fake"
+	mcfgfake "github.com/openshift/client-go/machineconfiguration/clientset/versioned/fake"
+	"github.com/stretchr/testify/assert"
+	sonobuoyclient "github.com/vmware-tanzu/sonobuoy/pkg/client"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/kubernetes/fake"
+
+	"github.com/redhat-openshift-ecosystem/provider-certification-tool/pkg"
+	"github.com/redhat-openshift-ecosystem/provider-certification-tool/pkg/upgrade"
+)
+
+type fakeSonobuoyClient struct {
+	sonobuoyclient.Interface
+}
+
+func (c *fakeSonobuoyClient) PreflightChecks(cfg *sonobuoyclient.PreflightConfig) []error {
+	return nil
+}
+
+const (
+	testUpgradeImage            = "quay.io/openshift-release-dev/ocp-release@sha256:0000000000000000000000000000000000000000000000000000000000000000"
+	testConditionalUpgradeImage = "quay.io/openshift-release-dev/ocp-release@sha256:2222222222222222222222222222222222222222222222222222222222222222"
+)
+
+func newTestClients(degraded bool, pools ...*mcfgv1.MachineConfigPool) *Clients {
+	co := &configv1.ClusterOperator{ObjectMeta: metav1.ObjectMeta{Name: "etcd"}}
+	if degraded {
+		co.Status.Conditions = []configv1.ClusterOperatorStatusCondition{{
+			Type:   configv1.OperatorDegraded,
+			Status: configv1.ConditionTrue,
+		}}
+	}
+	cv := &configv1.ClusterVersion{
+		ObjectMeta: metav1.ObjectMeta{Name: "version"},
+		Status: configv1.ClusterVersionStatus{
+			Desired: configv1.Release{Version: "4.15.1"},
+			AvailableUpdates: []configv1.Release{{
+				Version: "4.15.2",
+				Image:   testUpgradeImage,
+			}},
+			ConditionalUpdates: []configv1.ConditionalUpdate{{
+				Release: configv1.Release{Version: "4.15.3", Image: testConditionalUpgradeImage},
+				Risks: []configv1.ConditionalUpdateRisk{{
+					Name:    "KnownIssue",
+					Message: "Nodes may not boot.",
+					URL:     "https://issues.redhat.com/browse/OCPBUGS-0",
+				}},
+				Conditions: []metav1.Condition{{Type: "Recommended", Status: metav1.ConditionFalse}},
+			}},
+		},
+	}
+	registry := &imageregistryv1.Config{
+		ObjectMeta: metav1.ObjectMeta{Name: "cluster"},
+		Spec: imageregistryv1.ImageRegistrySpec{
+			OperatorSpec: operatorv1.OperatorSpec{ManagementState: operatorv1.Managed},
+		},
+	}
+	node := &v1.Node{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:   "worker-0",
+			Labels: map[string]string{pkg.DedicatedNodeRoleLabel: ""},
+		},
+		Spec: v1.NodeSpec{
+			Taints: []v1.Taint{{Key: pkg.DedicatedNodeRoleLabel, Effect: v1.TaintEffectNoSchedule}},
+		},
+	}
+	mcObjects := []runtime.Object{}
+	for _, pool := range pools {
+		mcObjects = append(mcObjects, pool)
+	}
+	return &Clients{
+		Kube:          fake.NewSimpleClientset(node),
+		Config:        cofake.NewSimpleClientset(co, cv),
+		ImageRegistry: irfake.NewSimpleClientset(registry),
+		MachineConfig: mcfgfake.NewSimpleClientset(mcObjects...),
+		Sonobuoy:      &fakeSonobuoyClient{},
+	}
+}
+
+func resultsByID(results []*Result) map[string]*Result {
+	m := map[string]*Result{}
+	for _, res := range results {
+		m[res.ID] = res
+	}
+	return m
+}
+
+func Test_Run(t *testing.T) {
+	unpausedPool := upgrade.NewMachineConfigPool()
+	pausedPool := upgrade.NewMachineConfigPool()
+	pausedPool.Spec.Paused = true
+
+	tests := []struct {
+		name        string
+		degraded    bool
+		pool        *mcfgv1.MachineConfigPool
+		options     *Options
+		want        map[string]Status
+		wantFailure bool
+	}{{
+		name:    "healthy cluster",
+		options: &Options{Mode: "regular", Dedicated: true},
+		want: map[string]Status{
+			CheckIDClusterOperators:  StatusPass,
+			CheckIDImageRegistry:     StatusPass,
+			CheckIDDedicatedNode:     StatusPass,
+			CheckIDNamespace:         StatusPass,
+			CheckIDMachineConfigPool: StatusSkip,
+			CheckIDSonobuoy:          StatusPass,
+			CheckIDProxyRegistry:     StatusPass,
+		},
+	}, {
+		name:        "degraded operator",
+		degraded:    true,
+		options:     &Options{Mode: "regular"},
+		want:        map[string]Status{CheckIDClusterOperators: StatusFail, CheckIDDedicatedNode: StatusSkip},
+		wantFailure: true,
+	}, {
+		name:     "degraded operator in devel mode",
+		degraded: true,
+		options:  &Options{Mode: "regular", Devel: true},
+		want:     map[string]Status{CheckIDClusterOperators: StatusWarn},
+	}, {
+		name:     "degraded operator skipped",
+		degraded: true,
+		options:  &Options{Mode: "regular", SkipChecks: []string{CheckIDClusterOperators}},
+		want:     map[string]Status{CheckIDClusterOperators: StatusSkip},
+	}, {
+		name:        "missing pool in upgrade mode",
+		options:     &Options{Mode: "upgrade", UpgradeImage: testUpgradeImage, Devel: true},
+		want:        map[string]Status{CheckIDMachineConfigPool: StatusFail},
+		wantFailure: true,
+	}, {
+		name:        "pool not paused in upgrade mode",
+		pool:        unpausedPool,
+		options:     &Options{Mode: "upgrade", UpgradeImage: testUpgradeImage, Devel: true},
+		want:        map[string]Status{CheckIDMachineConfigPool: StatusFail},
+		wantFailure: true,
+	}, {
+		name:    "paused pool in upgrade mode",
+		pool:    pausedPool,
+		options: &Options{Mode: "upgrade", UpgradeImage: testUpgradeImage, Devel: true},
+		want:    map[string]Status{CheckIDMachineConfigPool: StatusPass, CheckIDUpgradeTarget: StatusPass},
+	}, {
+		name:    "upgrade target not offered",
+		pool:    pausedPool,
+		options: &Options{Mode: "upgrade", UpgradeImage: "quay.io/openshift-release-dev/ocp-release@sha256:1111111111111111111111111111111111111111111111111111111111111111", Devel: true},
+		want:    map[string]Status{CheckIDUpgradeTarget: StatusWarn},
+	}, {
+		name:        "upgrade target not pinned by digest",
+		pool:        pausedPool,
+		options:     &Options{Mode: "upgrade", UpgradeImage: "quay.io/openshift-release-dev/ocp-release:4.15.2-x86_64", Devel: true},
+		want:        map[string]Status{CheckIDUpgradeTarget: StatusFail},
+		wantFailure: true,
+	}, {
+		name:    "upgrade target not pinned by digest skipped",
+		pool:    pausedPool,
+		options: &Options{Mode: "upgrade", UpgradeImage: "quay.io/openshift-release-dev/ocp-release:4.15.2-x86_64", SkipChecks: []string{CheckIDUpgradeTarget}},
+		want:    map[string]Status{CheckIDUpgradeTarget: StatusSkip},
+	}, {
+		name:    "upgrade target not recommended",
+		pool:    pausedPool,
+		options: &Options{Mode: "upgrade", UpgradeImage: testConditionalUpgradeImage, Devel: true},
+		want:    map[string]Status{CheckIDUpgradeTarget: StatusWarn},
+	}}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			var pools []*mcfgv1.MachineConfigPool
+			if test.pool != nil {
+				pools = append(pools, test.pool)
+			}
+			results := Run(context.TODO(), newTestClients(test.degraded, pools...), test.options)
+			got := resultsByID(results)
+			for id, status := range test.want {
+				assert.Equal(t, status, got[id].Status, "check %s: %s", id, got[id].Message)
+			}
+			assert.Equal(t, test.wantFailure, HasFailures(results))
+		})
+	}
+}
+
+func Test_ValidateSkipChecks(t *testing.T) {
+	assert.NoError(t, ValidateSkipChecks([]string{CheckIDClusterOperators, CheckIDSonobuoy}))
+	assert.Error(t, ValidateSkipChecks([]string{"unknown"}))
+}
+
+func Test_checkDedicatedNode(t *testing.T) {
+	newNode := func(name string, tainted bool) *v1.Node {
+		node := &v1.Node{ObjectMeta: metav1.ObjectMeta{
+			Name:   name,
+			Labels: map[string]string{pkg.DedicatedNodeRoleLabel: ""},
+		}}
+		if tainted {
+			node.Spec.Taints = []v1.Taint{{Key: pkg.DedicatedNodeRoleLabel, Effect: v1.TaintEffectNoSchedule}}
+		}
+		return node
+	}
+	tests := []struct {
+		name    string
+		nodes   []runtime.Object
+		count   int
+		wantErr bool
+	}{{
+		name:    "missing nodes",
+		count:   1,
+		wantErr: true,
+	}, {
+		name:  "pool of tainted nodes",
+		nodes: []runtime.Object{newNode("worker-0", true), newNode("worker-1", true), newNode("worker-2", true)},
+		count: 3,
+	}, {
+		name:    "fewer nodes than expected",
+		nodes:   []runtime.Object{newNode("worker-0", true)},
+		count:   2,
+		wantErr: true,
+	}, {
+		name:    "node without taint",
+		nodes:   []runtime.Object{newNode("worker-0", true), newNode("worker-1", false)},
+		count:   2,
+		wantErr: true,
+	}}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			c := &Clients{Kube: fake.NewSimpleClientset(test.nodes...)}
+			err := checkDedicatedNode(context.TODO(), c, &Options{Dedicated: true, DedicatedNodes: test.count})
+			assert.Equal(t, test.wantErr, err != nil, "error: %v", err)
+		})
+	}
+}
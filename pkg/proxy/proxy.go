@@ -0,0 +1,185 @@
+package proxy
+
+import (
+	"context"
+	"crypto/tls"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	configv1 "github.com/openshift/api/config/v1"
+	coclient "github.com/openshift/client-go/config/clientset/versioned"
+	"golang.org/x/net/http/httpproxy"
+	v1 "k8s.io/api/core/v1"
+	kerrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+const (
+	// TrustedCAConfigMapName is the ConfigMap created in the validation
+	// environment namespace, injected by the Cluster Network Operator with
+	// the trusted CA bundle of the cluster proxy.
+	TrustedCAConfigMapName = "opct-trusted-ca-bundle"
+	TrustedCAConfigMapKey  = "ca-bundle.crt"
+	TrustedCAInjectLabel   = "config.openshift.io/inject-trusted-cabundle"
+
+	// TrustedCAVolumeName is the volume mounting the trusted CA bundle to the
+	// path used by the system trust store.
+	TrustedCAVolumeName = "trusted-ca"
+	TrustedCAMountPath  = "/etc/pki/ca-trust/extracted/pem/tls-ca-bundle.pem"
+	trustedCAMountFile  = "tls-ca-bundle.pem"
+
+	registryCheckTimeout = 15 * time.Second
+)
+
+// defaultNoProxy are the in-cluster destinations reached without the proxy by
+// the plugins and the must-gather: the API server service, the aggregator and
+// the monitoring stack. The service host is expanded by the kubelet.
+var defaultNoProxy = []string{"localhost", "127.0.0.1", ".svc", ".cluster.local", "$(KUBERNETES_SERVICE_HOST)"}
+
+// ClusterProxy is the effective cluster-wide proxy configuration.
+type ClusterProxy struct {
+	HTTPProxy  string
+	HTTPSProxy string
+	NoProxy    string
+
+	// TrustedCA is the name of the ConfigMap, in the namespace openshift-config,
+	// with the user-provided CA bundle trusted by the proxy.
+	TrustedCA string
+}
+
+// GetClusterProxy returns the cluster-wide proxy, or nil when the cluster
+// does not use a proxy.
+func GetClusterProxy(ctx context.Context, client coclient.Interface) (*ClusterProxy, error) {
+	p, err := client.ConfigV1().Proxies().Get(ctx, "cluster", metav1.GetOptions{})
+	if err != nil {
+		if kerrors.IsNotFound(err) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("unable to get the cluster proxy: %w", err)
+	}
+	return NewClusterProxy(p), nil
+}
+
+// NewClusterProxy returns the effective proxy from the Proxy status, or nil
+// when the proxy is not set.
+func NewClusterProxy(p *configv1.Proxy) *ClusterProxy {
+	if p.Status.HTTPProxy == "" && p.Status.HTTPSProxy == "" {
+		return nil
+	}
+	return &ClusterProxy{
+		HTTPProxy:  p.Status.HTTPProxy,
+		HTTPSProxy: p.Status.HTTPSProxy,
+		NoProxy:    p.Status.NoProxy,
+		TrustedCA:  p.Spec.TrustedCA.Name,
+	}
+}
+
+// NoProxyList returns the destinations excluded from the proxy, including the
+// in-cluster destinations not set in the cluster proxy, e.g. when the proxy is
+// set by the user.
+func (p *ClusterProxy) NoProxyList() string {
+	noProxy := []string{}
+	seen := map[string]struct{}{}
+	for _, item := range append(strings.Split(p.NoProxy, ","), defaultNoProxy...) {
+		item = strings.TrimSpace(item)
+		if _, ok := seen[item]; ok || item == "" {
+			continue
+		}
+		seen[item] = struct{}{}
+		noProxy = append(noProxy, item)
+	}
+	return strings.Join(noProxy, ",")
+}
+
+// Env returns the proxy environment variables, in upper and lower case as
+// tools differ on the variables read.
+func (p *ClusterProxy) Env() []v1.EnvVar {
+	env := []v1.EnvVar{}
+	noProxy := p.NoProxyList()
+	for _, item := range []struct{ name, value string }{
+		{"HTTP_PROXY", p.HTTPProxy},
+		{"HTTPS_PROXY", p.HTTPSProxy},
+		{"NO_PROXY", noProxy},
+		{"http_proxy", p.HTTPProxy},
+		{"https_proxy", p.HTTPSProxy},
+		{"no_proxy", noProxy},
+	} {
+		if item.value != "" {
+			env = append(env, v1.EnvVar{Name: item.name, Value: item.value})
+		}
+	}
+	return env
+}
+
+// NewTrustedCAConfigMap returns the ConfigMap injected with the trusted CA
+// bundle of the cluster.
+func NewTrustedCAConfigMap(namespace string) *v1.ConfigMap {
+	return &v1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      TrustedCAConfigMapName,
+			Namespace: namespace,
+			Labels:    map[string]string{TrustedCAInjectLabel: "true"},
+		},
+	}
+}
+
+// TrustedCAVolume returns the volume of the trusted CA bundle ConfigMap.
+func TrustedCAVolume() v1.Volume {
+	return v1.Volume{
+		Name: TrustedCAVolumeName,
+		VolumeSource: v1.VolumeSource{
+			ConfigMap: &v1.ConfigMapVolumeSource{
+				LocalObjectReference: v1.LocalObjectReference{Name: TrustedCAConfigMapName},
+				Items:                []v1.KeyToPath{{Key: TrustedCAConfigMapKey, Path: trustedCAMountFile}},
+			},
+		},
+	}
+}
+
+// TrustedCAVolumeMount returns the mount of the trusted CA bundle replacing
+// the bundle of the system trust store.
+func TrustedCAVolumeMount() v1.VolumeMount {
+	return v1.VolumeMount{
+		Name:      TrustedCAVolumeName,
+		MountPath: TrustedCAMountPath,
+		SubPath:   trustedCAMountFile,
+		ReadOnly:  true,
+	}
+}
+
+// CheckRegistry checks if the registry is reachable through the proxy. Any
+// HTTP response from the registry API, including unauthorized, means the
+// proxy allows the access.
+func (p *ClusterProxy) CheckRegistry(ctx context.Context, registry string) error {
+	cfg := &httpproxy.Config{HTTPProxy: p.HTTPProxy, HTTPSProxy: p.HTTPSProxy, NoProxy: p.NoProxyList()}
+	proxyFunc := cfg.ProxyFunc()
+	client := &http.Client{
+		Timeout: registryCheckTimeout,
+		Transport: &http.Transport{
+			Proxy: func(req *http.Request) (*url.URL, error) {
+				return proxyFunc(req.URL)
+			},
+			TLSClientConfig: &tls.Config{MinVersion: tls.VersionTLS12},
+		},
+	}
+	return checkRegistry(ctx, client, registry)
+}
+
+func checkRegistry(ctx context.Context, client *http.Client, registry string) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, fmt.Sprintf("https://%s/v2/", registry), nil)
+	if err != nil {
+		return err
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return fmt.Errorf("registry %s is not reachable through the cluster proxy: %w", registry, err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode >= http.StatusInternalServerError {
+		return fmt.Errorf("registry %s returned %s through the cluster proxy", registry, resp.Status)
+	}
+	return nil
+}
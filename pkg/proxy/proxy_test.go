@@ -0,0 +1,72 @@
+package proxy
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+
+	configv1 "github.com/openshift/api/config/v1"
+	cofake "github.com/openshift/client-go/config/clientset/versioned/fake"
+	"github.com/stretchr/testify/assert"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestGetClusterProxy(t *testing.T) {
+	p, err := GetClusterProxy(context.TODO(), cofake.NewSimpleClientset())
+	assert.NoError(t, err)
+	assert.Nil(t, p, "proxy must be nil when not found")
+
+	p, err = GetClusterProxy(context.TODO(), cofake.NewSimpleClientset(&configv1.Proxy{
+		ObjectMeta: metav1.ObjectMeta{Name: "cluster"},
+	}))
+	assert.NoError(t, err)
+	assert.Nil(t, p, "proxy must be nil when not configured")
+
+	p, err = GetClusterProxy(context.TODO(), cofake.NewSimpleClientset(&configv1.Proxy{
+		ObjectMeta: metav1.ObjectMeta{Name: "cluster"},
+		Spec:       configv1.ProxySpec{TrustedCA: configv1.ConfigMapNameReference{Name: "user-ca-bundle"}},
+		Status: configv1.ProxyStatus{
+			HTTPSProxy: "http://proxy.example.com:3128",
+			NoProxy:    ".cluster.local,172.30.0.0/16",
+		},
+	}))
+	assert.NoError(t, err)
+	assert.Equal(t, "user-ca-bundle", p.TrustedCA)
+
+	names := []string{}
+	for _, env := range p.Env() {
+		names = append(names, env.Name)
+	}
+	assert.Equal(t, []string{"HTTPS_PROXY", "NO_PROXY", "https_proxy", "no_proxy"}, names)
+
+	// in-cluster destinations are always excluded from the proxy
+	assert.Equal(t, ".cluster.local,172.30.0.0/16,localhost,127.0.0.1,.svc,$(KUBERNETES_SERVICE_HOST)", p.NoProxyList())
+	p = &ClusterProxy{HTTPSProxy: "http://proxy.example.com:3128"}
+	assert.Equal(t, "localhost,127.0.0.1,.svc,.cluster.local,$(KUBERNETES_SERVICE_HOST)", p.NoProxyList())
+	assert.Contains(t, p.Env(), v1.EnvVar{Name: "no_proxy", Value: p.NoProxyList()})
+}
+
+func TestCheckRegistry(t *testing.T) {
+	registry := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusUnauthorized)
+	}))
+	defer registry.Close()
+	host := strings.TrimPrefix(registry.URL, "https://")
+
+	assert.NoError(t, checkRegistry(context.TODO(), registry.Client(), host))
+
+	// proxy denying the tunnel to the registry
+	proxy := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusForbidden)
+	}))
+	defer proxy.Close()
+	proxyURL, _ := url.Parse(proxy.URL)
+	client := registry.Client()
+	client.Transport.(*http.Transport).Proxy = http.ProxyURL(proxyURL)
+
+	assert.Error(t, checkRegistry(context.TODO(), client, host))
+}
@@ -0,0 +1,274 @@
+package rbac
+
+import (
+	"bufio"
+	"compress/gzip"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	log "github.com/sirupsen/logrus"
+	rbacv1 "k8s.io/api/rbac/v1"
+)
+
+// auditEvent holds the fields of the API server audit event used by the audit.
+type auditEvent struct {
+	Stage      string `json:"stage"`
+	RequestURI string `json:"requestURI"`
+	Verb       string `json:"verb"`
+	User       struct {
+		Username string `json:"username"`
+	} `json:"user"`
+	ObjectRef *struct {
+		APIGroup    string `json:"apiGroup"`
+		Resource    string `json:"resource"`
+		Subresource string `json:"subresource"`
+		Name        string `json:"name"`
+	} `json:"objectRef"`
+	ResponseStatus *struct {
+		Code int `json:"code"`
+	} `json:"responseStatus"`
+}
+
+// Request is the permission used by an API request.
+type Request struct {
+	Verb           string `json:"verb"`
+	APIGroup       string `json:"apiGroup,omitempty"`
+	Resource       string `json:"resource,omitempty"`
+	NonResourceURL string `json:"nonResourceURL,omitempty"`
+}
+
+// String returns the request in the format verb apiGroup/resource.
+func (r Request) String() string {
+	if r.NonResourceURL != "" {
+		return fmt.Sprintf("%s %s", r.Verb, r.NonResourceURL)
+	}
+	group := r.APIGroup
+	if group == "" {
+		group = "core"
+	}
+	return fmt.Sprintf("%s %s/%s", r.Verb, group, r.Resource)
+}
+
+// Usage is the number of times a permission has been used.
+type Usage struct {
+	Request
+	Count int `json:"count"`
+}
+
+// AuditReport is the result of the comparison of the granted rules with the
+// permissions used by the validation environment.
+type AuditReport struct {
+	// Events is the number of audit events of the user.
+	Events int `json:"events"`
+
+	// Used are the permissions used by the user.
+	Used []*Usage `json:"used"`
+
+	// NotGranted are the permissions used but not granted by the rules.
+	NotGranted []*Usage `json:"notGranted"`
+
+	// Forbidden are the requests denied by the API server.
+	Forbidden []*Usage `json:"forbidden"`
+
+	// Unused are the rules granted but never used.
+	Unused []rbacv1.PolicyRule `json:"unused"`
+}
+
+// Auditor aggregates the audit events of a user.
+type Auditor struct {
+	username  string
+	events    int
+	used      map[Request]*Usage
+	forbidden map[Request]*Usage
+}
+
+// NewAuditor creates the auditor of the permissions used by the user.
+func NewAuditor(username string) *Auditor {
+	return &Auditor{
+		username:  username,
+		used:      map[Request]*Usage{},
+		forbidden: map[Request]*Usage{},
+	}
+}
+
+// ReadMustGather reads the API server audit logs collected by the must-gather
+// (gather_audit_logs), plain or gzip compressed.
+func (a *Auditor) ReadMustGather(path string) error {
+	files := 0
+	err := filepath.Walk(path, func(p string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		if info.IsDir() || !strings.Contains(p, "audit_logs") {
+			return nil
+		}
+		if !strings.HasSuffix(p, ".log") && !strings.HasSuffix(p, ".log.gz") {
+			return nil
+		}
+		log.Debugf("Processing audit log file: %s", p)
+		files++
+		return a.readFile(p)
+	})
+	if err != nil {
+		return err
+	}
+	if files == 0 {
+		return fmt.Errorf("no audit logs found in %s, collect the audit logs with 'oc adm must-gather -- /usr/bin/gather_audit_logs'", path)
+	}
+	return nil
+}
+
+func (a *Auditor) readFile(path string) error {
+	fd, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer fd.Close()
+
+	var reader io.Reader = fd
+	if strings.HasSuffix(path, ".gz") {
+		gz, err := gzip.NewReader(fd)
+		if err != nil {
+			return fmt.Errorf("error reading %s: %w", path, err)
+		}
+		defer gz.Close()
+		reader = gz
+	}
+	return a.Read(reader)
+}
+
+// Read reads audit events, one JSON object by line.
+func (a *Auditor) Read(r io.Reader) error {
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 0, 64*1024), 10*1024*1024)
+	for scanner.Scan() {
+		line := scanner.Bytes()
+		if len(line) == 0 {
+			continue
+		}
+		ev := auditEvent{}
+		if err := json.Unmarshal(line, &ev); err != nil {
+			log.Debugf("Ignoring invalid audit event: %v", err)
+			continue
+		}
+		a.add(&ev)
+	}
+	return scanner.Err()
+}
+
+func (a *Auditor) add(ev *auditEvent) {
+	if ev.User.Username != a.username {
+		return
+	}
+	// Each request is logged on every stage, counting only the completed ones.
+	if ev.Stage != "" && ev.Stage != "ResponseComplete" && ev.Stage != "Panic" {
+		return
+	}
+	a.events++
+
+	req := Request{Verb: ev.Verb}
+	if ev.ObjectRef != nil && ev.ObjectRef.Resource != "" {
+		req.APIGroup = ev.ObjectRef.APIGroup
+		req.Resource = ev.ObjectRef.Resource
+		if ev.ObjectRef.Subresource != "" {
+			req.Resource += "/" + ev.ObjectRef.Subresource
+		}
+	} else {
+		req.NonResourceURL = strings.SplitN(ev.RequestURI, "?", 2)[0]
+	}
+
+	counter := a.used
+	if ev.ResponseStatus != nil && ev.ResponseStatus.Code == 403 {
+		counter = a.forbidden
+	}
+	if _, ok := counter[req]; !ok {
+		counter[req] = &Usage{Request: req}
+	}
+	counter[req].Count++
+}
+
+// Audit compares the permissions used with the rules granted.
+func (a *Auditor) Audit(rules []rbacv1.PolicyRule) *AuditReport {
+	report := &AuditReport{
+		Events:     a.events,
+		Used:       sortedUsage(a.used),
+		NotGranted: []*Usage{},
+		Forbidden:  sortedUsage(a.forbidden),
+		Unused:     []rbacv1.PolicyRule{},
+	}
+	hits := make([]int, len(rules))
+	for _, usage := range report.Used {
+		granted := false
+		for i := range rules {
+			if RuleAllows(&rules[i], &usage.Request) {
+				hits[i] += usage.Count
+				granted = true
+			}
+		}
+		if !granted {
+			report.NotGranted = append(report.NotGranted, usage)
+		}
+	}
+	for i := range rules {
+		if hits[i] == 0 {
+			report.Unused = append(report.Unused, rules[i])
+		}
+	}
+	return report
+}
+
+// RuleAllows returns true when the rule grants the request. Names of
+// resources are not evaluated as the audit events of lists don't have it.
+func RuleAllows(rule *rbacv1.PolicyRule, req *Request) bool {
+	if !matches(rule.Verbs, req.Verb) {
+		return false
+	}
+	if req.NonResourceURL != "" {
+		for _, url := range rule.NonResourceURLs {
+			if url == "*" || url == req.NonResourceURL ||
+				(strings.HasSuffix(url, "*") && strings.HasPrefix(req.NonResourceURL, strings.TrimSuffix(url, "*"))) {
+				return true
+			}
+		}
+		return false
+	}
+	if !matches(rule.APIGroups, req.APIGroup) {
+		return false
+	}
+	for _, res := range rule.Resources {
+		if res == "*" || res == req.Resource {
+			return true
+		}
+		// resource/* matches any subresource, */subresource any resource.
+		parts := strings.SplitN(req.Resource, "/", 2)
+		if len(parts) == 2 && (res == parts[0]+"/*" || res == "*/"+parts[1]) {
+			return true
+		}
+	}
+	return false
+}
+
+func matches(items []string, value string) bool {
+	for _, item := range items {
+		if item == "*" || item == value {
+			return true
+		}
+	}
+	return false
+}
+
+func sortedUsage(m map[Request]*Usage) []*Usage {
+	items := make([]*Usage, 0, len(m))
+	for _, usage := range m {
+		items = append(items, usage)
+	}
+	sort.Slice(items, func(i, j int) bool {
+		return items[i].Request.String() < items[j].Request.String()
+	})
+	return items
+}
@@ -0,0 +1,76 @@
+package rbac
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	rbacv1 "k8s.io/api/rbac/v1"
+)
+
+const testUser = "system:serviceaccount:opct:sonobuoy-serviceaccount"
+
+func Test_RuleAllows(t *testing.T) {
+	rule := &rbacv1.PolicyRule{
+		APIGroups: []string{"", "apps"},
+		Resources: []string{"pods", "deployments/*"},
+		Verbs:     []string{"get", "list"},
+	}
+	urlRule := &rbacv1.PolicyRule{
+		NonResourceURLs: []string{"/logs/*"},
+		Verbs:           []string{"get"},
+	}
+	tests := []struct {
+		name string
+		rule *rbacv1.PolicyRule
+		req  Request
+		want bool
+	}{
+		{name: "resource", rule: rule, req: Request{Verb: "get", Resource: "pods"}, want: true},
+		{name: "verb not granted", rule: rule, req: Request{Verb: "delete", Resource: "pods"}},
+		{name: "group not granted", rule: rule, req: Request{Verb: "get", APIGroup: "batch", Resource: "pods"}},
+		{name: "subresource not granted", rule: rule, req: Request{Verb: "get", Resource: "pods/log"}},
+		{name: "subresource wildcard", rule: rule, req: Request{Verb: "get", APIGroup: "apps", Resource: "deployments/scale"}, want: true},
+		{name: "non-resource prefix", rule: urlRule, req: Request{Verb: "get", NonResourceURL: "/logs/kube-apiserver"}, want: true},
+		{name: "non-resource not granted", rule: urlRule, req: Request{Verb: "get", NonResourceURL: "/metrics"}},
+		{name: "resource rule and non-resource request", rule: rule, req: Request{Verb: "get", NonResourceURL: "/metrics"}},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			assert.Equal(t, test.want, RuleAllows(test.rule, &test.req))
+		})
+	}
+}
+
+func Test_Audit(t *testing.T) {
+	events := []string{
+		`{"stage":"RequestReceived","verb":"get","user":{"username":"` + testUser + `"},"objectRef":{"resource":"pods"}}`,
+		`{"stage":"ResponseComplete","verb":"get","user":{"username":"` + testUser + `"},"objectRef":{"resource":"pods"},"responseStatus":{"code":200}}`,
+		`{"stage":"ResponseComplete","verb":"get","user":{"username":"` + testUser + `"},"objectRef":{"resource":"pods","subresource":"log"},"responseStatus":{"code":200}}`,
+		`{"stage":"ResponseComplete","verb":"create","user":{"username":"` + testUser + `"},"objectRef":{"apiGroup":"batch","resource":"jobs"},"responseStatus":{"code":403}}`,
+		`{"stage":"ResponseComplete","verb":"get","user":{"username":"` + testUser + `"},"requestURI":"/version?timeout=32s","responseStatus":{"code":200}}`,
+		`{"stage":"ResponseComplete","verb":"delete","user":{"username":"system:admin"},"objectRef":{"resource":"nodes"},"responseStatus":{"code":200}}`,
+		`invalid`,
+	}
+	rules := []rbacv1.PolicyRule{
+		{APIGroups: []string{""}, Resources: []string{"pods"}, Verbs: []string{"get"}},
+		{APIGroups: []string{""}, Resources: []string{"secrets"}, Verbs: []string{"get"}},
+		{NonResourceURLs: []string{"/version"}, Verbs: []string{"get"}},
+	}
+
+	auditor := NewAuditor(testUser)
+	assert.NoError(t, auditor.Read(strings.NewReader(strings.Join(events, "\n"))))
+	report := auditor.Audit(rules)
+
+	assert.Equal(t, 4, report.Events)
+	assert.Len(t, report.Used, 3)
+	if assert.Len(t, report.NotGranted, 1) {
+		assert.Equal(t, "get core/pods/log", report.NotGranted[0].String())
+	}
+	if assert.Len(t, report.Forbidden, 1) {
+		assert.Equal(t, "create batch/jobs", report.Forbidden[0].String())
+	}
+	if assert.Len(t, report.Unused, 1) {
+		assert.Equal(t, []string{"secrets"}, report.Unused[0].Resources)
+	}
+}
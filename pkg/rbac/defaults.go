@@ -0,0 +1,75 @@
+package rbac
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"path"
+	"sort"
+	"strings"
+
+	rbacv1 "k8s.io/api/rbac/v1"
+
+	"github.com/redhat-openshift-ecosystem/provider-certification-tool/internal/opct/plugin"
+)
+
+// DefaultRulesPath is the directory of the rules shipped for the embedded
+// plugins, one ClusterRole named by the plugin in the file <plugin>.yaml.
+const DefaultRulesPath = "data/templates/rbac"
+
+// pluginRulesIncludes are the plugins whose rules are granted to the plugin
+// running their tests: the OpenShift suites include the Kubernetes
+// conformance tests, and the replay and upgrade plugins run the tests of the
+// conformance suites.
+var pluginRulesIncludes = map[string][]string{
+	plugin.PluginNameOpenShiftConformance: {plugin.PluginNameKubernetesConformance},
+	plugin.PluginNameConformanceReplay:    {plugin.PluginNameKubernetesConformance, plugin.PluginNameOpenShiftConformance},
+	plugin.PluginNameOpenShiftUpgrade:     {plugin.PluginNameKubernetesConformance, plugin.PluginNameOpenShiftConformance},
+}
+
+// DefaultPluginRules returns the rules shipped for the plugins, with the rules
+// of the plugins they include, and the plugins without default rules, e.g.
+// plugins set by --plugin. The rules of every embedded plugin are returned when
+// plugins is empty.
+func DefaultPluginRules(fsys fs.FS, plugins []string) ([]rbacv1.PolicyRule, []string, error) {
+	if len(plugins) == 0 {
+		files, err := fs.Glob(fsys, path.Join(DefaultRulesPath, "*.yaml"))
+		if err != nil {
+			return nil, nil, err
+		}
+		for _, f := range files {
+			plugins = append(plugins, strings.TrimSuffix(path.Base(f), ".yaml"))
+		}
+	}
+
+	rules := []rbacv1.PolicyRule{}
+	missing := []string{}
+	loaded := map[string]struct{}{}
+	for _, name := range plugins {
+		for i, p := range append([]string{name}, pluginRulesIncludes[name]...) {
+			if _, ok := loaded[p]; ok {
+				continue
+			}
+			file := path.Join(DefaultRulesPath, p+".yaml")
+			data, err := fs.ReadFile(fsys, file)
+			// Plugins running only the tests of other plugins don't have rules.
+			if errors.Is(err, fs.ErrNotExist) && i == 0 {
+				if _, ok := pluginRulesIncludes[name]; !ok {
+					missing = append(missing, name)
+				}
+				continue
+			}
+			if err != nil {
+				return nil, nil, fmt.Errorf("unable to read the RBAC rules of plugin %q: %w", p, err)
+			}
+			pluginRules, err := ParseRules(data, file)
+			if err != nil {
+				return nil, nil, err
+			}
+			loaded[p] = struct{}{}
+			rules = append(rules, pluginRules...)
+		}
+	}
+	sort.Strings(missing)
+	return rules, missing, nil
+}
@@ -0,0 +1,56 @@
+package rbac
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/redhat-openshift-ecosystem/provider-certification-tool/internal/opct/plugin"
+)
+
+func Test_DefaultPluginRules(t *testing.T) {
+	fsys := os.DirFS("../..")
+
+	// Every embedded plugin ships the rules of the scoped profile.
+	manifests, err := filepath.Glob("../../data/templates/plugins/*.yaml")
+	require.NoError(t, err)
+	embedded := []string{}
+	for _, m := range manifests {
+		data, err := os.ReadFile(m)
+		require.NoError(t, err)
+		for _, line := range strings.Split(string(data), "\n") {
+			if name, ok := strings.CutPrefix(strings.TrimSpace(line), "plugin-name: "); ok {
+				embedded = append(embedded, name)
+			}
+		}
+	}
+	require.Len(t, embedded, len(manifests))
+	rules, missing, err := DefaultPluginRules(fsys, embedded)
+	assert.NoError(t, err)
+	assert.Empty(t, missing)
+
+	// The default rules are valid in the scoped profile.
+	_, err = ScopedRules(rules)
+	assert.NoError(t, err)
+
+	// Every embedded plugin rules are loaded when no plugin is set.
+	all, _, err := DefaultPluginRules(fsys, nil)
+	assert.NoError(t, err)
+	assert.ElementsMatch(t, rules, all)
+
+	// The replay plugin is granted the rules of the suites it replays.
+	replay, _, err := DefaultPluginRules(fsys, []string{plugin.PluginNameConformanceReplay})
+	assert.NoError(t, err)
+	suites, _, err := DefaultPluginRules(fsys, []string{plugin.PluginNameOpenShiftConformance})
+	assert.NoError(t, err)
+	assert.ElementsMatch(t, suites, replay)
+
+	// Plugins set by --plugin don't have default rules.
+	_, missing, err = DefaultPluginRules(fsys, []string{"sample-plugin", plugin.PluginNameKubernetesConformance})
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"sample-plugin"}, missing)
+}
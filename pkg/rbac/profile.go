@@ -0,0 +1,220 @@
+package rbac
+
+import (
+	"fmt"
+	"os"
+	"sort"
+
+	rbacv1 "k8s.io/api/rbac/v1"
+	"sigs.k8s.io/yaml"
+)
+
+const (
+	// ProfilePrivileged grants every permission to the validation environment.
+	// It is the default profile.
+	ProfilePrivileged = "privileged"
+
+	// ProfileScoped grants the permissions required by the aggregator and the
+	// rules shipped for the plugins, extended by the ones used by the plugins
+	// in a previous run, without wildcards.
+	ProfileScoped = "scoped"
+)
+
+var (
+	// readVerbs and writeVerbs enumerate the verbs granted to the aggregator.
+	readVerbs  = []string{"get", "list", "watch"}
+	writeVerbs = []string{"get", "list", "watch", "create", "update", "patch", "delete", "deletecollection"}
+
+	// deniedVerbs are never granted by the scoped profile, allowing the
+	// validation environment to grant permissions it doesn't hold.
+	deniedVerbs = []string{"*", "bind", "escalate", "impersonate"}
+
+	// aggregatorRules are required by the Sonobuoy aggregator and workers
+	// running in the validation environment namespace.
+	aggregatorRules = []rbacv1.PolicyRule{
+		{
+			APIGroups: []string{""},
+			Resources: []string{"pods", "pods/log", "configmaps", "secrets", "services", "serviceaccounts", "events"},
+			Verbs:     writeVerbs,
+		},
+		{
+			APIGroups: []string{""},
+			Resources: []string{"namespaces", "nodes"},
+			Verbs:     readVerbs,
+		},
+		{
+			APIGroups: []string{"apps", "batch"},
+			Resources: []string{"daemonsets", "jobs"},
+			Verbs:     writeVerbs,
+		},
+		{
+			APIGroups:     []string{"security.openshift.io"},
+			Resources:     []string{"securitycontextconstraints"},
+			ResourceNames: []string{"privileged"},
+			Verbs:         []string{"use"},
+		},
+		{
+			NonResourceURLs: []string{"/metrics", "/logs", "/logs/*", "/version"},
+			Verbs:           []string{"get"},
+		},
+	}
+)
+
+// ValidateProfile returns error when the profile is not supported.
+func ValidateProfile(profile string) error {
+	switch profile {
+	case ProfilePrivileged, ProfileScoped:
+		return nil
+	}
+	return fmt.Errorf("invalid RBAC profile %q, allowed values: %s, %s", profile, ProfilePrivileged, ProfileScoped)
+}
+
+// PrivilegedRules returns the rules of the privileged profile.
+func PrivilegedRules() []rbacv1.PolicyRule {
+	return []rbacv1.PolicyRule{
+		{
+			APIGroups: []string{"*"},
+			Resources: []string{"*"},
+			Verbs:     []string{"*"},
+		},
+		{
+			NonResourceURLs: []string{"/metrics", "/logs", "/logs/*"},
+			Verbs:           []string{"get"},
+		},
+	}
+}
+
+// AggregatorRules returns the rules required by the aggregator.
+func AggregatorRules() []rbacv1.PolicyRule {
+	return copyRules(aggregatorRules)
+}
+
+// ScopedRules returns the rules of the scoped profile: the aggregator rules
+// followed by the rules used by the plugins, without duplicates. The plugin
+// rules are the defaults shipped for the plugins (DefaultPluginRules),
+// extended by the rules generated by 'opct adm rbac audit --output rules' from
+// the audit logs of a previous run, and must not grant wildcards nor the verbs
+// allowing to escalate the privileges.
+func ScopedRules(pluginRules []rbacv1.PolicyRule) ([]rbacv1.PolicyRule, error) {
+	if len(pluginRules) == 0 {
+		return nil, fmt.Errorf("the %s RBAC profile requires the rules used by the plugins", ProfileScoped)
+	}
+	rules := AggregatorRules()
+	seen := map[string]struct{}{}
+	for _, rule := range rules {
+		seen[rule.String()] = struct{}{}
+	}
+	for _, rule := range pluginRules {
+		if err := validateScopedRule(&rule); err != nil {
+			return nil, err
+		}
+		if _, ok := seen[rule.String()]; ok {
+			continue
+		}
+		seen[rule.String()] = struct{}{}
+		rules = append(rules, *rule.DeepCopy())
+	}
+	return rules, nil
+}
+
+// validateScopedRule returns error when the rule grants wildcards or denied
+// verbs.
+func validateScopedRule(rule *rbacv1.PolicyRule) error {
+	for _, verb := range rule.Verbs {
+		if isDeniedVerb(verb) {
+			return fmt.Errorf("verb %q is not allowed in the %s RBAC profile: %s", verb, ProfileScoped, rule.String())
+		}
+	}
+	for _, items := range [][]string{rule.APIGroups, rule.Resources, rule.NonResourceURLs} {
+		for _, item := range items {
+			if item == "*" {
+				return fmt.Errorf("wildcards are not allowed in the %s RBAC profile: %s", ProfileScoped, rule.String())
+			}
+		}
+	}
+	return nil
+}
+
+// RulesFromUsage returns the rules granting the permissions used, one rule by
+// resource or non-resource URL with the sorted verbs. Denied verbs are not
+// granted.
+func RulesFromUsage(used []*Usage) []rbacv1.PolicyRule {
+	verbs := map[Request]map[string]struct{}{}
+	for _, usage := range used {
+		if isDeniedVerb(usage.Verb) {
+			continue
+		}
+		key := usage.Request
+		key.Verb = ""
+		if _, ok := verbs[key]; !ok {
+			verbs[key] = map[string]struct{}{}
+		}
+		verbs[key][usage.Verb] = struct{}{}
+	}
+	keys := make([]Request, 0, len(verbs))
+	for key := range verbs {
+		keys = append(keys, key)
+	}
+	// Resources are sorted before the non-resource URLs.
+	sort.Slice(keys, func(i, j int) bool {
+		if (keys[i].NonResourceURL == "") != (keys[j].NonResourceURL == "") {
+			return keys[i].NonResourceURL == ""
+		}
+		return keys[i].String() < keys[j].String()
+	})
+
+	rules := make([]rbacv1.PolicyRule, 0, len(keys))
+	for _, key := range keys {
+		rule := rbacv1.PolicyRule{}
+		for verb := range verbs[key] {
+			rule.Verbs = append(rule.Verbs, verb)
+		}
+		sort.Strings(rule.Verbs)
+		if key.NonResourceURL != "" {
+			rule.NonResourceURLs = []string{key.NonResourceURL}
+		} else {
+			rule.APIGroups = []string{key.APIGroup}
+			rule.Resources = []string{key.Resource}
+		}
+		rules = append(rules, rule)
+	}
+	return rules
+}
+
+// ReadRules reads the rules of the ClusterRole manifest (YAML or JSON).
+func ReadRules(path string) ([]rbacv1.PolicyRule, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("unable to read the RBAC rules: %w", err)
+	}
+	return ParseRules(data, path)
+}
+
+// ParseRules parses the rules of the ClusterRole manifest read from path.
+func ParseRules(data []byte, path string) ([]rbacv1.PolicyRule, error) {
+	cr := rbacv1.ClusterRole{}
+	if err := yaml.UnmarshalStrict(data, &cr); err != nil {
+		return nil, fmt.Errorf("unable to parse the RBAC rules %s: %w", path, err)
+	}
+	if cr.Kind != "ClusterRole" {
+		return nil, fmt.Errorf("invalid RBAC rules %s: kind must be ClusterRole, got %q", path, cr.Kind)
+	}
+	return cr.Rules, nil
+}
+
+func isDeniedVerb(verb string) bool {
+	for _, denied := range deniedVerbs {
+		if verb == denied {
+			return true
+		}
+	}
+	return false
+}
+
+func copyRules(rules []rbacv1.PolicyRule) []rbacv1.PolicyRule {
+	out := make([]rbacv1.PolicyRule, 0, len(rules))
+	for _, rule := range rules {
+		out = append(out, *rule.DeepCopy())
+	}
+	return out
+}
@@ -0,0 +1,112 @@
+package rbac
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	rbacv1 "k8s.io/api/rbac/v1"
+)
+
+func Test_ScopedRules(t *testing.T) {
+	pluginRules := []rbacv1.PolicyRule{
+		{APIGroups: []string{""}, Resources: []string{"pods"}, Verbs: []string{"create", "get"}},
+		{APIGroups: []string{""}, Resources: []string{"pods"}, Verbs: []string{"create", "get"}},
+		aggregatorRules[0],
+	}
+	rules, err := ScopedRules(pluginRules)
+	assert.NoError(t, err)
+	// Duplicated and aggregator rules must not be granted twice.
+	assert.Len(t, rules, len(aggregatorRules)+1)
+
+	tests := []struct {
+		name    string
+		rule    rbacv1.PolicyRule
+		wantErr string
+	}{
+		{
+			name:    "wildcard verb",
+			rule:    rbacv1.PolicyRule{APIGroups: []string{""}, Resources: []string{"pods"}, Verbs: []string{"*"}},
+			wantErr: `verb "*" is not allowed`,
+		},
+		{
+			name:    "escalate",
+			rule:    rbacv1.PolicyRule{APIGroups: []string{"rbac.authorization.k8s.io"}, Resources: []string{"clusterroles"}, Verbs: []string{"escalate"}},
+			wantErr: `verb "escalate" is not allowed`,
+		},
+		{
+			name:    "bind cluster-admin",
+			rule:    rbacv1.PolicyRule{APIGroups: []string{"rbac.authorization.k8s.io"}, Resources: []string{"clusterroles"}, ResourceNames: []string{"cluster-admin"}, Verbs: []string{"bind"}},
+			wantErr: `verb "bind" is not allowed`,
+		},
+		{
+			name:    "wildcard resource",
+			rule:    rbacv1.PolicyRule{APIGroups: []string{"*"}, Resources: []string{"*"}, Verbs: []string{"get"}},
+			wantErr: "wildcards are not allowed",
+		},
+		{
+			name:    "wildcard non-resource URL",
+			rule:    rbacv1.PolicyRule{NonResourceURLs: []string{"*"}, Verbs: []string{"get"}},
+			wantErr: "wildcards are not allowed",
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			_, err := ScopedRules([]rbacv1.PolicyRule{test.rule})
+			assert.ErrorContains(t, err, test.wantErr)
+		})
+	}
+
+	_, err = ScopedRules(nil)
+	assert.Error(t, err)
+}
+
+func Test_RulesFromUsage(t *testing.T) {
+	used := []*Usage{
+		{Request: Request{Verb: "get", Resource: "pods"}, Count: 2},
+		{Request: Request{Verb: "create", Resource: "pods"}, Count: 1},
+		{Request: Request{Verb: "get", Resource: "pods/log"}, Count: 1},
+		{Request: Request{Verb: "create", APIGroup: "apps", Resource: "deployments"}, Count: 1},
+		{Request: Request{Verb: "impersonate", Resource: "users"}, Count: 1},
+		{Request: Request{Verb: "get", NonResourceURL: "/version"}, Count: 1},
+	}
+	rules := RulesFromUsage(used)
+	assert.Equal(t, []rbacv1.PolicyRule{
+		{APIGroups: []string{"apps"}, Resources: []string{"deployments"}, Verbs: []string{"create"}},
+		{APIGroups: []string{""}, Resources: []string{"pods"}, Verbs: []string{"create", "get"}},
+		{APIGroups: []string{""}, Resources: []string{"pods/log"}, Verbs: []string{"get"}},
+		{NonResourceURLs: []string{"/version"}, Verbs: []string{"get"}},
+	}, rules)
+
+	// The generated rules are valid in the scoped profile.
+	_, err := ScopedRules(rules)
+	assert.NoError(t, err)
+}
+
+func Test_ReadRules(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "rules.yaml")
+	require.NoError(t, os.WriteFile(path, []byte(`apiVersion: rbac.authorization.k8s.io/v1
+kind: ClusterRole
+metadata:
+  name: opct-scc-privileged
+rules:
+- apiGroups: [""]
+  resources: ["pods"]
+  verbs: ["get"]
+`), 0644))
+	rules, err := ReadRules(path)
+	assert.NoError(t, err)
+	assert.Equal(t, []rbacv1.PolicyRule{{APIGroups: []string{""}, Resources: []string{"pods"}, Verbs: []string{"get"}}}, rules)
+
+	require.NoError(t, os.WriteFile(path, []byte("kind: Role\n"), 0644))
+	_, err = ReadRules(path)
+	assert.ErrorContains(t, err, "kind must be ClusterRole")
+}
+
+func Test_ValidateProfile(t *testing.T) {
+	assert.NoError(t, ValidateProfile(ProfilePrivileged))
+	assert.NoError(t, ValidateProfile(ProfileScoped))
+	assert.Error(t, ValidateProfile("admin"))
+}
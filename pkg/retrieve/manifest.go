@@ -0,0 +1,175 @@
+package retrieve
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"time"
+
+	coclient "github.com/openshift/client-go/config/clientset/versioned"
+	log "github.com/sirupsen/logrus"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+
+	"github.com/redhat-openshift-ecosystem/provider-certification-tool/pkg"
+	"github.com/redhat-openshift-ecosystem/provider-certification-tool/pkg/client"
+	"github.com/redhat-openshift-ecosystem/provider-certification-tool/pkg/version"
+)
+
+// ManifestSuffix is appended to the archive name to create the integrity
+// manifest, e.g. opct_202401011200_<run-id>.tar.gz.manifest.json.
+const ManifestSuffix = ".manifest.json"
+
+// ErrManifestNotFound is returned when the archive has no integrity manifest,
+// e.g. archives retrieved by older versions.
+var ErrManifestNotFound = errors.New("integrity manifest not found")
+
+// Manifest is the integrity manifest of the results archive, written next to
+// the archive when retrieved, allowing the report to detect archives
+// truncated or modified after the retrieval.
+type Manifest struct {
+	Archive          string    `json:"archive"`
+	SHA256           string    `json:"sha256"`
+	Size             int64     `json:"size"`
+	CreatedAt        time.Time `json:"createdAt"`
+	RunID            string    `json:"runId,omitempty"`
+	ClusterID        string    `json:"clusterId,omitempty"`
+	OpenShiftVersion string    `json:"openshiftVersion,omitempty"`
+
+	// ClientVersion is the version of the CLI retrieving the results.
+	ClientVersion string `json:"clientVersion"`
+
+	// ServerVersion is the version of the CLI which created the validation
+	// environment.
+	ServerVersion string `json:"serverVersion,omitempty"`
+
+	// Partial is set when the archive was retrieved before the validation
+	// finished, with 'opct retrieve --partial'.
+	Partial bool `json:"partial,omitempty"`
+}
+
+// ManifestPath returns the path of the integrity manifest of the archive.
+func ManifestPath(archive string) string {
+	return archive + ManifestSuffix
+}
+
+// fileDigest returns the SHA-256 and the size of the file.
+func fileDigest(path string) (string, int64, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return "", 0, err
+	}
+	defer f.Close()
+	h := sha256.New()
+	size, err := io.Copy(h, f)
+	if err != nil {
+		return "", 0, err
+	}
+	return hex.EncodeToString(h.Sum(nil)), size, nil
+}
+
+// WriteManifest writes the integrity manifest of the archive, with the
+// digest of the archive and the environment info, returning its path.
+func WriteManifest(archive string, info *Manifest) (string, error) {
+	digest, size, err := fileDigest(archive)
+	if err != nil {
+		return "", fmt.Errorf("unable to read the archive %s: %w", archive, err)
+	}
+	m := *info
+	m.Archive = filepath.Base(archive)
+	m.SHA256 = digest
+	m.Size = size
+	m.CreatedAt = time.Now().UTC().Truncate(time.Second)
+	data, err := json.MarshalIndent(&m, "", "  ")
+	if err != nil {
+		return "", err
+	}
+	path := ManifestPath(archive)
+	if err := os.WriteFile(path, append(data, '\n'), 0644); err != nil {
+		return "", fmt.Errorf("unable to write the integrity manifest %s: %w", path, err)
+	}
+	return path, nil
+}
+
+// ReadManifest reads the integrity manifest of the archive, returning
+// ErrManifestNotFound when it does not exist.
+func ReadManifest(archive string) (*Manifest, error) {
+	data, err := os.ReadFile(ManifestPath(archive))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, ErrManifestNotFound
+		}
+		return nil, err
+	}
+	m := &Manifest{}
+	if err := json.Unmarshal(data, m); err != nil {
+		return nil, fmt.Errorf("unable to parse the integrity manifest %s: %w", ManifestPath(archive), err)
+	}
+	return m, nil
+}
+
+// VerifyManifest checks the archive against its integrity manifest,
+// returning error when the archive is truncated or modified.
+func VerifyManifest(archive string) (*Manifest, error) {
+	m, err := ReadManifest(archive)
+	if err != nil {
+		return nil, err
+	}
+	digest, size, err := fileDigest(archive)
+	if err != nil {
+		return m, fmt.Errorf("unable to read the archive %s: %w", archive, err)
+	}
+	switch {
+	case size < m.Size:
+		return m, fmt.Errorf("archive is truncated: %d of %d bytes", size, m.Size)
+	case size != m.Size:
+		return m, fmt.Errorf("archive size %d differs from the manifest size %d, the archive was modified", size, m.Size)
+	case digest != m.SHA256:
+		return m, fmt.Errorf("archive SHA-256 %s differs from the manifest %s, the archive was modified", digest, m.SHA256)
+	}
+	return m, nil
+}
+
+// environmentInfo returns the manifest info of the validation environment.
+// The environment info is best-effort, missing fields are left empty.
+func environmentInfo(ctx context.Context, kclient kubernetes.Interface, runID string) *Manifest {
+	info := &Manifest{
+		RunID:         runID,
+		ClientVersion: version.Version.Version,
+	}
+	cm, err := kclient.CoreV1().ConfigMaps(pkg.GetNamespace()).Get(ctx, pkg.VersionInfoConfigMapName, metav1.GetOptions{})
+	if err != nil {
+		log.Warnf("Unable to read the server version to the integrity manifest: %v", err)
+	} else {
+		info.ServerVersion = cm.Data["cli-version"]
+	}
+	if err := clusterInfo(ctx, info); err != nil {
+		log.Warnf("Unable to read the cluster info to the integrity manifest: %v", err)
+	}
+	return info
+}
+
+// clusterInfo sets the cluster ID and the OpenShift version to the manifest.
+func clusterInfo(ctx context.Context, info *Manifest) error {
+	restConfig, err := client.CreateRestConfig()
+	if err != nil {
+		return err
+	}
+	oc, err := coclient.NewForConfig(restConfig)
+	if err != nil {
+		return err
+	}
+	cv, err := oc.ConfigV1().ClusterVersions().Get(ctx, "version", metav1.GetOptions{})
+	if err != nil {
+		return fmt.Errorf("unable to get the cluster version: %w", err)
+	}
+	info.ClusterID = string(cv.Spec.ClusterID)
+	info.OpenShiftVersion = cv.Status.Desired.Version
+	return nil
+}
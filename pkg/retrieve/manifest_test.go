@@ -0,0 +1,82 @@
+package retrieve
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_VerifyManifest(t *testing.T) {
+	content := []byte("results archive content")
+	tests := []struct {
+		name    string
+		modify  func(t *testing.T, archive string)
+		wantErr string
+	}{
+		{
+			name: "archive verified",
+		},
+		{
+			name: "archive truncated",
+			modify: func(t *testing.T, archive string) {
+				require.NoError(t, os.WriteFile(archive, content[:10], 0644))
+			},
+			wantErr: "archive is truncated: 10 of 23 bytes",
+		},
+		{
+			name: "archive with content appended",
+			modify: func(t *testing.T, archive string) {
+				require.NoError(t, os.WriteFile(archive, append(content, '!'), 0644))
+			},
+			wantErr: "archive size 24 differs from the manifest size 23, the archive was modified",
+		},
+		{
+			name: "archive modified",
+			modify: func(t *testing.T, archive string) {
+				require.NoError(t, os.WriteFile(archive, []byte("RESULTS archive content"), 0644))
+			},
+			wantErr: "the archive was modified",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			archive := filepath.Join(t.TempDir(), "opct_202401011200_id.tar.gz")
+			require.NoError(t, os.WriteFile(archive, content, 0644))
+
+			path, err := WriteManifest(archive, &Manifest{RunID: "id", ClusterID: "cluster", ClientVersion: "v0.0.0"})
+			require.NoError(t, err)
+			assert.Equal(t, archive+".manifest.json", path)
+
+			if tt.modify != nil {
+				tt.modify(t, archive)
+			}
+			m, err := VerifyManifest(archive)
+			require.NotNil(t, m)
+			assert.Equal(t, "opct_202401011200_id.tar.gz", m.Archive)
+			assert.Equal(t, "id", m.RunID)
+			assert.Equal(t, int64(len(content)), m.Size)
+			assert.Equal(t, "8b0e19451f0c7d38e7d3ddca55d27c74815d0a8fda5d55478b27bf054a8ccbc6", m.SHA256)
+			if tt.wantErr == "" {
+				assert.NoError(t, err)
+				return
+			}
+			assert.ErrorContains(t, err, tt.wantErr)
+		})
+	}
+}
+
+func Test_VerifyManifestNotFound(t *testing.T) {
+	archive := filepath.Join(t.TempDir(), "opct_202401011200_id.tar.gz")
+	require.NoError(t, os.WriteFile(archive, []byte("content"), 0644))
+
+	m, err := VerifyManifest(archive)
+	assert.Nil(t, m)
+	assert.ErrorIs(t, err, ErrManifestNotFound)
+
+	require.NoError(t, os.WriteFile(ManifestPath(archive), []byte("{"), 0644))
+	_, err = VerifyManifest(archive)
+	assert.ErrorContains(t, err, "unable to parse the integrity manifest")
+}
@@ -0,0 +1,537 @@
+package retrieve
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	coclient "github.com/openshift/client-go/config/clientset/versioned"
+	log "github.com/sirupsen/logrus"
+	sonobuoyclient "github.com/vmware-tanzu/sonobuoy/pkg/client"
+	"github.com/vmware-tanzu/sonobuoy/pkg/client/results"
+	sbconfig "github.com/vmware-tanzu/sonobuoy/pkg/config"
+	"github.com/vmware-tanzu/sonobuoy/pkg/discovery"
+	"github.com/vmware-tanzu/sonobuoy/pkg/plugin"
+	"github.com/vmware-tanzu/sonobuoy/pkg/plugin/aggregation"
+	"github.com/vmware-tanzu/sonobuoy/pkg/plugin/driver/job"
+	"github.com/vmware-tanzu/sonobuoy/pkg/plugin/loader"
+	"github.com/vmware-tanzu/sonobuoy/pkg/tarball"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/wait"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/kubernetes/scheme"
+	"k8s.io/client-go/rest"
+	"k8s.io/client-go/tools/remotecommand"
+
+	"github.com/redhat-openshift-ecosystem/provider-certification-tool/internal/opct/archive"
+	"github.com/redhat-openshift-ecosystem/provider-certification-tool/pkg"
+	"github.com/redhat-openshift-ecosystem/provider-certification-tool/pkg/client"
+	"github.com/redhat-openshift-ecosystem/provider-certification-tool/pkg/runid"
+)
+
+const (
+	sonobuoyConfigMapName    = "sonobuoy-config-cm"
+	sonobuoyPluginsCMName    = "sonobuoy-plugins-cm"
+	sonobuoyWorkerName       = "sonobuoy-worker"
+	partialPodsSelector      = "sonobuoy-component in (aggregator,plugin)"
+	partialHelperPrefix      = "opct-retrieve-"
+	partialHelperContainer   = "retrieve"
+	partialHelperTimeout     = 2 * time.Minute
+	partialHelperSleepSecs   = 600
+	partialHelperResultsPath = "/results"
+	kubeletPodsDir           = "/var/lib/kubelet/pods"
+)
+
+// RetrievePartialResults collects the results produced so far by a running
+// validation environment into a partial archive in the destination directory:
+// the results of the plugins already received by the aggregator, the files of
+// the plugins in progress, the pod logs and the cluster resources required by
+// the report. The archive is marked with archive.PartialInfoFile.
+func RetrievePartialResults(ctx context.Context, kclient kubernetes.Interface, sclient sonobuoyclient.Interface, destinationDirectory string) ([]string, error) {
+	status, err := sclient.GetStatus(&sonobuoyclient.StatusConfig{Namespace: pkg.GetNamespace()})
+	if err != nil {
+		return nil, fmt.Errorf("unable to get the aggregator status: %w", err)
+	}
+	if status.Status == aggregation.CompleteStatus {
+		log.Info("The validation environment finished, retrieving the final results archive")
+		return RetrieveResults(kclient, sclient, destinationDirectory, DefaultRetrieveRetryLimit)
+	}
+
+	aggPod, err := aggregation.GetAggregatorPod(kclient, pkg.GetNamespace())
+	if err != nil {
+		return nil, fmt.Errorf("unable to get the aggregator pod: %w", err)
+	}
+	restConfig, err := client.CreateRestConfig()
+	if err != nil {
+		return nil, err
+	}
+	workDir, err := os.MkdirTemp("", "opct-partial-")
+	if err != nil {
+		return nil, err
+	}
+	defer os.RemoveAll(workDir)
+
+	runID := runid.SetLoggerFromCluster(ctx, kclient)
+	pr := &partialRetriever{
+		kclient:    kclient,
+		restConfig: restConfig,
+		namespace:  pkg.GetNamespace(),
+		dir:        workDir,
+		info:       archive.NewPartialInfo(runID),
+	}
+	pr.info.AggregatorStatus = status.Status
+
+	cfg, err := pr.loadConfig(ctx)
+	if err != nil {
+		return nil, err
+	}
+	plugins, err := pr.loadPlugins(ctx, cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	log.Info("Collecting the results received by the aggregator...")
+	if err := pr.collectAggregator(ctx, aggPod, cfg.UUID); err != nil {
+		log.Warnf("Unable to collect the results received by the aggregator: %v", err)
+	}
+	for _, p := range plugins {
+		if hasPluginResults(workDir, p.GetName()) {
+			pr.info.Plugins[p.GetName()] = archive.PartialPluginComplete
+			continue
+		}
+		log.Infof("Collecting the results in progress of plugin %s...", p.GetName())
+		if err := pr.collectPlugin(ctx, p.GetName()); err != nil {
+			log.Warnf("Unable to collect the results in progress of plugin %s: %v", p.GetName(), err)
+			continue
+		}
+		pr.info.Plugins[p.GetName()] = archive.PartialPluginInProgress
+	}
+
+	log.Info("Collecting the pod logs and the cluster resources...")
+	if err := pr.collectPodLogs(ctx); err != nil {
+		log.Warnf("Unable to collect the pod logs: %v", err)
+	}
+	if err := pr.collectClusterResources(ctx); err != nil {
+		log.Warnf("Unable to collect the cluster resources: %v", err)
+	}
+	if err := writeJSONFile(workDir, "meta/config.json", cfg, false); err != nil {
+		return nil, err
+	}
+	if err := assemblePartial(workDir, plugins, pr.info); err != nil {
+		return nil, err
+	}
+
+	file := filepath.Join(destinationDirectory, partialFileName(time.Now().UTC(), runID))
+	if err := tarball.DirToTarball(workDir, file, true); err != nil {
+		return nil, fmt.Errorf("unable to create the partial archive: %w", err)
+	}
+	log.Infof("Partial results saved to %s", file)
+
+	info := environmentInfo(ctx, kclient, runID)
+	info.Partial = true
+	manifest, err := WriteManifest(file, info)
+	if err != nil {
+		return nil, err
+	}
+	log.Infof("Integrity manifest saved to %s, share it with the results archive", manifest)
+	return []string{file, manifest}, nil
+}
+
+// partialFileName returns the name of the partial archive, following the
+// archive name created by retrieve, e.g. opct_202401011200_<run-id>_partial.tar.gz.
+func partialFileName(now time.Time, runID string) string {
+	if runID == "" {
+		return fmt.Sprintf("opct_%s_partial.tar.gz", now.Format("200601021504"))
+	}
+	return fmt.Sprintf("opct_%s_%s_partial.tar.gz", now.Format("200601021504"), runID)
+}
+
+// assemblePartial creates the metadata the report requires from the results
+// collected in dir: the processed results and the definition of the plugins
+// with results, the run info and the partial marker.
+func assemblePartial(dir string, plugins []plugin.Interface, info *archive.PartialInfo) error {
+	loaded := []string{}
+	for _, p := range plugins {
+		name := p.GetName()
+		if !hasPluginResults(dir, name) {
+			info.Plugins[name] = archive.PartialPluginMissing
+			continue
+		}
+		if _, ok := info.Plugins[name]; !ok {
+			info.Plugins[name] = archive.PartialPluginComplete
+		}
+		item, errs := results.PostProcessPlugin(p, dir)
+		for _, err := range errs {
+			log.Warnf("Processing the results of plugin %s: %v", name, err)
+		}
+		if err := results.SaveProcessedResults(name, dir, item); err != nil {
+			return fmt.Errorf("unable to save the results of plugin %s: %w", name, err)
+		}
+		if err := writeJSONFile(dir, filepath.Join(results.PluginsDir, name, "definition.json"), p, true); err != nil {
+			return err
+		}
+		loaded = append(loaded, name)
+	}
+	sort.Strings(loaded)
+
+	if err := writeJSONFile(dir, filepath.Join("meta", results.InfoFile), &discovery.RunInfo{LoadedPlugins: loaded}, true); err != nil {
+		return err
+	}
+	return writeJSONFile(dir, archive.PartialInfoFile, info, true)
+}
+
+// hasPluginResults returns true when the plugin has at least one results file.
+func hasPluginResults(dir, name string) bool {
+	found := false
+	_ = filepath.Walk(filepath.Join(dir, results.PluginsDir, name, results.ResultsDir), func(path string, info os.FileInfo, err error) error {
+		if err != nil || found {
+			return filepath.SkipDir
+		}
+		found = info.Mode().IsRegular()
+		return nil
+	})
+	return found
+}
+
+// writeJSONFile writes the object to the path relative to dir, keeping the
+// existing file when overwrite is false.
+func writeJSONFile(dir, name string, obj interface{}, overwrite bool) error {
+	path := filepath.Join(dir, name)
+	if _, err := os.Stat(path); err == nil && !overwrite {
+		return nil
+	}
+	data, err := json.Marshal(obj)
+	if err != nil {
+		return fmt.Errorf("unable to encode %s: %w", name, err)
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return err
+	}
+	return os.WriteFile(path, data, 0644)
+}
+
+// resultsContainer returns the running plugin container sharing the results
+// directory with the Sonobuoy worker.
+func resultsContainer(pod *corev1.Pod) string {
+	running := map[string]bool{}
+	for _, cs := range pod.Status.ContainerStatuses {
+		running[cs.Name] = cs.State.Running != nil
+	}
+	for _, c := range pod.Spec.Containers {
+		if c.Name == sonobuoyWorkerName || !running[c.Name] {
+			continue
+		}
+		for _, vm := range c.VolumeMounts {
+			if vm.MountPath == plugin.ResultsDir {
+				return c.Name
+			}
+		}
+	}
+	return ""
+}
+
+// partialRetriever collects the partial results of the environment to dir.
+type partialRetriever struct {
+	kclient    kubernetes.Interface
+	restConfig *rest.Config
+	namespace  string
+	dir        string
+	info       *archive.PartialInfo
+}
+
+// loadConfig reads the Sonobuoy configuration of the environment.
+func (pr *partialRetriever) loadConfig(ctx context.Context) (*sbconfig.Config, error) {
+	cm, err := pr.kclient.CoreV1().ConfigMaps(pr.namespace).Get(ctx, sonobuoyConfigMapName, metav1.GetOptions{})
+	if err != nil {
+		return nil, fmt.Errorf("unable to get the Sonobuoy config: %w", err)
+	}
+	cfg := &sbconfig.Config{}
+	if err := json.Unmarshal([]byte(cm.Data["config.json"]), cfg); err != nil {
+		return nil, fmt.Errorf("unable to parse the Sonobuoy config: %w", err)
+	}
+	return cfg, nil
+}
+
+// loadPlugins reads the plugins definitions scheduled by the aggregator.
+func (pr *partialRetriever) loadPlugins(ctx context.Context, cfg *sbconfig.Config) ([]plugin.Interface, error) {
+	cm, err := pr.kclient.CoreV1().ConfigMaps(pr.namespace).Get(ctx, sonobuoyPluginsCMName, metav1.GetOptions{})
+	if err != nil {
+		return nil, fmt.Errorf("unable to get the plugins definitions: %w", err)
+	}
+	keys := make([]string, 0, len(cm.Data))
+	for k := range cm.Data {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	plugins := []plugin.Interface{}
+	for _, k := range keys {
+		def, err := loader.LoadDefinition([]byte(cm.Data[k]))
+		if err != nil {
+			return nil, fmt.Errorf("unable to parse the plugin definition %s: %w", k, err)
+		}
+		// OPCT plugins are Job plugins, sending the results to the global directory.
+		plugins = append(plugins, job.NewPlugin(def, pr.namespace, cfg.WorkerImage, cfg.ImagePullPolicy, cfg.ImagePullSecrets, cfg.CustomAnnotations))
+	}
+	return plugins, nil
+}
+
+// collectAggregator copies the results received by the aggregator. The
+// aggregator image has no shell and the results are stored in an emptyDir
+// volume, so a short-lived helper pod is created in the aggregator node,
+// mounting the volume directory read-only to archive the results, and
+// deleted when the copy finishes.
+func (pr *partialRetriever) collectAggregator(ctx context.Context, pod *corev1.Pod, uuid string) error {
+	if pod == nil || uuid == "" {
+		return fmt.Errorf("aggregator pod or run UUID not found")
+	}
+	helper, err := newAggregatorHelperPod(pod, pr.helperImage(ctx), pr.info.RunID)
+	if err != nil {
+		return err
+	}
+	helper, err = pr.kclient.CoreV1().Pods(pr.namespace).Create(ctx, helper, metav1.CreateOptions{})
+	if err != nil {
+		return fmt.Errorf("unable to create the helper pod: %w", err)
+	}
+	defer func() {
+		// The helper pod is removed also when the retrieve is canceled.
+		if err := pr.kclient.CoreV1().Pods(pr.namespace).Delete(context.Background(), helper.Name, metav1.DeleteOptions{}); err != nil {
+			log.Warnf("Unable to delete the helper pod %s: %v", helper.Name, err)
+		}
+	}()
+
+	err = wait.PollUntilContextTimeout(ctx, 2*time.Second, partialHelperTimeout, true, func(ctx context.Context) (bool, error) {
+		p, err := pr.kclient.CoreV1().Pods(pr.namespace).Get(ctx, helper.Name, metav1.GetOptions{})
+		if err != nil {
+			return false, nil
+		}
+		switch p.Status.Phase {
+		case corev1.PodRunning:
+			return true, nil
+		case corev1.PodFailed, corev1.PodSucceeded:
+			return false, fmt.Errorf("helper pod %s finished: %s", helper.Name, p.Status.Phase)
+		}
+		return false, nil
+	})
+	if err != nil {
+		return fmt.Errorf("waiting for the helper pod %s: %w", helper.Name, err)
+	}
+	return pr.execTar(ctx, helper.Name, partialHelperContainer, filepath.Join(partialHelperResultsPath, uuid), pr.dir)
+}
+
+// newAggregatorHelperPod returns the pod reading the results volume of the
+// aggregator pod. The emptyDir volume can't be mounted by other pods, so the
+// helper runs in the same node mounting the volume directory from the kubelet,
+// privileged to read the files labeled to the aggregator pod.
+func newAggregatorHelperPod(agg *corev1.Pod, image, runID string) (*corev1.Pod, error) {
+	if agg.Spec.NodeName == "" {
+		return nil, fmt.Errorf("aggregator pod %s is not scheduled", agg.Name)
+	}
+	volume := ""
+	for _, c := range agg.Spec.Containers {
+		if c.Name != sbconfig.AggregatorContainerName {
+			continue
+		}
+		for _, vm := range c.VolumeMounts {
+			if vm.MountPath == sbconfig.AggregatorResultsPath {
+				volume = vm.Name
+			}
+		}
+	}
+	found := false
+	for _, v := range agg.Spec.Volumes {
+		if v.Name == volume && v.EmptyDir != nil {
+			found = true
+		}
+	}
+	if !found {
+		return nil, fmt.Errorf("results emptyDir volume not found in the aggregator pod %s", agg.Name)
+	}
+
+	labels := map[string]string{}
+	if runID != "" {
+		labels[runid.Label] = runID
+	}
+	hostPathType := corev1.HostPathDirectory
+	privileged := true
+	return &corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      fmt.Sprintf("%s%d", partialHelperPrefix, time.Now().Unix()),
+			Namespace: agg.Namespace,
+			Labels:    labels,
+		},
+		Spec: corev1.PodSpec{
+			NodeName:           agg.Spec.NodeName,
+			ServiceAccountName: agg.Spec.ServiceAccountName,
+			RestartPolicy:      corev1.RestartPolicyNever,
+			Tolerations:        []corev1.Toleration{{Operator: corev1.TolerationOpExists}},
+			Containers: []corev1.Container{{
+				Name:            partialHelperContainer,
+				Image:           image,
+				ImagePullPolicy: corev1.PullIfNotPresent,
+				Command:         []string{"/bin/sh", "-c", fmt.Sprintf("sleep %d", partialHelperSleepSecs)},
+				SecurityContext: &corev1.SecurityContext{Privileged: &privileged},
+				VolumeMounts:    []corev1.VolumeMount{{Name: "results", MountPath: partialHelperResultsPath, ReadOnly: true}},
+			}},
+			Volumes: []corev1.Volume{{
+				Name: "results",
+				VolumeSource: corev1.VolumeSource{HostPath: &corev1.HostPathVolumeSource{
+					Path: filepath.Join(kubeletPodsDir, string(agg.UID), "volumes", "kubernetes.io~empty-dir", volume),
+					Type: &hostPathType,
+				}},
+			}},
+		},
+	}, nil
+}
+
+// helperImage returns the image of the running plugins, available in the
+// cluster also in disconnected environments, defaulting to the plugins image.
+func (pr *partialRetriever) helperImage(ctx context.Context) string {
+	pods, err := pr.kclient.CoreV1().Pods(pr.namespace).List(ctx, metav1.ListOptions{LabelSelector: "sonobuoy-component=plugin"})
+	if err == nil {
+		for _, p := range pods.Items {
+			for _, c := range p.Spec.Containers {
+				if c.Name == "plugin" {
+					return c.Image
+				}
+			}
+		}
+	}
+	return pkg.GetPluginsImage()
+}
+
+// collectPlugin copies the files produced so far by the plugin pod. Only the
+// results directory is copied, the shared directory holds the credentials
+// used by the plugin.
+func (pr *partialRetriever) collectPlugin(ctx context.Context, name string) error {
+	pods, err := pr.kclient.CoreV1().Pods(pr.namespace).List(ctx, metav1.ListOptions{
+		LabelSelector: fmt.Sprintf("sonobuoy-component=plugin,sonobuoy-plugin=%s", name),
+	})
+	if err != nil {
+		return err
+	}
+	for i := range pods.Items {
+		container := resultsContainer(&pods.Items[i])
+		if container == "" {
+			continue
+		}
+		dest := filepath.Join(pr.dir, results.PluginsDir, name, results.ResultsDir, "global")
+		if err := pr.execTar(ctx, pods.Items[i].Name, container, plugin.ResultsDir, dest); err != nil {
+			return err
+		}
+		if !hasPluginResults(pr.dir, name) {
+			return fmt.Errorf("no results produced by the plugin pod %s", pods.Items[i].Name)
+		}
+		return nil
+	}
+	return fmt.Errorf("no running plugin pod found")
+}
+
+// execTar archives the directory in the container, extracting it to dest.
+func (pr *partialRetriever) execTar(ctx context.Context, pod, container, dir, dest string) error {
+	req := pr.kclient.CoreV1().RESTClient().Post().
+		Resource("pods").
+		Name(pod).
+		Namespace(pr.namespace).
+		SubResource("exec")
+	req.VersionedParams(&corev1.PodExecOptions{
+		Container: container,
+		Command:   []string{"tar", "czf", "-", "-C", dir, "."},
+		Stdout:    true,
+		Stderr:    true,
+	}, scheme.ParameterCodec)
+	executor, err := remotecommand.NewSPDYExecutor(pr.restConfig, "POST", req.URL())
+	if err != nil {
+		return err
+	}
+
+	reader, writer := io.Pipe()
+	stderr := &bytes.Buffer{}
+	go func() {
+		writer.CloseWithError(executor.StreamWithContext(ctx, remotecommand.StreamOptions{Stdout: writer, Stderr: stderr}))
+	}()
+	if err := tarball.DecodeTarball(reader, dest); err != nil {
+		reader.CloseWithError(err)
+		return fmt.Errorf("unable to copy %s from %s/%s: %w %s", dir, pod, container, err, strings.TrimSpace(stderr.String()))
+	}
+	return nil
+}
+
+// collectPodLogs saves the logs of the aggregator and plugin pods, in the
+// same location of the final archive.
+func (pr *partialRetriever) collectPodLogs(ctx context.Context) error {
+	pods, err := pr.kclient.CoreV1().Pods(pr.namespace).List(ctx, metav1.ListOptions{LabelSelector: partialPodsSelector})
+	if err != nil {
+		return err
+	}
+	for _, pod := range pods.Items {
+		for _, c := range pod.Spec.Containers {
+			data, err := pr.kclient.CoreV1().Pods(pr.namespace).GetLogs(pod.Name, &corev1.PodLogOptions{Container: c.Name}).DoRaw(ctx)
+			if err != nil {
+				log.Warnf("Unable to get the logs of %s/%s: %v", pod.Name, c.Name, err)
+				continue
+			}
+			path := filepath.Join(pr.dir, discovery.PodLogsLocation, pod.Namespace, pod.Name, "logs", c.Name+".txt")
+			if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+				return err
+			}
+			if err := os.WriteFile(path, data, 0644); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// collectClusterResources saves the cluster resources read by the report,
+// collected by the aggregator only when the validation finishes.
+func (pr *partialRetriever) collectClusterResources(ctx context.Context) error {
+	oc, err := coclient.NewForConfig(pr.restConfig)
+	if err != nil {
+		return err
+	}
+	cv1 := oc.ConfigV1()
+	resources := map[string]func() (interface{}, error){
+		"config.openshift.io_v1_clusterversions.json": func() (interface{}, error) {
+			return cv1.ClusterVersions().List(ctx, metav1.ListOptions{})
+		},
+		"config.openshift.io_v1_infrastructures.json": func() (interface{}, error) {
+			return cv1.Infrastructures().List(ctx, metav1.ListOptions{})
+		},
+		"config.openshift.io_v1_clusteroperators.json": func() (interface{}, error) {
+			return cv1.ClusterOperators().List(ctx, metav1.ListOptions{})
+		},
+		"config.openshift.io_v1_networks.json": func() (interface{}, error) {
+			return cv1.Networks().List(ctx, metav1.ListOptions{})
+		},
+		results.CoreNodesFile: func() (interface{}, error) {
+			return pr.kclient.CoreV1().Nodes().List(ctx, metav1.ListOptions{})
+		},
+	}
+	for file, list := range resources {
+		obj, err := list()
+		if err != nil {
+			log.Warnf("Unable to collect %s: %v", file, err)
+			continue
+		}
+		if err := writeJSONFile(pr.dir, filepath.Join(discovery.ClusterResourceLocation, file), obj, false); err != nil {
+			return err
+		}
+	}
+
+	cms, err := pr.kclient.CoreV1().ConfigMaps(pr.namespace).List(ctx, metav1.ListOptions{})
+	if err != nil {
+		log.Warnf("Unable to collect the config maps of %s: %v", pr.namespace, err)
+	} else if err := writeJSONFile(pr.dir, filepath.Join("resources/ns", pr.namespace, "core_v1_configmaps.json"), cms, false); err != nil {
+		return err
+	}
+	return discovery.SaveHealthSummary(pr.dir)
+}
@@ -0,0 +1,150 @@
+package retrieve
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	sbconfig "github.com/vmware-tanzu/sonobuoy/pkg/config"
+	"github.com/vmware-tanzu/sonobuoy/pkg/discovery"
+	"github.com/vmware-tanzu/sonobuoy/pkg/plugin"
+	"github.com/vmware-tanzu/sonobuoy/pkg/plugin/driver/job"
+	"github.com/vmware-tanzu/sonobuoy/pkg/plugin/manifest"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"github.com/redhat-openshift-ecosystem/provider-certification-tool/internal/opct/archive"
+	"github.com/redhat-openshift-ecosystem/provider-certification-tool/pkg/runid"
+)
+
+const testJUnit = `<?xml version="1.0" encoding="UTF-8"?>
+<testsuite name="openshift-tests" tests="2" failures="1">
+  <testcase name="test passed" time="1"></testcase>
+  <testcase name="test failed" time="1"><failure message="failed">failed</failure></testcase>
+</testsuite>
+`
+
+func Test_PartialFileName(t *testing.T) {
+	now := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	assert.Equal(t, "opct_202401011200_id_partial.tar.gz", partialFileName(now, "id"))
+	assert.Equal(t, "opct_202401011200_partial.tar.gz", partialFileName(now, ""))
+}
+
+func Test_AssemblePartial(t *testing.T) {
+	newPlugin := func(name string) plugin.Interface {
+		def := manifest.Manifest{SonobuoyConfig: manifest.SonobuoyConfig{
+			PluginName:   name,
+			Driver:       "Job",
+			ResultFormat: "junit",
+		}}
+		return job.NewPlugin(def, "opct", "sonobuoy:v0", "IfNotPresent", "", nil)
+	}
+	plugins := []plugin.Interface{
+		newPlugin("10-openshift-kube-conformance"),
+		newPlugin("20-openshift-conformance-validated"),
+		newPlugin("99-openshift-artifacts-collector"),
+	}
+
+	dir := t.TempDir()
+	for _, name := range []string{"10-openshift-kube-conformance", "20-openshift-conformance-validated"} {
+		results := filepath.Join(dir, "plugins", name, "results", "global")
+		require.NoError(t, os.MkdirAll(results, 0755))
+		require.NoError(t, os.WriteFile(filepath.Join(results, "junit_e2e.xml"), []byte(testJUnit), 0644))
+	}
+	// Plugin scheduled without results.
+	require.NoError(t, os.MkdirAll(filepath.Join(dir, "plugins", "99-openshift-artifacts-collector", "results", "global"), 0755))
+
+	info := archive.NewPartialInfo("id")
+	info.Plugins["20-openshift-conformance-validated"] = archive.PartialPluginInProgress
+	require.NoError(t, assemblePartial(dir, plugins, info))
+
+	readJSON := func(name string, obj interface{}) {
+		data, err := os.ReadFile(filepath.Join(dir, name))
+		require.NoError(t, err)
+		require.NoError(t, json.Unmarshal(data, obj))
+	}
+	runInfo := discovery.RunInfo{}
+	readJSON("meta/info.json", &runInfo)
+	assert.Equal(t, []string{"10-openshift-kube-conformance", "20-openshift-conformance-validated"}, runInfo.LoadedPlugins)
+
+	got := archive.PartialInfo{}
+	readJSON(archive.PartialInfoFile, &got)
+	assert.True(t, got.Partial)
+	assert.Equal(t, "id", got.RunID)
+	assert.Equal(t, map[string]string{
+		"10-openshift-kube-conformance":      archive.PartialPluginComplete,
+		"20-openshift-conformance-validated": archive.PartialPluginInProgress,
+		"99-openshift-artifacts-collector":   archive.PartialPluginMissing,
+	}, got.Plugins)
+
+	definition := struct {
+		Definition *manifest.Manifest
+	}{}
+	readJSON("plugins/10-openshift-kube-conformance/definition.json", &definition)
+	require.NotNil(t, definition.Definition)
+	assert.Equal(t, "10-openshift-kube-conformance", definition.Definition.SonobuoyConfig.PluginName)
+
+	assert.FileExists(t, filepath.Join(dir, "plugins/10-openshift-kube-conformance/sonobuoy_results.yaml"))
+	assert.NoFileExists(t, filepath.Join(dir, "plugins/99-openshift-artifacts-collector/sonobuoy_results.yaml"))
+}
+
+func Test_ResultsContainer(t *testing.T) {
+	resultsMount := []corev1.VolumeMount{{Name: "results", MountPath: plugin.ResultsDir}}
+	running := corev1.ContainerState{Running: &corev1.ContainerStateRunning{}}
+	terminated := corev1.ContainerState{Terminated: &corev1.ContainerStateTerminated{}}
+	newPod := func(pluginState corev1.ContainerState) *corev1.Pod {
+		return &corev1.Pod{
+			Spec: corev1.PodSpec{Containers: []corev1.Container{
+				{Name: "tests"},
+				{Name: "plugin", VolumeMounts: resultsMount},
+				{Name: "sonobuoy-worker", VolumeMounts: resultsMount},
+			}},
+			Status: corev1.PodStatus{ContainerStatuses: []corev1.ContainerStatus{
+				{Name: "tests", State: running},
+				{Name: "plugin", State: pluginState},
+				{Name: "sonobuoy-worker", State: running},
+			}},
+		}
+	}
+	assert.Equal(t, "plugin", resultsContainer(newPod(running)))
+	assert.Equal(t, "", resultsContainer(newPod(terminated)), "the worker container must not be used")
+}
+
+func Test_NewAggregatorHelperPod(t *testing.T) {
+	agg := &corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{Name: "sonobuoy", Namespace: "opct", UID: "1234"},
+		Spec: corev1.PodSpec{
+			NodeName:           "master-0",
+			ServiceAccountName: "sonobuoy-serviceaccount",
+			Containers: []corev1.Container{{
+				Name:         sbconfig.AggregatorContainerName,
+				VolumeMounts: []corev1.VolumeMount{{Name: "output-volume", MountPath: sbconfig.AggregatorResultsPath}},
+			}},
+			Volumes: []corev1.Volume{{Name: "output-volume", VolumeSource: corev1.VolumeSource{EmptyDir: &corev1.EmptyDirVolumeSource{}}}},
+		},
+	}
+
+	pod, err := newAggregatorHelperPod(agg, "quay.io/opct/plugin-openshift-tests:v0", "id")
+	require.NoError(t, err)
+	assert.Equal(t, "opct", pod.Namespace)
+	assert.Equal(t, "master-0", pod.Spec.NodeName)
+	assert.Equal(t, "sonobuoy-serviceaccount", pod.Spec.ServiceAccountName)
+	assert.Equal(t, map[string]string{runid.Label: "id"}, pod.Labels)
+	require.Len(t, pod.Spec.Volumes, 1)
+	assert.Equal(t, "/var/lib/kubelet/pods/1234/volumes/kubernetes.io~empty-dir/output-volume", pod.Spec.Volumes[0].HostPath.Path)
+	require.Len(t, pod.Spec.Containers, 1)
+	assert.True(t, pod.Spec.Containers[0].VolumeMounts[0].ReadOnly)
+	assert.Empty(t, agg.Spec.EphemeralContainers, "the aggregator pod must not be changed")
+
+	agg.Spec.Volumes[0].EmptyDir = nil
+	_, err = newAggregatorHelperPod(agg, "quay.io/opct/plugin-openshift-tests:v0", "id")
+	assert.ErrorContains(t, err, "results emptyDir volume not found")
+
+	agg.Spec.NodeName = ""
+	_, err = newAggregatorHelperPod(agg, "quay.io/opct/plugin-openshift-tests:v0", "id")
+	assert.ErrorContains(t, err, "is not scheduled")
+}
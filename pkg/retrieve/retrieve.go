@@ -1,12 +1,14 @@
 package retrieve
 
 import (
+	"context"
 	"fmt"
 	"io"
 	"os"
-	"time"
-	"strings"
 	"path/filepath"
+	"regexp"
+	"strings"
+	"time"
 
 	"github.com/pkg/errors"
 	log "github.com/sirupsen/logrus"
@@ -14,18 +16,28 @@ import (
 	sonobuoyclient "github.com/vmware-tanzu/sonobuoy/pkg/client"
 	config2 "github.com/vmware-tanzu/sonobuoy/pkg/config"
 	"golang.org/x/sync/errgroup"
+	"k8s.io/client-go/kubernetes"
 
 	"github.com/redhat-openshift-ecosystem/provider-certification-tool/pkg"
 	"github.com/redhat-openshift-ecosystem/provider-certification-tool/pkg/client"
+	"github.com/redhat-openshift-ecosystem/provider-certification-tool/pkg/runid"
 	"github.com/redhat-openshift-ecosystem/provider-certification-tool/pkg/status"
 )
 
+// DefaultRetrieveRetryLimit is the number of attempts to download the results.
+const DefaultRetrieveRetryLimit = 10
+
 func NewCmdRetrieve() *cobra.Command {
-	return &cobra.Command{
+	var partial bool
+	cmd := &cobra.Command{
 		Use:   "retrieve",
 		Args:  cobra.MaximumNArgs(1),
 		Short: "Collect results from validation environment",
-		Long:  `Downloads the results archive from the validation environment`,
+		Long: `Downloads the results archive from the validation environment.
+
+With --partial, the results produced so far are collected from a running
+validation environment into a partial archive, which can be processed by the
+report ignoring the plugins without results.`,
 		RunE: func(cmd *cobra.Command, args []string) error {
 			destinationDirectory, err := os.Getwd()
 			if err != nil {
@@ -53,9 +65,18 @@ func NewCmdRetrieve() *cobra.Command {
 				return fmt.Errorf("retrieve finished with errors: %v", err)
 			}
 
+			if partial {
+				log.Warn("Collecting partial results from the running validation environment, the archive does not contain the complete results.")
+				if _, err := RetrievePartialResults(cmd.Context(), kclient, sclient, destinationDirectory); err != nil {
+					return fmt.Errorf("retrieve finished with errors: %v", err)
+				}
+				log.Info("Use the report command to check the partial results, plugins without results are ignored.")
+				return nil
+			}
+
 			log.Info("Collecting results...")
 
-			if err := retrieveResultsRetry(sclient, destinationDirectory); err != nil {
+			if _, err := RetrieveResults(kclient, sclient, destinationDirectory, DefaultRetrieveRetryLimit); err != nil {
 				return fmt.Errorf("retrieve finished with errors: %v", err)
 			}
 
@@ -63,15 +84,22 @@ func NewCmdRetrieve() *cobra.Command {
 			return nil
 		},
 	}
+	cmd.Flags().BoolVar(&partial, "partial", false, "Collect the results produced so far by a running validation environment into a partial archive.")
+	return cmd
 }
 
-func retrieveResultsRetry(sclient sonobuoyclient.Interface, destinationDirectory string) error {
+// RetrieveResults downloads the results archive to the destination directory,
+// retrying up to limit times. It returns the files saved, named by the run ID
+// when available, and the integrity manifest of the archive.
+func RetrieveResults(kclient kubernetes.Interface, sclient sonobuoyclient.Interface, destinationDirectory string, limit int) ([]string, error) {
+	runID := runid.SetLoggerFromCluster(context.TODO(), kclient)
+	info := environmentInfo(context.TODO(), kclient, runID)
+	var files []string
 	var err error
-	limit := 10 // Retry retrieve 10 times
 	pause := time.Second * 2
 	retries := 1
 	for retries <= limit {
-		err = retrieveResults(sclient, destinationDirectory)
+		files, err = retrieveResults(sclient, destinationDirectory, info)
 		if err != nil {
 			log.Warn(err)
 			if retries+1 < limit {
@@ -81,40 +109,64 @@ func retrieveResultsRetry(sclient sonobuoyclient.Interface, destinationDirectory
 			retries++
 			continue
 		}
-		return nil // Retrieved results without a problem
+		return files, nil // Retrieved results without a problem
 	}
 
-	return errors.New("Retrieval retry limit reached")
+	return nil, errors.Wrap(err, "Retrieval retry limit reached")
 }
 
-func retrieveResults(sclient sonobuoyclient.Interface, destinationDirectory string) error {
+func retrieveResults(sclient sonobuoyclient.Interface, destinationDirectory string, info *Manifest) ([]string, error) {
 	// Get a reader that contains the tar output of the results directory.
 	reader, ec, err := sclient.RetrieveResults(&sonobuoyclient.RetrieveConfig{
-		Namespace: pkg.CertificationNamespace,
+		Namespace: pkg.GetNamespace(),
 		Path:      config2.AggregatorResultsPath,
 	})
 	if err != nil {
-		return errors.Wrap(err, "error retrieving results from sonobuoy")
+		return nil, errors.Wrap(err, "error retrieving results from sonobuoy")
 	}
 
 	// Download results into target directory
 	results, err := writeResultsToDirectory(destinationDirectory, reader, ec)
 	if err != nil {
-		return errors.Wrap(err, "error retrieving results from sonobyuoy")
+		return nil, errors.Wrap(err, "error retrieving results from sonobyuoy")
 	}
 
 	// Log the new files to stdout
+	files := make([]string, 0, len(results))
 	for _, result := range results {
-		// Rename the file prepending 'opct_' to the name.
-		newFile := fmt.Sprintf("%s/opct_%s", filepath.Dir(result), strings.Replace(filepath.Base(result), "sonobuoy_", "", 1))
+		newFile := filepath.Join(filepath.Dir(result), resultFileName(filepath.Base(result), info.RunID))
 		log.Debugf("Renaming %s to %s", result, newFile)
 		if err := os.Rename(result, newFile); err != nil {
-			return fmt.Errorf("error renaming %s to %s: %w", result, newFile, err)
+			return nil, fmt.Errorf("error renaming %s to %s: %w", result, newFile, err)
 		}
 		log.Infof("Results saved to %s", newFile)
+		files = append(files, newFile)
+		if !strings.HasSuffix(newFile, ".tar.gz") {
+			continue
+		}
+		manifest, err := WriteManifest(newFile, info)
+		if err != nil {
+			return nil, err
+		}
+		log.Infof("Integrity manifest saved to %s, share it with the results archive", manifest)
+		files = append(files, manifest)
 	}
 
-	return nil
+	return files, nil
+}
+
+// reSonobuoyArchive matches the archive name created by Sonobuoy:
+// <timestamp>_sonobuoy_<sonobuoy-uuid>.tar.gz
+var reSonobuoyArchive = regexp.MustCompile(`^([0-9]+)_sonobuoy_.+\.tar\.gz$`)
+
+// resultFileName returns the name of the retrieved file prefixed by 'opct_'.
+// The archive is named by the run ID when available, correlating it with the
+// logs and the cluster objects, e.g. opct_202401011200_<run-id>.tar.gz.
+func resultFileName(name, runID string) string {
+	if m := reSonobuoyArchive.FindStringSubmatch(name); m != nil && runID != "" {
+		return fmt.Sprintf("opct_%s_%s.tar.gz", m[1], runID)
+	}
+	return fmt.Sprintf("opct_%s", strings.Replace(name, "sonobuoy_", "", 1))
 }
 
 func writeResultsToDirectory(outputDir string, r io.Reader, ec <-chan error) ([]string, error) {
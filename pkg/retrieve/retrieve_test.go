@@ -0,0 +1,39 @@
+package retrieve
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_ResultFileName(t *testing.T) {
+	tests := []struct {
+		name  string
+		file  string
+		runID string
+		want  string
+	}{
+		{
+			name:  "archive named by the run ID",
+			file:  "202401011200_sonobuoy_0d1f3c2a-5a8e-4b8e-9c3f-1e0b8a7c6d5e.tar.gz",
+			runID: "6f1c3a52-2f0e-4a8e-9d5b-0b9e6c2a1f00",
+			want:  "opct_202401011200_6f1c3a52-2f0e-4a8e-9d5b-0b9e6c2a1f00.tar.gz",
+		},
+		{
+			name: "archive without run ID",
+			file: "202401011200_sonobuoy_0d1f3c2a-5a8e-4b8e-9c3f-1e0b8a7c6d5e.tar.gz",
+			want: "opct_202401011200_0d1f3c2a-5a8e-4b8e-9c3f-1e0b8a7c6d5e.tar.gz",
+		},
+		{
+			name:  "other files keep the name",
+			file:  "sonobuoy_results.txt",
+			runID: "6f1c3a52-2f0e-4a8e-9d5b-0b9e6c2a1f00",
+			want:  "opct_results.txt",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, resultFileName(tt.file, tt.runID))
+		})
+	}
+}
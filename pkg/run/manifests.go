@@ -2,13 +2,21 @@ package run
 
 import (
 	"bytes"
+	"fmt"
+	"sort"
+	"strings"
 	"text/template"
 
 	"github.com/pkg/errors"
 	efs "github.com/redhat-openshift-ecosystem/provider-certification-tool/internal/assets"
+	"github.com/redhat-openshift-ecosystem/provider-certification-tool/internal/opct/plugin"
+	"github.com/redhat-openshift-ecosystem/provider-certification-tool/pkg/proxy"
 	log "github.com/sirupsen/logrus"
+	"github.com/vmware-tanzu/sonobuoy/pkg/plugin/driver"
 	"github.com/vmware-tanzu/sonobuoy/pkg/plugin/loader"
 	"github.com/vmware-tanzu/sonobuoy/pkg/plugin/manifest"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 )
 
 // ProcessManifestTemplates processes go template variables in the manifest which map to variable in RunOptions
@@ -57,3 +65,152 @@ func loadPluginManifests(r *RunOptions) ([]*manifest.Manifest, error) {
 
 	return manifests, nil
 }
+
+// filterPluginManifests selects the plugins to run based in the flags
+// --include-plugin and --exclude-plugin, returning the selected manifests
+// and the name of the plugins intentionally skipped.
+// The artifacts collector is a required companion of the conformance plugins,
+// it is always kept unless waived by --skip-collector.
+func filterPluginManifests(r *RunOptions, manifests []*manifest.Manifest) ([]*manifest.Manifest, []string, error) {
+	available := map[string]struct{}{}
+	for _, m := range manifests {
+		available[m.SonobuoyConfig.PluginName] = struct{}{}
+	}
+	lookup := func(flag string, names []string) (map[string]struct{}, error) {
+		items := map[string]struct{}{}
+		for _, name := range names {
+			if _, ok := available[name]; !ok {
+				return nil, fmt.Errorf("unknown plugin %q in --%s, available plugins: %s", name, flag, strings.Join(sortedKeys(available), ", "))
+			}
+			items[name] = struct{}{}
+		}
+		return items, nil
+	}
+	include, err := lookup("include-plugin", r.includePlugins)
+	if err != nil {
+		return nil, nil, err
+	}
+	exclude, err := lookup("exclude-plugin", r.excludePlugins)
+	if err != nil {
+		return nil, nil, err
+	}
+	if _, ok := exclude[plugin.PluginNameArtifactsCollector]; ok && !r.skipCollector {
+		return nil, nil, fmt.Errorf("plugin %q is required to collect the artifacts used by the report, use --skip-collector to run without it", plugin.PluginNameArtifactsCollector)
+	}
+
+	selected := []*manifest.Manifest{}
+	skipped := []string{}
+	var collector *manifest.Manifest
+	for _, m := range manifests {
+		name := m.SonobuoyConfig.PluginName
+		_, isIncluded := include[name]
+		_, isExcluded := exclude[name]
+		isCollector := name == plugin.PluginNameArtifactsCollector
+		switch {
+		case isCollector && r.skipCollector:
+		case isCollector && !isExcluded:
+			collector = m
+			selected = append(selected, m)
+			continue
+		case isExcluded:
+		case len(include) > 0 && !isIncluded:
+		default:
+			selected = append(selected, m)
+			continue
+		}
+		skipped = append(skipped, name)
+	}
+	if len(selected) == 0 || (collector != nil && len(selected) == 1) {
+		return nil, nil, errors.New("no conformance plugins selected to run")
+	}
+	sort.Strings(skipped)
+
+	// The collector waits for the last plugin of the default workflow, it
+	// must be blocked by the last plugin selected to run instead.
+	if collector != nil && len(skipped) > 0 {
+		blockedBy := ""
+		for _, m := range selected {
+			if m != collector && m.SonobuoyConfig.PluginName > blockedBy {
+				blockedBy = m.SonobuoyConfig.PluginName
+			}
+		}
+		for i := range collector.Spec.Env {
+			if collector.Spec.Env[i].Name == "PLUGIN_BLOCKED_BY" {
+				collector.Spec.Env[i].Value = blockedBy
+			}
+		}
+	}
+
+	return selected, skipped, nil
+}
+
+func sortedKeys(m map[string]struct{}) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// spreadPluginPods sets the plugin pods to prefer nodes not running other
+// plugins, spreading the plugins across the dedicated nodes.
+func spreadPluginPods(manifests []*manifest.Manifest) {
+	for _, m := range manifests {
+		if m.PodSpec == nil {
+			m.PodSpec = &manifest.PodSpec{PodSpec: driver.DefaultPodSpec(m.SonobuoyConfig.Driver)}
+		}
+		if m.PodSpec.Affinity == nil {
+			m.PodSpec.Affinity = &v1.Affinity{}
+		}
+		if m.PodSpec.Affinity.PodAntiAffinity == nil {
+			m.PodSpec.Affinity.PodAntiAffinity = &v1.PodAntiAffinity{}
+		}
+		anti := m.PodSpec.Affinity.PodAntiAffinity
+		anti.PreferredDuringSchedulingIgnoredDuringExecution = append(anti.PreferredDuringSchedulingIgnoredDuringExecution, v1.WeightedPodAffinityTerm{
+			Weight: 100,
+			PodAffinityTerm: v1.PodAffinityTerm{
+				LabelSelector: &metav1.LabelSelector{
+					MatchLabels: map[string]string{"sonobuoy-component": "plugin"},
+				},
+				TopologyKey: "kubernetes.io/hostname",
+			},
+		})
+	}
+}
+
+// applyClusterProxy sets the proxy environment variables and the trusted CA
+// bundle to every container of the plugins, including the artifacts collector
+// running the must-gather. Variables already set by the templates are replaced,
+// so every container uses the same proxy.
+func applyClusterProxy(manifests []*manifest.Manifest, p *proxy.ClusterProxy) {
+	env := p.Env()
+	setContainer := func(c *v1.Container) {
+		for _, e := range env {
+			replaced := false
+			for i := range c.Env {
+				if c.Env[i].Name == e.Name {
+					c.Env[i] = e
+					replaced = true
+				}
+			}
+			if !replaced {
+				c.Env = append(c.Env, e)
+			}
+		}
+		c.VolumeMounts = append(c.VolumeMounts, proxy.TrustedCAVolumeMount())
+	}
+	for _, m := range manifests {
+		if m.PodSpec == nil {
+			m.PodSpec = &manifest.PodSpec{PodSpec: driver.DefaultPodSpec(m.SonobuoyConfig.Driver)}
+		}
+		m.PodSpec.Volumes = append(m.PodSpec.Volumes, proxy.TrustedCAVolume())
+		setContainer(&m.Spec.Container)
+		for i := range m.PodSpec.InitContainers {
+			setContainer(&m.PodSpec.InitContainers[i])
+		}
+		for i := range m.PodSpec.Containers {
+			setContainer(&m.PodSpec.Containers[i])
+		}
+	}
+}
@@ -0,0 +1,125 @@
+package run
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/vmware-tanzu/sonobuoy/pkg/plugin/manifest"
+	v1 "k8s.io/api/core/v1"
+
+	"github.com/redhat-openshift-ecosystem/provider-certification-tool/internal/opct/plugin"
+	"github.com/redhat-openshift-ecosystem/provider-certification-tool/pkg/proxy"
+)
+
+func newTestManifests() []*manifest.Manifest {
+	names := []string{
+		plugin.PluginNameArtifactsCollector,
+		plugin.PluginNameOpenShiftUpgrade,
+		plugin.PluginNameConformanceReplay,
+		plugin.PluginNameOpenShiftConformance,
+		plugin.PluginNameKubernetesConformance,
+	}
+	manifests := []*manifest.Manifest{}
+	for _, name := range names {
+		m := &manifest.Manifest{SonobuoyConfig: manifest.SonobuoyConfig{PluginName: name}}
+		if name == plugin.PluginNameArtifactsCollector {
+			m.Spec.Env = []v1.EnvVar{{Name: "PLUGIN_BLOCKED_BY", Value: plugin.PluginNameConformanceReplay}}
+		}
+		manifests = append(manifests, m)
+	}
+	return manifests
+}
+
+func Test_FilterPluginManifests(t *testing.T) {
+	tests := []struct {
+		name          string
+		options       *RunOptions
+		wantSelected  []string
+		wantSkipped   []string
+		wantBlockedBy string
+		wantErr       bool
+	}{{
+		name:          "include kube conformance keeps the collector",
+		options:       &RunOptions{includePlugins: []string{plugin.PluginNameKubernetesConformance}},
+		wantSelected:  []string{plugin.PluginNameArtifactsCollector, plugin.PluginNameKubernetesConformance},
+		wantSkipped:   []string{plugin.PluginNameOpenShiftUpgrade, plugin.PluginNameOpenShiftConformance, plugin.PluginNameConformanceReplay},
+		wantBlockedBy: plugin.PluginNameKubernetesConformance,
+	}, {
+		name:          "exclude replay",
+		options:       &RunOptions{excludePlugins: []string{plugin.PluginNameConformanceReplay}},
+		wantSelected:  []string{plugin.PluginNameArtifactsCollector, plugin.PluginNameOpenShiftUpgrade, plugin.PluginNameOpenShiftConformance, plugin.PluginNameKubernetesConformance},
+		wantSkipped:   []string{plugin.PluginNameConformanceReplay},
+		wantBlockedBy: plugin.PluginNameOpenShiftConformance,
+	}, {
+		name:         "waive the collector",
+		options:      &RunOptions{includePlugins: []string{plugin.PluginNameKubernetesConformance}, skipCollector: true},
+		wantSelected: []string{plugin.PluginNameKubernetesConformance},
+		wantSkipped:  []string{plugin.PluginNameArtifactsCollector, plugin.PluginNameOpenShiftUpgrade, plugin.PluginNameConformanceReplay, plugin.PluginNameOpenShiftConformance},
+	}, {
+		name:    "exclude the collector without waiver",
+		options: &RunOptions{excludePlugins: []string{plugin.PluginNameArtifactsCollector}},
+		wantErr: true,
+	}, {
+		name:    "unknown plugin",
+		options: &RunOptions{includePlugins: []string{"10-kube-conformance"}},
+		wantErr: true,
+	}}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			selected, skipped, err := filterPluginManifests(test.options, newTestManifests())
+			if test.wantErr {
+				assert.Error(t, err)
+				return
+			}
+			assert.NoError(t, err)
+			names := []string{}
+			for _, m := range selected {
+				names = append(names, m.SonobuoyConfig.PluginName)
+				if m.SonobuoyConfig.PluginName == plugin.PluginNameArtifactsCollector {
+					assert.Equal(t, test.wantBlockedBy, m.Spec.Env[0].Value)
+				}
+			}
+			assert.Equal(t, test.wantSelected, names)
+			assert.ElementsMatch(t, test.wantSkipped, skipped)
+		})
+	}
+}
+
+func Test_SpreadPluginPods(t *testing.T) {
+	manifests := newTestManifests()
+	manifests[0].SonobuoyConfig.Driver = "Job"
+	manifests[1].PodSpec = &manifest.PodSpec{PodSpec: v1.PodSpec{ServiceAccountName: "sonobuoy-serviceaccount"}}
+
+	spreadPluginPods(manifests)
+	for _, m := range manifests {
+		if assert.NotNil(t, m.PodSpec, m.SonobuoyConfig.PluginName) {
+			terms := m.PodSpec.Affinity.PodAntiAffinity.PreferredDuringSchedulingIgnoredDuringExecution
+			assert.Len(t, terms, 1)
+			assert.Equal(t, "kubernetes.io/hostname", terms[0].PodAffinityTerm.TopologyKey)
+		}
+	}
+	assert.Equal(t, "sonobuoy-serviceaccount", manifests[1].PodSpec.ServiceAccountName)
+}
+
+func Test_ApplyClusterProxy(t *testing.T) {
+	manifests := newTestManifests()
+	manifests[0].PodSpec = &manifest.PodSpec{PodSpec: v1.PodSpec{
+		InitContainers: []v1.Container{{Name: "login"}},
+		Containers:     []v1.Container{{Name: "tests", Env: []v1.EnvVar{{Name: "NO_PROXY", Value: "example.com"}}}},
+	}}
+	p := &proxy.ClusterProxy{HTTPSProxy: "http://proxy.example.com:3128", NoProxy: ".cluster.local"}
+
+	applyClusterProxy(manifests, p)
+
+	for _, m := range manifests {
+		assert.Contains(t, m.Spec.Env, v1.EnvVar{Name: "HTTPS_PROXY", Value: p.HTTPSProxy}, m.SonobuoyConfig.PluginName)
+		assert.Contains(t, m.Spec.VolumeMounts, proxy.TrustedCAVolumeMount(), m.SonobuoyConfig.PluginName)
+		assert.Contains(t, m.PodSpec.Volumes, proxy.TrustedCAVolume(), m.SonobuoyConfig.PluginName)
+	}
+	for _, c := range append(manifests[0].PodSpec.InitContainers, manifests[0].PodSpec.Containers...) {
+		assert.Contains(t, c.Env, v1.EnvVar{Name: "NO_PROXY", Value: p.NoProxyList()}, c.Name)
+		assert.Len(t, c.Env, len(p.Env()), "variables set by the templates must be replaced")
+		assert.Contains(t, c.VolumeMounts, proxy.TrustedCAVolumeMount(), c.Name)
+	}
+}
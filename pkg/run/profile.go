@@ -0,0 +1,294 @@
+package run
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+
+	"github.com/spf13/pflag"
+	"sigs.k8s.io/yaml"
+
+	"github.com/redhat-openshift-ecosystem/provider-certification-tool/pkg/rbac"
+)
+
+const (
+	RunProfileAPIVersion = "opct.openshift.io/v1alpha1"
+	RunProfileKind       = "RunProfile"
+)
+
+// RunProfile is the declarative, versioned, representation of the options
+// used to launch the validation environment. It is loaded from the file set
+// by 'opct run --config'.
+type RunProfile struct {
+	APIVersion string         `json:"apiVersion"`
+	Kind       string         `json:"kind"`
+	Spec       RunProfileSpec `json:"spec"`
+}
+
+// RunProfileSpec holds the run options. Empty values are ignored when merging
+// the profile with the command line flags.
+type RunProfileSpec struct {
+	Mode            string            `json:"mode,omitempty"`
+	UpgradeImage    string            `json:"upgradeImage,omitempty"`
+	ImageRepository string            `json:"imageRepository,omitempty"`
+	Timeout         int               `json:"timeout,omitempty"`
+	Dedicated       *bool             `json:"dedicated,omitempty"`
+	DedicatedNodes  int               `json:"dedicatedNodes,omitempty"`
+	RBACProfile     string            `json:"rbacProfile,omitempty"`
+	RBACRules       string            `json:"rbacRules,omitempty"`
+	PinImages       *bool             `json:"pinImages,omitempty"`
+	ImageLockFile   string            `json:"imageLockFile,omitempty"`
+	DevCount        int               `json:"devCount,omitempty"`
+	Images          *RunProfileImages `json:"images,omitempty"`
+	Plugins         []string          `json:"plugins,omitempty"`
+	IncludePlugins  []string          `json:"includePlugins,omitempty"`
+	ExcludePlugins  []string          `json:"excludePlugins,omitempty"`
+	SkipCollector   bool              `json:"skipCollector,omitempty"`
+	TestsFile       string            `json:"testsFile,omitempty"`
+	Proxy           *RunProfileProxy  `json:"proxy,omitempty"`
+	Tags            map[string]string `json:"tags,omitempty"`
+
+	// PluginSettings are the per-plugin overrides, keyed by plugin name.
+	PluginSettings map[string]*PluginSettings `json:"pluginSettings,omitempty"`
+}
+
+// RunProfileImages holds the image overrides used by the aggregator and plugins.
+type RunProfileImages struct {
+	Sonobuoy             string `json:"sonobuoy,omitempty"`
+	Plugins              string `json:"plugins,omitempty"`
+	Collector            string `json:"collector,omitempty"`
+	MustGatherMonitoring string `json:"mustGatherMonitoring,omitempty"`
+	OpenshiftTests       string `json:"openshiftTests,omitempty"`
+}
+
+// RunProfileProxy holds the proxy set to the plugins instead of the cluster-wide proxy.
+type RunProfileProxy struct {
+	HTTPProxy  string `json:"httpProxy,omitempty"`
+	HTTPSProxy string `json:"httpsProxy,omitempty"`
+	NoProxy    string `json:"noProxy,omitempty"`
+}
+
+// LoadRunProfile reads and validates the run profile from a file.
+func LoadRunProfile(path string) (*RunProfile, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("unable to read run profile %q: %w", path, err)
+	}
+	p := &RunProfile{}
+	if err := yaml.UnmarshalStrict(data, p); err != nil {
+		return nil, fmt.Errorf("unable to parse run profile %q: %w", path, err)
+	}
+	if err := p.Validate(); err != nil {
+		return nil, fmt.Errorf("invalid run profile %q: %w", path, err)
+	}
+	return p, nil
+}
+
+// Validate checks the profile version and the values of the spec.
+func (p *RunProfile) Validate() error {
+	if p.APIVersion != RunProfileAPIVersion {
+		return fmt.Errorf("unsupported apiVersion %q, want %q", p.APIVersion, RunProfileAPIVersion)
+	}
+	if p.Kind != RunProfileKind {
+		return fmt.Errorf("unsupported kind %q, want %q", p.Kind, RunProfileKind)
+	}
+	switch p.Spec.Mode {
+	case "", "regular", "upgrade":
+	default:
+		return fmt.Errorf("invalid mode %q, allowed values: regular, upgrade", p.Spec.Mode)
+	}
+	if p.Spec.Timeout < 0 {
+		return fmt.Errorf("timeout must be greater than zero, got %d", p.Spec.Timeout)
+	}
+	if p.Spec.RBACProfile != "" {
+		if err := rbac.ValidateProfile(p.Spec.RBACProfile); err != nil {
+			return err
+		}
+	}
+	if p.Spec.DedicatedNodes < 0 {
+		return fmt.Errorf("dedicatedNodes must be greater than zero, got %d", p.Spec.DedicatedNodes)
+	}
+	if err := validateTags(p.Spec.Tags); err != nil {
+		return err
+	}
+	for name, s := range p.Spec.PluginSettings {
+		if s == nil {
+			return fmt.Errorf("empty plugin settings of plugin %q", name)
+		}
+		if s.Timeout < 0 {
+			return fmt.Errorf("invalid timeout %d of plugin %q, must be greater than zero", s.Timeout, name)
+		}
+	}
+	if p.Spec.DevCount < 0 {
+		return fmt.Errorf("devCount must be greater than zero, got %d", p.Spec.DevCount)
+	}
+	return nil
+}
+
+// Apply merges the profile into the run options. Values explicitly set by
+// command line flags take precedence over the profile.
+func (p *RunProfile) Apply(r *RunOptions, flags *pflag.FlagSet) {
+	isSet := func(names ...string) bool {
+		for _, name := range names {
+			if flags.Changed(name) {
+				return true
+			}
+		}
+		return false
+	}
+	s := p.Spec
+	if s.Mode != "" && !isSet("mode") {
+		r.mode = s.Mode
+	}
+	if s.UpgradeImage != "" && !isSet("upgrade-to-image") {
+		r.upgradeImage = s.UpgradeImage
+	}
+	if s.ImageRepository != "" && !isSet("image-repository") {
+		r.imageRepository = s.ImageRepository
+	}
+	if s.Timeout != 0 && !isSet("timeout") {
+		r.timeout = s.Timeout
+	}
+	if s.Dedicated != nil && !isSet("dedicated") {
+		r.dedicated = *s.Dedicated
+	}
+	if s.PinImages != nil && !isSet("pin-images") {
+		r.pinImages = *s.PinImages
+	}
+	if s.ImageLockFile != "" && !isSet("image-lock-file") {
+		r.imageLockFile = s.ImageLockFile
+	}
+	if s.RBACProfile != "" && !isSet("rbac-profile") {
+		r.rbacProfile = s.RBACProfile
+	}
+	if s.RBACRules != "" && !isSet("rbac-rules") {
+		r.rbacRules = s.RBACRules
+	}
+	if s.DedicatedNodes != 0 && !isSet("dedicated-nodes") {
+		r.dedicatedNodes = s.DedicatedNodes
+	}
+	if s.DevCount != 0 && !isSet("devel-limit-tests", "dev-count") {
+		r.devCount = strconv.Itoa(s.DevCount)
+	}
+	if len(s.Plugins) > 0 && !isSet("plugin") {
+		*r.plugins = append([]string{}, s.Plugins...)
+	}
+	if len(s.IncludePlugins) > 0 && !isSet("include-plugin") {
+		r.includePlugins = append([]string{}, s.IncludePlugins...)
+	}
+	if len(s.ExcludePlugins) > 0 && !isSet("exclude-plugin") {
+		r.excludePlugins = append([]string{}, s.ExcludePlugins...)
+	}
+	if s.SkipCollector && !isSet("skip-collector") {
+		r.skipCollector = true
+	}
+	if s.TestsFile != "" && !isSet("tests-file") {
+		r.testsFile = s.TestsFile
+	}
+	if s.Proxy != nil {
+		if s.Proxy.HTTPProxy != "" && !isSet("http-proxy") {
+			r.httpProxy = s.Proxy.HTTPProxy
+		}
+		if s.Proxy.HTTPSProxy != "" && !isSet("https-proxy") {
+			r.httpsProxy = s.Proxy.HTTPSProxy
+		}
+		if s.Proxy.NoProxy != "" && !isSet("no-proxy") {
+			r.noProxy = s.Proxy.NoProxy
+		}
+	}
+	if len(s.Tags) > 0 {
+		// Tags are merged, the flags override the profile by key.
+		tags := make(map[string]string, len(s.Tags)+len(r.tags))
+		for k, v := range s.Tags {
+			tags[k] = v
+		}
+		for k, v := range r.tags {
+			tags[k] = v
+		}
+		r.tags = tags
+	}
+	if len(s.PluginSettings) > 0 {
+		// Settings set by --plugin-* flags are merged in the pre-run.
+		r.pluginSettings = copyPluginSettings(s.PluginSettings)
+	}
+	if s.Images == nil {
+		return
+	}
+	if s.Images.Sonobuoy != "" && !isSet("sonobuoy-image") {
+		r.sonobuoyImage = s.Images.Sonobuoy
+	}
+	if s.Images.Plugins != "" && !isSet("plugins-image") {
+		r.PluginsImage = s.Images.Plugins
+	}
+	if s.Images.Collector != "" && !isSet("collector-image") {
+		r.CollectorImage = s.Images.Collector
+	}
+	if s.Images.MustGatherMonitoring != "" && !isSet("must-gather-monitoring-image") {
+		r.MustGatherMonitoringImage = s.Images.MustGatherMonitoring
+	}
+	if s.Images.OpenshiftTests != "" && !isSet("openshift-tests-image") {
+		r.OpenshiftTestsImage = s.Images.OpenshiftTests
+	}
+}
+
+// newRunProfileFromOptions creates the effective profile from the run options,
+// used to record what was requested to the validation environment.
+func newRunProfileFromOptions(r *RunOptions) *RunProfile {
+	devCount, _ := strconv.Atoi(r.devCount)
+	dedicated := r.dedicated
+	pinImages := r.pinImages
+	p := &RunProfile{
+		APIVersion: RunProfileAPIVersion,
+		Kind:       RunProfileKind,
+		Spec: RunProfileSpec{
+			Mode:            r.mode,
+			UpgradeImage:    r.upgradeImage,
+			ImageRepository: r.imageRepository,
+			Timeout:         r.timeout,
+			Dedicated:       &dedicated,
+			DedicatedNodes:  r.dedicatedNodes,
+			RBACProfile:     r.rbacProfile,
+			RBACRules:       r.rbacRules,
+			PinImages:       &pinImages,
+			ImageLockFile:   r.imageLockFile,
+			DevCount:        devCount,
+			Images: &RunProfileImages{
+				Sonobuoy:             r.sonobuoyImage,
+				Plugins:              r.PluginsImage,
+				Collector:            r.CollectorImage,
+				MustGatherMonitoring: r.MustGatherMonitoringImage,
+				OpenshiftTests:       r.OpenshiftTestsImage,
+			},
+		},
+	}
+	if len(r.tags) > 0 {
+		p.Spec.Tags = r.tags
+	}
+	if len(r.pluginSettings) > 0 {
+		p.Spec.PluginSettings = r.pluginSettings
+	}
+	if r.plugins != nil && len(*r.plugins) > 0 {
+		p.Spec.Plugins = append([]string{}, *r.plugins...)
+	}
+	if len(r.includePlugins) > 0 {
+		p.Spec.IncludePlugins = append([]string{}, r.includePlugins...)
+	}
+	if len(r.excludePlugins) > 0 {
+		p.Spec.ExcludePlugins = append([]string{}, r.excludePlugins...)
+	}
+	p.Spec.SkipCollector = r.skipCollector
+	p.Spec.TestsFile = r.testsFile
+	if r.httpProxy != "" || r.httpsProxy != "" || r.noProxy != "" {
+		p.Spec.Proxy = &RunProfileProxy{HTTPProxy: r.httpProxy, HTTPSProxy: r.httpsProxy, NoProxy: r.noProxy}
+	}
+	return p
+}
+
+// String serializes the profile to YAML.
+func (p *RunProfile) String() string {
+	data, err := yaml.Marshal(p)
+	if err != nil {
+		return ""
+	}
+	return string(data)
+}
@@ -0,0 +1,140 @@
+package run
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/spf13/pflag"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_LoadRunProfile(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+		wantErr bool
+	}{{
+		name: "valid profile",
+		content: `apiVersion: opct.openshift.io/v1alpha1
+kind: RunProfile
+spec:
+  mode: upgrade
+  upgradeImage: quay.io/openshift-release-dev/ocp-release@sha256:abc
+  timeout: 3600
+`,
+	}, {
+		name: "unsupported version",
+		content: `apiVersion: opct.openshift.io/v1
+kind: RunProfile
+`,
+		wantErr: true,
+	}, {
+		name: "invalid mode",
+		content: `apiVersion: opct.openshift.io/v1alpha1
+kind: RunProfile
+spec:
+  mode: fast
+`,
+		wantErr: true,
+	}, {
+		name: "unknown field",
+		content: `apiVersion: opct.openshift.io/v1alpha1
+kind: RunProfile
+spec:
+  timeoutSeconds: 10
+`,
+		wantErr: true,
+	}}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "run.yaml")
+			assert.NoError(t, os.WriteFile(path, []byte(test.content), 0644))
+			_, err := LoadRunProfile(path)
+			if test.wantErr {
+				assert.Error(t, err)
+				return
+			}
+			assert.NoError(t, err)
+		})
+	}
+}
+
+func Test_RunProfileApply(t *testing.T) {
+	dedicated := true
+	pinImages := true
+	p := &RunProfile{
+		APIVersion: RunProfileAPIVersion,
+		Kind:       RunProfileKind,
+		Spec: RunProfileSpec{
+			Mode:      "upgrade",
+			Timeout:   3600,
+			Dedicated: &dedicated,
+			PinImages: &pinImages,
+			Images:    &RunProfileImages{Plugins: "quay.io/opct/plugins:profile"},
+			Tags:      map[string]string{"customer": "acme", "hw": "gen2"},
+		},
+	}
+
+	o := &RunOptions{plugins: &[]string{}}
+	flags := pflag.NewFlagSet("run", pflag.ContinueOnError)
+	flags.StringVar(&o.mode, "mode", defaultRunMode, "")
+	flags.IntVar(&o.timeout, "timeout", 0, "")
+	flags.BoolVar(&o.dedicated, "dedicated", false, "")
+	flags.BoolVar(&o.pinImages, "pin-images", false, "")
+	flags.StringVar(&o.PluginsImage, "plugins-image", "", "")
+	flags.StringToStringVar(&o.tags, "tag", map[string]string{}, "")
+	assert.NoError(t, flags.Parse([]string{"--timeout=60", "--tag=hw=gen3"}))
+
+	p.Apply(o, flags)
+
+	assert.Equal(t, "upgrade", o.mode)
+	assert.Equal(t, 60, o.timeout, "flag must take precedence over the profile")
+	assert.True(t, o.dedicated)
+	assert.True(t, o.pinImages)
+	assert.Equal(t, "quay.io/opct/plugins:profile", o.PluginsImage)
+	assert.Equal(t, map[string]string{"customer": "acme", "hw": "gen3"}, o.tags, "tags must be merged, flags take precedence")
+}
+
+func Test_RunProfileRoundTrip(t *testing.T) {
+	want := &RunOptions{
+		mode:            "upgrade",
+		upgradeImage:    "quay.io/openshift-release-dev/ocp-release@sha256:0000",
+		imageRepository: "mirror.local/opct",
+		timeout:         3600,
+		dedicated:       true,
+		dedicatedNodes:  2,
+		rbacProfile:     "scoped",
+		rbacRules:       "rules.yaml",
+		pinImages:       true,
+		imageLockFile:   "images-lock.yaml",
+		devCount:        "10",
+		plugins:         &[]string{},
+		includePlugins:  []string{"10-openshift-kube-conformance"},
+		excludePlugins:  []string{"05-openshift-cluster-upgrade"},
+		skipCollector:   true,
+		testsFile:       "failures.txt",
+		httpsProxy:      "http://proxy.example.com:3128",
+		noProxy:         ".example.com",
+		tags:            map[string]string{"customer": "acme"},
+		pluginSettings: map[string]*PluginSettings{
+			"10-openshift-kube-conformance": {Timeout: 3600, Env: map[string]string{"MY_VAR": "value"}},
+		},
+		sonobuoyImage:             "mirror.local/opct/sonobuoy:v0",
+		PluginsImage:              "mirror.local/opct/plugin-openshift-tests:v0",
+		CollectorImage:            "mirror.local/opct/plugin-artifacts-collector:v0",
+		MustGatherMonitoringImage: "mirror.local/opct/must-gather-monitoring:v0",
+		OpenshiftTestsImage:       "mirror.local/opct/openshift-tests:v0",
+	}
+
+	// The profile is recorded as YAML, and loaded by 'opct run --config'.
+	file := filepath.Join(t.TempDir(), "profile.yaml")
+	assert.NoError(t, os.WriteFile(file, []byte(newRunProfileFromOptions(want).String()), 0644))
+	p, err := LoadRunProfile(file)
+	assert.NoError(t, err)
+
+	got := &RunOptions{plugins: &[]string{}}
+	p.Apply(got, pflag.NewFlagSet("run", pflag.ContinueOnError))
+	assert.Equal(t, want, got)
+}
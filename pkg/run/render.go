@@ -0,0 +1,101 @@
+package run
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+
+	"github.com/pkg/errors"
+	sonobuoyclient "github.com/vmware-tanzu/sonobuoy/pkg/client"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/kubernetes/fake"
+	"k8s.io/client-go/kubernetes/scheme"
+	k8stesting "k8s.io/client-go/testing"
+	"sigs.k8s.io/yaml"
+)
+
+// renderRecorder holds the objects which would be created by 'opct run'.
+// It is used by the render mode (--render) to review the manifests without
+// changing the cluster.
+type renderRecorder struct {
+	objects  []runtime.Object
+	manifest []byte
+}
+
+// newRenderClients creates the clients used to render the objects. Every
+// create or update request sent to the clients is recorded instead of being
+// sent to the cluster.
+func newRenderClients() (kubernetes.Interface, sonobuoyclient.Interface, *renderRecorder) {
+	rec := &renderRecorder{}
+
+	kclient := fake.NewSimpleClientset()
+	kclient.PrependReactor("*", "*", func(action k8stesting.Action) (bool, runtime.Object, error) {
+		var obj runtime.Object
+		switch a := action.(type) {
+		case k8stesting.CreateAction:
+			obj = a.GetObject()
+		case k8stesting.UpdateAction:
+			obj = a.GetObject()
+		default:
+			return false, nil, nil
+		}
+		rec.record(obj)
+		return true, obj, nil
+	})
+
+	return kclient, &renderSonobuoyClient{recorder: rec}, rec
+}
+
+// record saves the object setting the TypeMeta, when missing, from the client scheme.
+func (rec *renderRecorder) record(obj runtime.Object) {
+	obj = obj.DeepCopyObject()
+	if obj.GetObjectKind().GroupVersionKind().Empty() {
+		gvks, _, err := scheme.Scheme.ObjectKinds(obj)
+		if err == nil && len(gvks) > 0 {
+			obj.GetObjectKind().SetGroupVersionKind(gvks[0])
+		}
+	}
+	rec.objects = append(rec.objects, obj)
+}
+
+// Write prints the recorded objects, followed by the Sonobuoy manifests, as
+// a multi-document YAML.
+func (rec *renderRecorder) Write(w io.Writer) error {
+	for _, obj := range rec.objects {
+		data, err := yaml.Marshal(obj)
+		if err != nil {
+			return errors.Wrap(err, "unable to render object")
+		}
+		if _, err := fmt.Fprintf(w, "---\n%s", data); err != nil {
+			return err
+		}
+	}
+	// Sonobuoy manifests are terminated by a document separator.
+	if len(rec.manifest) > 0 {
+		if _, err := fmt.Fprintf(w, "---\n%s", bytes.TrimSuffix(rec.manifest, []byte("---\n"))); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// renderSonobuoyClient generates the Sonobuoy manifests of a run without
+// applying it to the cluster.
+type renderSonobuoyClient struct {
+	sonobuoyclient.Interface
+	recorder *renderRecorder
+}
+
+// Run records the manifests which would be applied by Sonobuoy.
+func (c *renderSonobuoyClient) Run(cfg *sonobuoyclient.RunConfig) error {
+	if cfg == nil {
+		return errors.New("nil RunConfig provided")
+	}
+	m, err := (&sonobuoyclient.SonobuoyClient{}).GenerateManifest(&cfg.GenConfig)
+	if err != nil {
+		return errors.Wrap(err, "unable to generate the Sonobuoy manifests")
+	}
+	c.recorder.manifest = m
+	return nil
+}
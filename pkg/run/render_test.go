@@ -0,0 +1,64 @@
+package run
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/redhat-openshift-ecosystem/provider-certification-tool/pkg"
+	"github.com/redhat-openshift-ecosystem/provider-certification-tool/pkg/inventory"
+	"github.com/redhat-openshift-ecosystem/provider-certification-tool/pkg/proxy"
+)
+
+func Test_Render(t *testing.T) {
+	o := newRunOptions()
+	o.plugins = &[]string{"../../test/testdata/plugins/sample-v0-ok.yaml"}
+	o.mode = defaultRunMode
+	o.dedicated = true
+	o.sonobuoyImage = pkg.GetSonobuoyImage()
+
+	kclient, sclient, recorder := newRenderClients()
+	assert.NoError(t, o.PreRunSetup(kclient))
+	assert.NoError(t, o.Run(kclient, sclient))
+
+	kinds := []string{}
+	for _, obj := range recorder.objects {
+		kinds = append(kinds, obj.GetObjectKind().GroupVersionKind().Kind)
+	}
+	assert.Equal(t, []string{"Namespace", "ServiceAccount", "ClusterRole", "ClusterRoleBinding", "ConfigMap", "ConfigMap", "ConfigMap"}, kinds)
+
+	// The objects created by the run are recorded in the inventory.
+	assert.True(t, o.inventory.Has(inventory.KindNamespace, "", pkg.GetNamespace()))
+	assert.True(t, o.inventory.Has(inventory.KindClusterRoleBinding, "", pkg.GetPrivilegedClusterRoleBinding()))
+	assert.True(t, o.inventory.Has(inventory.KindConfigMap, pkg.GetNamespace(), pkg.PluginsVarsConfigMapName))
+	assert.True(t, o.inventory.Has(inventory.KindConfigMap, pkg.GetNamespace(), inventory.ConfigMapName))
+	assert.False(t, o.inventory.Has(inventory.KindMachineConfigPool, "", pkg.MachineConfigPoolName))
+
+	var out bytes.Buffer
+	assert.NoError(t, recorder.Write(&out))
+	assert.Contains(t, out.String(), "name: "+pkg.PluginsVarsConfigMapName)
+	assert.Contains(t, out.String(), "name: "+inventory.ConfigMapName)
+	assert.Contains(t, out.String(), "plugin-name: 99-openshift-artifacts-collector")
+}
+
+func Test_RenderClusterProxy(t *testing.T) {
+	o := newRunOptions()
+	o.plugins = &[]string{"../../test/testdata/plugins/sample-v0-ok.yaml"}
+	o.mode = defaultRunMode
+	o.sonobuoyImage = pkg.GetSonobuoyImage()
+	o.httpsProxy = "http://proxy.example.com:3128"
+	o.clusterProxy = o.proxyFromFlags()
+
+	kclient, sclient, recorder := newRenderClients()
+	assert.NoError(t, o.PreRunSetup(kclient))
+	assert.NoError(t, o.Run(kclient, sclient))
+
+	// The proxy set by the flags is rendered without reaching the cluster.
+	var out bytes.Buffer
+	assert.NoError(t, recorder.Write(&out))
+	assert.Contains(t, out.String(), "name: "+proxy.TrustedCAConfigMapName)
+	assert.Contains(t, out.String(), "value: "+o.httpsProxy)
+	assert.Contains(t, out.String(), "value: "+o.clusterProxy.NoProxyList())
+	assert.Contains(t, out.String(), "cluster-proxy: \"true\"")
+}
@@ -4,13 +4,14 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
+	"io/fs"
 	"os"
+	"sort"
+	"strconv"
+	"strings"
 	"time"
 
-	configv1 "github.com/openshift/api/config/v1"
-	operatorv1 "github.com/openshift/api/operator/v1"
 	coclient "github.com/openshift/client-go/config/clientset/versioned"
-	irclient "github.com/openshift/client-go/imageregistry/clientset/versioned"
 	mcfgclientset "github.com/openshift/client-go/machineconfiguration/clientset/versioned"
 	"github.com/pkg/errors"
 	"github.com/redhat-openshift-ecosystem/provider-certification-tool/pkg/version"
@@ -23,12 +24,22 @@ import (
 	"github.com/vmware-tanzu/sonobuoy/pkg/plugin/manifest"
 	v1 "k8s.io/api/core/v1"
 
+	efs "github.com/redhat-openshift-ecosystem/provider-certification-tool/internal/assets"
+	"github.com/redhat-openshift-ecosystem/provider-certification-tool/internal/opct/archive"
+	"github.com/redhat-openshift-ecosystem/provider-certification-tool/internal/opct/plugin"
 	"github.com/redhat-openshift-ecosystem/provider-certification-tool/pkg"
 	"github.com/redhat-openshift-ecosystem/provider-certification-tool/pkg/client"
+	"github.com/redhat-openshift-ecosystem/provider-certification-tool/pkg/images"
+	"github.com/redhat-openshift-ecosystem/provider-certification-tool/pkg/inventory"
+	"github.com/redhat-openshift-ecosystem/provider-certification-tool/pkg/notify"
+	"github.com/redhat-openshift-ecosystem/provider-certification-tool/pkg/preflight"
+	"github.com/redhat-openshift-ecosystem/provider-certification-tool/pkg/proxy"
+	"github.com/redhat-openshift-ecosystem/provider-certification-tool/pkg/rbac"
+	"github.com/redhat-openshift-ecosystem/provider-certification-tool/pkg/runid"
 	"github.com/redhat-openshift-ecosystem/provider-certification-tool/pkg/status"
+	"github.com/redhat-openshift-ecosystem/provider-certification-tool/pkg/upgrade"
 	"github.com/redhat-openshift-ecosystem/provider-certification-tool/pkg/wait"
 	rbacv1 "k8s.io/api/rbac/v1"
-	kerrors "k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime/schema"
 	"k8s.io/client-go/kubernetes"
@@ -37,6 +48,15 @@ import (
 type RunOptions struct {
 	plugins *[]string
 
+	// includePlugins and excludePlugins filter the default plugins by name.
+	includePlugins []string
+	excludePlugins []string
+	skipCollector  bool
+
+	// testsFile is the list of e2e tests to run by the replay plugin.
+	testsFile string
+	tests     []string
+
 	sonobuoyImage   string
 	imageRepository string
 
@@ -48,17 +68,78 @@ type RunOptions struct {
 	MustGatherMonitoringImage string
 	OpenshiftTestsImage       string
 
-	timeout       int
-	watch         bool
-	mode          string
-	upgradeImage  string
+	timeout      int
+	watch        bool
+	mode         string
+	upgradeImage string
+
+	// configFile is the path of the run profile (--config).
+	configFile string
+
+	// render prints the objects which would be created, without changing the cluster.
+	render bool
 
 	// devel flags
 	devCount      string
 	devSkipChecks bool
 
+	// skipChecks is the list of preflight check IDs to skip.
+	skipChecks []string
+
+	// createMCP creates the MachineConfigPool, paused once the nodes are updated, in upgrade mode.
+	createMCP bool
+
 	// Dedicated node
 	dedicated bool
+
+	// pinImages resolves the images to digests before scheduling.
+	pinImages bool
+
+	// imageLockFile is the file with the image digests, used to pin the
+	// images without reaching the registry.
+	imageLockFile string
+
+	// rbacProfile is the RBAC profile granted to the validation environment.
+	rbacProfile string
+
+	// rbacRules is the ClusterRole file with the rules used by the plugins,
+	// granted by the scoped profile in addition to the default plugin rules.
+	rbacRules string
+
+	// scopedRules are the rules granted by the scoped profile.
+	scopedRules []rbacv1.PolicyRule
+
+	// dedicatedNodes is the number of dedicated nodes, plugin pods are
+	// spread across the nodes when greater than one.
+	dedicatedNodes int
+
+	// tags are the user-defined key/values stored in the plugins-config
+	// ConfigMap, used to identify the run in the results.
+	tags map[string]string
+
+	// pluginSettings are the per-plugin timeout, resources and env overrides,
+	// merged from the run profile and the pluginFlags.
+	pluginSettings map[string]*PluginSettings
+	pluginFlags    pluginSettingsFlags
+
+	// clusterProxy is the cluster-wide proxy injected into the plugins,
+	// set by the proxy flags or detected from the cluster.
+	clusterProxy *proxy.ClusterProxy
+	httpProxy    string
+	httpsProxy   string
+	noProxy      string
+
+	// runID is the unique ID of the run, generated when the environment is created.
+	runID string
+
+	// selectedPlugins are the plugins scheduled by the run.
+	selectedPlugins []string
+
+	// notifier posts the lifecycle events of the run to the webhook.
+	notifier *notify.Notifier
+
+	// inventory records the objects created by the run, removed by destroy.
+	inventory *inventory.Inventory
 }
 
 const (
@@ -66,6 +147,7 @@ const (
 	defaultRunMode           = "regular"
 	defaultUpgradeImage      = ""
 	defaultDedicatedFlag     = true
+	defaultDedicatedNodes    = 1
 	defaultRunWatchFlag      = false
 )
 
@@ -86,6 +168,7 @@ func NewCmdRun() *cobra.Command {
 	var err error
 	var kclient kubernetes.Interface
 	var sclient sonobuoyclient.Interface
+	var recorder *renderRecorder
 	o := newRunOptions()
 
 	cmd := &cobra.Command{
@@ -93,6 +176,109 @@ func NewCmdRun() *cobra.Command {
 		Short: "Run the suite of tests for provider validation",
 		Long:  `Launches the provider validation environment inside of an already running OpenShift cluster`,
 		PreRunE: func(cmd *cobra.Command, args []string) error {
+			// Run profile setup, flags set by the user take precedence.
+			if o.configFile != "" {
+				profile, err := LoadRunProfile(o.configFile)
+				if err != nil {
+					log.WithError(err).Error("pre-run failed when loading the run profile")
+					return err
+				}
+				profile.Apply(o, cmd.Flags())
+				log.Infof("Run profile loaded from %s", o.configFile)
+			}
+			if o.mode == "upgrade" && o.upgradeImage == "" {
+				err := errors.New("--upgrade-to-image must be set when --mode=upgrade")
+				log.WithError(err).Error("pre-run failed when validating the options")
+				return err
+			}
+			if err := rbac.ValidateProfile(o.rbacProfile); err != nil {
+				log.WithError(err).Error("pre-run failed when validating the options")
+				return err
+			}
+			if o.testsFile != "" {
+				if o.plugins != nil && len(*o.plugins) > 0 {
+					err := errors.New("--tests-file cannot be used with --plugin")
+					log.WithError(err).Error("pre-run failed when validating the options")
+					return err
+				}
+				if o.tests, err = loadTestsFile(o.testsFile); err != nil {
+					log.WithError(err).Error("pre-run failed when validating the options")
+					return err
+				}
+				if len(o.includePlugins) == 0 {
+					o.includePlugins = []string{plugin.PluginNameConformanceReplay}
+				}
+				log.Infof("Loaded %d tests from %s", len(o.tests), o.testsFile)
+			}
+			if o.rbacProfile == rbac.ProfileScoped {
+				_, selected, err := pluginNames(o)
+				if err != nil {
+					log.WithError(err).Error("pre-run failed when loading the plugins")
+					return err
+				}
+				if o.scopedRules, err = loadScopedRules(efs.GetData(), o.rbacRules, selected); err != nil {
+					log.WithError(err).Error("pre-run failed when validating the options")
+					return err
+				}
+			}
+			if o.pluginSettings, err = mergePluginSettingsFlags(o.pluginSettings, &o.pluginFlags); err != nil {
+				log.WithError(err).Error("pre-run failed when validating the options")
+				return err
+			}
+			if len(o.pluginSettings) > 0 {
+				available, selected, err := pluginNames(o)
+				if err != nil {
+					log.WithError(err).Error("pre-run failed when loading the plugins")
+					return err
+				}
+				if err := validatePluginSettings(o.pluginSettings, available); err != nil {
+					log.WithError(err).Error("pre-run failed when validating the options")
+					return err
+				}
+				if _, err := pluginDeadlines(selected, o.pluginSettings); err != nil {
+					log.WithError(err).Error("pre-run failed when validating the options")
+					return err
+				}
+			}
+			if err := validateTags(o.tags); err != nil {
+				log.WithError(err).Error("pre-run failed when validating the options")
+				return err
+			}
+			if o.dedicatedNodes < 1 {
+				err := fmt.Errorf("invalid --dedicated-nodes %d, at least one node must be set", o.dedicatedNodes)
+				log.WithError(err).Error("pre-run failed when validating the options")
+				return err
+			}
+
+			if o.notifier, err = notify.NewNotifierFromFlags(cmd.Flags()); err != nil {
+				log.WithError(err).Error("pre-run failed when validating the options")
+				return err
+			}
+
+			o.runID = runid.New()
+			runid.SetLogger(o.runID)
+
+			// The proxy set by the flags takes precedence over the cluster proxy.
+			o.clusterProxy = o.proxyFromFlags()
+
+			// Render mode does not reach the cluster, the objects created by
+			// the setup are recorded to be printed when running.
+			if o.render {
+				log.SetOutput(os.Stderr)
+				log.Infof("Run ID: %s", o.runID)
+				kclient, sclient, recorder = newRenderClients()
+				if o.clusterProxy == nil {
+					log.Info("The cluster proxy is not detected when rendering, set --https-proxy to render the plugins of a cluster with proxy.")
+				}
+				if err = o.PreRunSetup(kclient); err != nil {
+					log.WithError(err).Error("pre-run failed when rendering the environment")
+					return err
+				}
+				return nil
+			}
+
+			log.Infof("Run ID: %s", o.runID)
+
 			// Client setup
 			kclient, sclient, err = client.CreateClients()
 			if err != nil {
@@ -100,8 +286,26 @@ func NewCmdRun() *cobra.Command {
 				return err
 			}
 
+			if o.clusterProxy == nil {
+				if o.clusterProxy, err = detectClusterProxy(cmd.Context()); err != nil {
+					log.WithError(err).Error("pre-run failed when detecting the cluster proxy")
+					return err
+				}
+			}
+
+			if o.createMCP {
+				if err = o.setupMachineConfigPool(cmd.Context()); err != nil {
+					log.WithError(err).Error("pre-run failed when creating the MachineConfigPool")
+					return err
+				}
+			}
+
 			// Pre-checks and setup
-			if err = o.PreRunCheck(kclient); err != nil {
+			if err = preflight.ValidateSkipChecks(o.skipChecks); err != nil {
+				log.WithError(err).Error("pre-run failed when validating the options")
+				return err
+			}
+			if err = o.PreRunCheck(kclient, sclient); err != nil {
 				log.WithError(err).Error("pre-run failed when checking dependencies")
 				return err
 			}
@@ -113,12 +317,23 @@ func NewCmdRun() *cobra.Command {
 			return nil
 		},
 		RunE: func(cmd *cobra.Command, args []string) error {
+			if o.render {
+				log.Info("Rendering OPCT environment...")
+				if err := o.Run(kclient, sclient); err != nil {
+					log.WithError(err).Errorf("render finished with errors.")
+					return err
+				}
+				return recorder.Write(cmd.OutOrStdout())
+			}
+
 			log.Info("Running OPCT...")
 			if err := o.Run(kclient, sclient); err != nil {
 				log.WithError(err).Errorf("execution finished with errors.")
 				return err
 			}
 
+			o.notifier.Notify(cmd.Context(), o.scheduledEvent())
+
 			log.Info("Jobs scheduled! Waiting for resources be created...")
 			if err := wait.WaitForRequiredResources(kclient); err != nil {
 				log.WithError(err).Errorf("error waiting for required pods to become ready")
@@ -129,7 +344,8 @@ func NewCmdRun() *cobra.Command {
 			time.Sleep(status.StatusInterval)
 
 			// Retrieve the first status and print it, finishing when --watch is not set.
-			s := status.NewStatusOptions(&status.StatusInput{Watch: o.watch})
+			s := status.NewStatusOptions(&status.StatusInput{Watch: o.watch, Notifier: o.notifier})
+			s.RunID = o.runID
 			if err := s.WaitForStatusReport(cmd.Context(), sclient); err != nil {
 				log.WithError(err).Error("error retrieving aggregator status")
 				return err
@@ -152,21 +368,26 @@ func NewCmdRun() *cobra.Command {
 		},
 	}
 
+	cmd.Flags().StringVar(&o.configFile, "config", "", "Run profile file with the options to launch the validation environment. Flags take precedence over the profile.")
 	cmd.Flags().StringVar(&o.mode, "mode", defaultRunMode, "Run mode: Availble: regular, upgrade")
 	cmd.Flags().StringVar(&o.upgradeImage, "upgrade-to-image", defaultUpgradeImage, "Target OpenShift Release Image. Example: oc adm release info 4.11.18 -o jsonpath={.image}")
+	cmd.Flags().BoolVar(&o.createMCP, "create-mcp", false, "Create the MachineConfigPool required by the upgrade mode when it does not exist, pausing it once the dedicated nodes are updated.")
 	cmd.Flags().StringVar(&o.imageRepository, "image-repository", "", "Image repository containing required images test environment. Example: openshift-provider-cert-tool --mirror-repository mirror.repository.net/ocp-cert")
 
 	cmd.Flags().IntVar(&o.timeout, "timeout", defaultRunTimeoutSeconds, "Execution timeout in seconds")
 	cmd.Flags().BoolVarP(&o.watch, "watch", "w", defaultRunWatchFlag, "Keep watch status after running")
+	cmd.Flags().BoolVar(&o.render, "render", false, "Print the objects which would be created in the cluster, without running.")
 
 	cmd.Flags().StringVar(&o.devCount, "devel-limit-tests", "0", "Developer Mode only: run small random set of tests. Default: 0 (disabled)")
 	cmd.Flags().BoolVar(&o.devSkipChecks, "devel-skip-checks", false, "Developer Mode only: skip checks")
+	cmd.Flags().StringSliceVar(&o.skipChecks, "skip-checks", nil, "Comma-separated list of preflight check IDs to skip. Checks can be reviewed with 'opct preflight'.")
 
 	// Override build-int images use by plugins/steps in the standard workflow.
 	cmd.Flags().StringVar(&o.sonobuoyImage, "sonobuoy-image", pkg.GetSonobuoyImage(), "Image override for the Sonobuoy worker and aggregator")
 	cmd.Flags().StringVar(&o.PluginsImage, "plugins-image", pkg.GetPluginsImage(), "Image containing plugins to be executed.")
 	cmd.Flags().StringVar(&o.CollectorImage, "collector-image", pkg.GetCollectorImage(), "Image containing the collector plugin.")
 	cmd.Flags().StringVar(&o.MustGatherMonitoringImage, "must-gather-monitoring-image", pkg.GetMustGatherMonitoring(), "Image containing the must-gather monitoring plugin.")
+	cmd.Flags().BoolVar(&o.pinImages, "pin-images", false, "Resolve the images to digests from the registry before scheduling, the digests are recorded in the results archive. The registry is reached with anonymous access, use --image-lock-file for private or disconnected registries.")
 
 	// devel can be override by quay.io/opct/openshift-tests:devel
 	// opct run --devel-skip-checks=true --plugins-image=plugin-openshift-tests:v0.0.0-devel-8ff93d9 --devel-tests-image=quay.io/opct/openshift-tests:devel
@@ -174,9 +395,29 @@ func NewCmdRun() *cobra.Command {
 
 	// Flags use for maitainance / development / CI. Those are intentionally hidden.
 	cmd.Flags().StringArrayVar(o.plugins, "plugin", nil, "Override default conformance plugins to use. Can be used multiple times. (default plugins can be reviewed with assets subcommand)")
+	cmd.Flags().StringVar(&o.imageLockFile, "image-lock-file", "", "Pin the images to the digests in the lock file, without reaching the registry. Create the file with 'opct get images --format lock'.")
+	cmd.Flags().StringVar(&o.rbacProfile, "rbac-profile", rbac.ProfilePrivileged, "RBAC profile granted to the validation environment. Available: privileged, scoped. The scoped profile grants the permissions required by the aggregator and the rules shipped for the plugins, extended by --rbac-rules.")
+	cmd.Flags().StringVar(&o.rbacRules, "rbac-rules", "", "ClusterRole file with rules granted by --rbac-profile=scoped in addition to the rules shipped for the plugins. Required by plugins set by --plugin. Create the file from the audit logs of a previous run with 'opct adm rbac audit --output rules'.")
 	cmd.Flags().BoolVar(&o.dedicated, "dedicated", defaultDedicatedFlag, "Setup plugins to run in dedicated test environment.")
+	cmd.Flags().StringToStringVar(&o.tags, "tag", map[string]string{}, "User-defined tag (key=value) recorded in the results archive and report. Can be set multiple times, e.g. --tag customer=acme --tag hw=gen3.")
+	cmd.Flags().StringToIntVar(&o.pluginFlags.timeouts, "plugin-timeout", map[string]int{}, "Timeout in seconds of the plugin, given after the previous plugins finish. Requires the timeout of the previous plugins. Example: --plugin-timeout 99-openshift-artifacts-collector=3600")
+	cmd.Flags().StringToStringVar(&o.pluginFlags.cpuRequests, "plugin-cpu-request", map[string]string{}, "CPU request of the plugin containers. Example: --plugin-cpu-request 20-openshift-conformance-validated=2")
+	cmd.Flags().StringToStringVar(&o.pluginFlags.cpuLimits, "plugin-cpu-limit", map[string]string{}, "CPU limit of the plugin containers. Example: --plugin-cpu-limit 20-openshift-conformance-validated=4")
+	cmd.Flags().StringToStringVar(&o.pluginFlags.memoryRequests, "plugin-memory-request", map[string]string{}, "Memory request of the plugin containers. Example: --plugin-memory-request 20-openshift-conformance-validated=2Gi")
+	cmd.Flags().StringToStringVar(&o.pluginFlags.memoryLimits, "plugin-memory-limit", map[string]string{}, "Memory limit of the plugin containers. Example: --plugin-memory-limit 20-openshift-conformance-validated=8Gi")
+	cmd.Flags().StringArrayVar(&o.pluginFlags.env, "plugin-env", nil, "Environment variable of the plugin containers, empty values remove the variable. Can be used multiple times. Example: --plugin-env 20-openshift-conformance-validated.MY_VAR=value")
+	cmd.Flags().IntVar(&o.dedicatedNodes, "dedicated-nodes", defaultDedicatedNodes, "Number of dedicated nodes. Plugin pods are spread across the nodes when greater than one.")
 	cmd.Flags().StringVar(&o.devCount, "dev-count", "0", "Developer Mode only: run small random set of tests. Default: 0 (disabled)")
 
+	cmd.Flags().StringArrayVar(&o.includePlugins, "include-plugin", nil, "Run only the default plugins with the given name. Can be used multiple times. Example: --include-plugin=10-openshift-kube-conformance")
+	cmd.Flags().StringArrayVar(&o.excludePlugins, "exclude-plugin", nil, "Skip the default plugin with the given name. Can be used multiple times.")
+	cmd.Flags().StringVar(&o.testsFile, "tests-file", "", "File with the list of e2e tests to run, one test name by line. Only the replay plugin, running the tests of the list, and the collector are scheduled.")
+	cmd.Flags().StringVar(&o.httpProxy, "http-proxy", "", "HTTP proxy set to the plugins, overriding the cluster-wide proxy. Used by --render, which does not detect the cluster proxy.")
+	cmd.Flags().StringVar(&o.httpsProxy, "https-proxy", "", "HTTPS proxy set to the plugins, overriding the cluster-wide proxy. Used by --render, which does not detect the cluster proxy.")
+	cmd.Flags().StringVar(&o.noProxy, "no-proxy", "", "Comma-separated destinations excluded from the proxy set by --http-proxy or --https-proxy. The in-cluster destinations are always excluded.")
+	cmd.Flags().BoolVar(&o.skipCollector, "skip-collector", false, "Run without the artifacts collector plugin (99-openshift-artifacts-collector). The report will not have the cluster artifacts.")
+	notify.AddFlags(cmd.Flags())
+
 	hideOptionalFlags(cmd, "plugin")
 	hideOptionalFlags(cmd, "dedicated")
 	// hideOptionalFlags(cmd, "devel-limit-tests")
@@ -191,157 +432,147 @@ func NewCmdRun() *cobra.Command {
 	return cmd
 }
 
-// PreRunCheck performs some checks before kicking off Sonobuoy
-func (r *RunOptions) PreRunCheck(kclient kubernetes.Interface) error {
-	coreClient := kclient.CoreV1()
+// record adds the object created by the run to the inventory.
+func (r *RunOptions) record(kind, namespace, name string) {
+	if r.inventory == nil {
+		r.inventory = inventory.New(r.runID)
+	}
+	r.inventory.Add(kind, namespace, name)
+}
+
+// scheduledEvent returns the event of the plugins scheduled by the run.
+func (r *RunOptions) scheduledEvent() *notify.Event {
+	ev := notify.NewEvent(notify.EventPluginsScheduled, r.runID, fmt.Sprintf("%d plugins scheduled: %s", len(r.selectedPlugins), strings.Join(r.selectedPlugins, ", ")))
+	ev.Plugins = r.selectedPlugins
+	return ev
+}
 
-	// Get ConfigV1 client for Cluster Operators
+// proxyFromFlags returns the proxy set by the user, or nil when not set.
+func (r *RunOptions) proxyFromFlags() *proxy.ClusterProxy {
+	if r.httpProxy == "" && r.httpsProxy == "" {
+		return nil
+	}
+	return &proxy.ClusterProxy{HTTPProxy: r.httpProxy, HTTPSProxy: r.httpsProxy, NoProxy: r.noProxy}
+}
+
+// detectClusterProxy returns the cluster-wide proxy, when configured.
+func detectClusterProxy(ctx context.Context) (*proxy.ClusterProxy, error) {
 	restConfig, err := client.CreateRestConfig()
 	if err != nil {
-		return err
+		return nil, err
 	}
-	oc, err := coclient.NewForConfig(restConfig)
+	configClient, err := coclient.NewForConfig(restConfig)
 	if err != nil {
-		return err
+		return nil, err
 	}
+	return proxy.GetClusterProxy(ctx, configClient)
+}
 
-	// Check if Cluster Operators are stable
-	if errs := checkClusterOperators(oc); errs != nil {
-		errorMessages := []string{}
-		for _, err := range errs {
-			errorMessages = append(errorMessages, err.Error())
-		}
-		log.Errorf("Preflights checks failed: operators are not in ready state, check the status with 'oc get clusteroperator': %v", errorMessages)
-		if !r.devSkipChecks {
-			return errors.New("All Cluster Operators must be available, not progressing, and not degraded before validation can run.")
+// PreRunCheck performs the preflight checks before kicking off Sonobuoy.
+func (r *RunOptions) PreRunCheck(kclient kubernetes.Interface, sclient sonobuoyclient.Interface) error {
+	clients, err := preflight.NewClients()
+	if err != nil {
+		return err
+	}
+	clients.Kube = kclient
+	clients.Sonobuoy = sclient
+
+	results := preflight.Run(context.TODO(), clients, &preflight.Options{
+		Mode:            r.mode,
+		UpgradeImage:    r.upgradeImage,
+		ImageRepository: r.imageRepository,
+		Dedicated:       r.dedicated,
+		DedicatedNodes:  r.dedicatedNodes,
+		SkipChecks:      r.skipChecks,
+		Devel:           r.devSkipChecks,
+	})
+	for _, res := range results {
+		switch res.Status {
+		case preflight.StatusFail:
+			log.Errorf("Preflight check %s failed: %s. %s", res.ID, res.Message, res.Remediation)
+		case preflight.StatusWarn:
+			log.Warnf("Preflight check %s: %s", res.ID, res.Message)
+		case preflight.StatusSkip:
+			log.Debugf("Preflight check %s skipped: %s", res.ID, res.Message)
+		default:
+			log.Debugf("Preflight check %s passed", res.ID)
 		}
-		log.Warnf("DEVEL MODE, THIS IS NOT SUPPORTED: Skipping Cluster Operator checks: %v", errs)
 	}
+	if preflight.HasFailures(results) {
+		return errors.New("preflight checks failed, run 'opct preflight' to review the results")
+	}
+	return nil
+}
 
-	// Get ConfigV1 client for Cluster Operators
-	irClient, err := irclient.NewForConfig(restConfig)
+// setupMachineConfigPool creates the MachineConfigPool used by the dedicated
+// node in upgrade mode, waiting for the node to be updated and the pool paused.
+func (r *RunOptions) setupMachineConfigPool(ctx context.Context) error {
+	if r.mode != "upgrade" {
+		log.Warnf("The MachineConfigPool is required only in upgrade mode, ignoring --create-mcp")
+		return nil
+	}
+	restConfig, err := client.CreateRestConfig()
 	if err != nil {
 		return err
 	}
-
-	// Check if Registry is in managed state or exit
-	managed, err := checkRegistry(irClient)
+	mcClient, err := mcfgclientset.NewForConfig(restConfig)
 	if err != nil {
-		if !r.devSkipChecks {
-			return err
-		}
-		log.Warn("DEVEL MODE, THIS IS NOT SUPPORTED: Skipping Image registry check: %w", err)
+		return err
 	}
-	if !managed {
-		if !r.devSkipChecks {
-			return errors.New("OpenShift Image Registry must deployed before validation can run")
-		}
-		log.Warn("DEVEL MODE, THIS IS NOT SUPPORTED: Skipping unmanaged image registry check")
+	created, err := upgrade.EnsureMachineConfigPool(ctx, mcClient)
+	if err != nil {
+		return err
 	}
+	// Existing pools are owned by the user, not removed by destroy.
+	if created {
+		r.record(inventory.KindMachineConfigPool, "", pkg.MachineConfigPoolName)
+	}
+	return upgrade.WaitForMachineConfigPool(ctx, mcClient, upgrade.DefaultMachineConfigPoolWaitTimeout)
+}
 
-	if r.dedicated {
-		log.Info("Ensuring required node label and taints exists")
-		nodes, err := coreClient.Nodes().List(context.TODO(), metav1.ListOptions{
-			LabelSelector: pkg.DedicatedNodeRoleLabelSelector,
-		})
+// resolveImageDigests pins the images used by the run to digests, resolved
+// from the image lock file when set, otherwise from the registry.
+func (r *RunOptions) resolveImageDigests(ctx context.Context) (*images.Lock, error) {
+	var resolver images.Resolver = images.NewRegistryResolver()
+	if r.imageLockFile != "" {
+		lock, err := images.LoadLock(r.imageLockFile)
 		if err != nil {
-			return errors.Wrap(err, "error getting the Node list")
-		}
-		if len(nodes.Items) == 0 {
-			errMsg := fmt.Sprintf("missing dedicated node. Set the label %q to a node and try again", pkg.DedicatedNodeRoleLabelSelector)
-			errMsg = fmt.Sprintf("%s\nCheck the documentation[1] or run 'opct adm setup-node' to set the label and taints", errMsg)
-			errMsg = fmt.Sprintf("%s\n[1] https://redhat-openshift-ecosystem.github.io/provider-certification-tool/user/#standard-env-setup-node", errMsg)
-			return fmt.Errorf(errMsg)
+			return nil, err
 		}
-		if len(nodes.Items) > 2 {
-			return fmt.Errorf("too many nodes with label %q. Set the label to only one node and try again", pkg.DedicatedNodeRoleLabelSelector)
-		}
-		node := nodes.Items[0]
-		found := false
-		for _, taint := range node.Spec.Taints {
-			if taint.Key == pkg.DedicatedNodeRoleLabel {
-				found = true
-				break
-			}
-		}
-		if !found {
-			return fmt.Errorf("missing taint \"%s='':NoSchedule\" in the dedicated node %q. Set the taint and try again", pkg.DedicatedNodeRoleLabel, node.Name)
+		resolver = &images.LockResolver{Lock: lock}
+		// Images rewritten by --image-repository are looked up by the source image.
+		if r.imageRepository != "" && r.imageRepository != pkg.DefaultToolsRepository {
+			resolver = &images.LockResolver{Lock: lock, Mirror: r.imageRepository, Source: pkg.DefaultToolsRepository}
 		}
 	}
 
-	// Check if namespace already exists
-	p, err := coreClient.Namespaces().Get(context.TODO(), pkg.CertificationNamespace, metav1.GetOptions{})
-	if err != nil {
-		// If error is due to namespace not being found, we continue.
-		if !kerrors.IsNotFound(err) {
-			return err
+	lock := images.NewLock()
+	for _, image := range []*string{&r.sonobuoyImage, &r.PluginsImage, &r.CollectorImage, &r.MustGatherMonitoringImage, &r.OpenshiftTestsImage} {
+		if *image == "" {
+			continue
 		}
-	}
-
-	if p.Name != "" {
-		return errors.New(fmt.Sprintf("%s namespace already exists. You must run 'destroy' to clean the environment and try again.", pkg.CertificationNamespace))
-	}
-
-	// Check if MachineConfigPool exists when upgrade mode is set.:
-	// - node selectors: node-role.kubernetes.io/tests=''
-	// - paused: true
-	// Check MachineConfigPool when upgrade.
-	if r.mode == "upgrade" {
-		mcpName := "opct"
-		machineConfigClient, err := mcfgclientset.NewForConfig(restConfig)
-		if err != nil {
-			return err
-		}
-		poolList, err := machineConfigClient.MachineconfigurationV1().MachineConfigPools().List(context.TODO(), metav1.ListOptions{})
-		if err != nil {
-			return fmt.Errorf("getting MachineConfigPools failed: %w", err)
+		// The openshift-tests image is served by the cluster from the release payload.
+		if strings.HasPrefix(*image, "image-registry.openshift-image-registry.svc") {
+			log.Debugf("Skipping image served by the cluster registry: %s", *image)
+			continue
 		}
-		// Should we need to create it when not found?
-		mcpCreateInstructions := func() {
-			log.Println("MachineConfigPool not found, create it with the following instructions:")
-			fmt.Println(`$ cat << EOF  | oc apply -f -
----
-apiVersion: machineconfiguration.openshift.io/v1
-kind: MachineConfigPool
-metadata:
-  name: opct
-spec:
-  machineConfigSelector:
-    matchExpressions:
-      - key: machineconfiguration.openshift.io/role,
-        operator: In,
-        values: [worker,opct]
-  nodeSelector:
-    matchLabels:
-      node-role.kubernetes.io/tests: ""
-  paused: true
-EOF`)
+		if images.IsPinned(*image) {
+			lock.Add(*image, (*image)[strings.Index(*image, "@")+1:])
+			continue
 		}
-		if len(poolList.Items) == 0 {
-			fmt.Println()
-			return fmt.Errorf("MachineConfigPool %q not found, create it and try again", mcpName)
-		}
-		isFound := false
-		isPaused := false
-		for _, pool := range poolList.Items {
-			if pool.Name == mcpName {
-				isFound = true
-				if !pool.Spec.Paused {
-					log.Errorf("MachineConfigPool %q is not paused", mcpName)
-				}
-				isPaused = true
+		digest, err := resolver.Resolve(ctx, *image)
+		if err != nil {
+			if r.imageLockFile != "" {
+				return nil, err
 			}
+			return nil, fmt.Errorf("unable to pin the image %s, use --image-lock-file to pin the images without reaching the registry: %w", *image, err)
 		}
-		if !isFound {
-			mcpCreateInstructions()
-			return fmt.Errorf("MachineConfigPool %q not found, create it and try again", mcpName)
-		}
-		if !isPaused {
-			return fmt.Errorf("MachineConfigPool %q is not paused, set `spec.pause=true` and try again", mcpName)
-		}
+		lock.Add(*image, digest)
+		pinned := images.Pin(*image, digest)
+		log.Infof("Image %s pinned to %s", *image, pinned)
+		*image = pinned
 	}
-
-	return nil
+	return lock, nil
 }
 
 // PreRunSetup performs setup required by OPCT environment.
@@ -350,8 +581,8 @@ func (r *RunOptions) PreRunSetup(kclient kubernetes.Interface) error {
 
 	namespace := &v1.Namespace{
 		ObjectMeta: metav1.ObjectMeta{
-			Name:        pkg.CertificationNamespace,
-			Labels:      pkg.SonobuoyDefaultLabels,
+			Name:        pkg.GetNamespace(),
+			Labels:      runid.Labels(r.runID),
 			Annotations: make(map[string]string),
 		},
 	}
@@ -377,14 +608,15 @@ func (r *RunOptions) PreRunSetup(kclient kubernetes.Interface) error {
 	if err != nil {
 		return errors.Wrap(err, "error creating Namespace")
 	}
+	r.record(inventory.KindNamespace, "", pkg.GetNamespace())
 
 	// Create Sonobuoy ServiceAccount
 	// https://github.com/vmware-tanzu/sonobuoy/blob/main/pkg/client/gen.go#L611-L616
 	sa := &v1.ServiceAccount{
 		ObjectMeta: metav1.ObjectMeta{
 			Name:      pkg.SonobuoyServiceAccountName,
-			Namespace: pkg.CertificationNamespace,
-			Labels:    pkg.SonobuoyDefaultLabels,
+			Namespace: pkg.GetNamespace(),
+			Labels:    runid.Labels(r.runID),
 		},
 	}
 	sa.SetGroupVersionKind(schema.GroupVersionKind{
@@ -393,10 +625,11 @@ func (r *RunOptions) PreRunSetup(kclient kubernetes.Interface) error {
 		Kind:    "ServiceAccount",
 	})
 
-	_, err = kclient.CoreV1().ServiceAccounts(pkg.CertificationNamespace).Create(context.TODO(), sa, metav1.CreateOptions{})
+	_, err = kclient.CoreV1().ServiceAccounts(pkg.GetNamespace()).Create(context.TODO(), sa, metav1.CreateOptions{})
 	if err != nil {
 		return errors.Wrap(err, "error creating ServiceAccount")
 	}
+	r.record(inventory.KindServiceAccount, pkg.GetNamespace(), pkg.SonobuoyServiceAccountName)
 
 	log.Info("Ensuring the tool will run in the privileged environment...")
 
@@ -404,53 +637,31 @@ func (r *RunOptions) PreRunSetup(kclient kubernetes.Interface) error {
 
 	// Replacing Sonobuoy's default Admin RBAC not working correctly on upgrades.
 	// https://github.com/vmware-tanzu/sonobuoy/blob/5b97033257d0276c7b0d1b20412667a69d79261e/pkg/client/gen.go#L445-L481
-	cr := &rbacv1.ClusterRole{
-		ObjectMeta: metav1.ObjectMeta{
-			Name:      pkg.PrivilegedClusterRole,
-			Namespace: pkg.CertificationNamespace,
-			Labels:    pkg.SonobuoyDefaultLabels,
-		},
-		Rules: []rbacv1.PolicyRule{
-			{
-				APIGroups: []string{"*"},
-				Resources: []string{"*"},
-				Verbs:     []string{"*"},
-			},
-			{
-				NonResourceURLs: []string{"/metrics", "/logs", "/logs/*"},
-				Verbs:           []string{"get"},
-			},
-		},
+	rules := rbac.PrivilegedRules()
+	if r.rbacProfile == rbac.ProfileScoped {
+		rules = r.scopedRules
 	}
-	cr.SetGroupVersionKind(schema.GroupVersionKind{
-		Group:   rbacv1.GroupName,
-		Version: "v1",
-		Kind:    "ClusterRole",
-	})
-
-	_, err = rbacClient.ClusterRoles().Update(context.TODO(), cr, metav1.UpdateOptions{})
-	if err != nil {
-		return errors.Wrap(err, "error creating privileged ClusterRole")
+	if err := r.updateClusterRole(kclient, rules); err != nil {
+		return err
 	}
-	log.Infof("Created %s ClusterRole", pkg.PrivilegedClusterRole)
 
 	crb := &rbacv1.ClusterRoleBinding{
 		ObjectMeta: metav1.ObjectMeta{
-			Name:      pkg.PrivilegedClusterRoleBinding,
-			Namespace: pkg.CertificationNamespace,
-			Labels:    pkg.SonobuoyDefaultLabels,
+			Name:      pkg.GetPrivilegedClusterRoleBinding(),
+			Namespace: pkg.GetNamespace(),
+			Labels:    runid.Labels(r.runID),
 		},
 		Subjects: []rbacv1.Subject{
 			{
 				Kind:      rbacv1.ServiceAccountKind,
 				Name:      pkg.SonobuoyServiceAccountName,
-				Namespace: pkg.CertificationNamespace,
+				Namespace: pkg.GetNamespace(),
 			},
 		},
 		RoleRef: rbacv1.RoleRef{
 			APIGroup: rbacv1.GroupName,
 			Kind:     "ClusterRole",
-			Name:     pkg.PrivilegedClusterRole,
+			Name:     pkg.GetPrivilegedClusterRole(),
 		},
 	}
 	crb.SetGroupVersionKind(schema.GroupVersionKind{
@@ -463,17 +674,72 @@ func (r *RunOptions) PreRunSetup(kclient kubernetes.Interface) error {
 	if err != nil {
 		return errors.Wrap(err, "error creating privileged ClusterRoleBinding")
 	}
-	log.Infof("Created %s ClusterRoleBinding", pkg.PrivilegedClusterRoleBinding)
+	r.record(inventory.KindClusterRoleBinding, "", pkg.GetPrivilegedClusterRoleBinding())
+	log.Infof("Created %s ClusterRoleBinding", pkg.GetPrivilegedClusterRoleBinding())
 
 	return nil
 }
 
+// loadScopedRules returns the rules of the scoped profile: the rules shipped
+// for the plugins, extended by the rules read from the file. The file is
+// required by plugins without default rules.
+func loadScopedRules(fsys fs.FS, path string, plugins []string) ([]rbacv1.PolicyRule, error) {
+	pluginRules, missing, err := rbac.DefaultPluginRules(fsys, plugins)
+	if err != nil {
+		return nil, err
+	}
+	if path == "" {
+		if len(missing) > 0 {
+			return nil, fmt.Errorf("--rbac-rules must be set when --rbac-profile=%s to run plugins without default rules (%s), create the rules with 'opct adm rbac audit --output rules'", rbac.ProfileScoped, strings.Join(missing, ", "))
+		}
+		return rbac.ScopedRules(pluginRules)
+	}
+	fileRules, err := rbac.ReadRules(path)
+	if err != nil {
+		return nil, err
+	}
+	return rbac.ScopedRules(append(pluginRules, fileRules...))
+}
+
+// updateClusterRole sets the rules of the ClusterRole bound to the validation
+// environment service account.
+func (r *RunOptions) updateClusterRole(kclient kubernetes.Interface, rules []rbacv1.PolicyRule) error {
+	cr := &rbacv1.ClusterRole{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      pkg.GetPrivilegedClusterRole(),
+			Namespace: pkg.GetNamespace(),
+			Labels:    runid.Labels(r.runID),
+		},
+		Rules: rules,
+	}
+	cr.SetGroupVersionKind(schema.GroupVersionKind{
+		Group:   rbacv1.GroupName,
+		Version: "v1",
+		Kind:    "ClusterRole",
+	})
+
+	_, err := kclient.RbacV1().ClusterRoles().Update(context.TODO(), cr, metav1.UpdateOptions{})
+	if err != nil {
+		return errors.Wrap(err, "error creating privileged ClusterRole")
+	}
+	r.record(inventory.KindClusterRole, "", pkg.GetPrivilegedClusterRole())
+	log.Infof("Created %s ClusterRole with %s RBAC profile", pkg.GetPrivilegedClusterRole(), r.rbacProfile)
+	return nil
+}
+
 // createConfigMap generic way to create the configMap on the certification namespace.
 func (r *RunOptions) createConfigMap(kclient kubernetes.Interface, sclient sonobuoyclient.Interface, cm *v1.ConfigMap) error {
-	_, err := kclient.CoreV1().ConfigMaps(pkg.CertificationNamespace).Create(context.TODO(), cm, metav1.CreateOptions{})
+	if r.runID != "" {
+		if cm.Labels == nil {
+			cm.Labels = map[string]string{}
+		}
+		cm.Labels[runid.Label] = r.runID
+	}
+	_, err := kclient.CoreV1().ConfigMaps(pkg.GetNamespace()).Create(context.TODO(), cm, metav1.CreateOptions{})
 	if err != nil {
 		return err
 	}
+	r.record(inventory.KindConfigMap, pkg.GetNamespace(), cm.Name)
 	return nil
 }
 
@@ -505,36 +771,102 @@ func (r *RunOptions) Run(kclient kubernetes.Interface, sclient sonobuoyclient.In
 		r.MustGatherMonitoringImage = fmt.Sprintf("%s/%s", imageRepository, pkg.MustGatherMonitoringImage)
 	}
 
-	// Let Sonobuoy do some preflight checks before we run
-	errs := sclient.PreflightChecks(&sonobuoyclient.PreflightConfig{
-		Namespace:           pkg.CertificationNamespace,
-		DNSNamespace:        "openshift-dns",
-		DNSPodLabels:        []string{"dns.operator.openshift.io/daemonset-dns=default"},
-		PreflightChecksSkip: []string{"existingnamespace"}, // Skip namespace check since we create it manually
-	})
-	if len(errs) > 0 {
-		for _, err := range errs {
-			log.Error(err)
+	// Images are pinned before the manifests are rendered.
+	var imageLock *images.Lock
+	if r.pinImages || r.imageLockFile != "" {
+		var err error
+		imageLock, err = r.resolveImageDigests(context.TODO())
+		if err != nil {
+			return err
 		}
-		if !r.devSkipChecks {
-			return errors.New("preflight checks failed")
+	}
+
+	if r.plugins == nil || len(*r.plugins) == 0 {
+		log.Debugf("Loading default plugins")
+		var err error
+		manifests, err = loadPluginManifests(r)
+		if err != nil {
+			return err
+		}
+		// Targeted runs replace the replay plugin by the plugin running the tests from the list.
+		if len(r.tests) > 0 {
+			testsManifest, err := loadTestsManifest(r)
+			if err != nil {
+				return err
+			}
+			if err := replaceReplayManifest(manifests, testsManifest); err != nil {
+				return err
+			}
+		}
+	} else {
+		// User provided their own plugins at command line
+		log.Debugf("Loading plugins specific at command line")
+		for _, p := range *r.plugins {
+			asset, err := loader.LoadDefinitionFromFile(p)
+			if err != nil {
+				return err
+			}
+			manifests = append(manifests, asset)
+		}
+	}
+
+	if len(manifests) == 0 {
+		return errors.New("No validation plugins to run")
+	}
+
+	var skippedPlugins []string
+	if len(r.includePlugins) > 0 || len(r.excludePlugins) > 0 || r.skipCollector {
+		var err error
+		manifests, skippedPlugins, err = filterPluginManifests(r, manifests)
+		if err != nil {
+			return err
 		}
-		log.Warn("DEVEL MODE, THIS IS NOT SUPPORTED: Skipping preflight checks")
+	}
+
+	selectedPlugins := []string{}
+	for _, m := range manifests {
+		selectedPlugins = append(selectedPlugins, m.SonobuoyConfig.PluginName)
+	}
+	sort.Strings(selectedPlugins)
+	r.selectedPlugins = selectedPlugins
+	if len(r.tests) > 0 && sort.SearchStrings(selectedPlugins, plugin.PluginNameConformanceReplay) == len(selectedPlugins) {
+		return fmt.Errorf("plugin %q must be selected to run the tests from --tests-file", plugin.PluginNameConformanceReplay)
+	}
+	if r.dedicated && r.dedicatedNodes > 1 {
+		spreadPluginPods(manifests)
+		log.Infof("Plugin pods will be spread across %d dedicated nodes", r.dedicatedNodes)
+	}
+	if r.clusterProxy != nil {
+		applyClusterProxy(manifests, r.clusterProxy)
+		log.Infof("Cluster proxy set, plugins will use the proxy %s", r.clusterProxy.HTTPSProxy)
+	}
+	pluginEnvOverrides, err := applyPluginSettings(manifests, r.pluginSettings, r.timeout)
+	if err != nil {
+		return err
+	}
+	if len(skippedPlugins) > 0 {
+		log.Infof("Plugins selected to run: %s", strings.Join(selectedPlugins, ", "))
+		log.Warnf("Plugins skipped by user selection: %s", strings.Join(skippedPlugins, ", "))
 	}
 
 	// Create version information ConfigMap
-	if err := r.createConfigMap(kclient, sclient, &v1.ConfigMap{
+	versionCM := &v1.ConfigMap{
 		ObjectMeta: metav1.ObjectMeta{
 			Name:      pkg.VersionInfoConfigMapName,
-			Namespace: pkg.CertificationNamespace,
+			Namespace: pkg.GetNamespace(),
 		},
 		Data: map[string]string{
 			"cli-version":      version.Version.Version,
 			"cli-commit":       version.Version.Commit,
 			"sonobuoy-version": buildinfo.Version,
 			"sonobuoy-image":   r.sonobuoyImage,
+			runid.ConfigMapKey: r.runID,
 		},
-	}); err != nil {
+	}
+	if imageLock != nil {
+		versionCM.Data[images.LockConfigMapKey] = imageLock.String()
+	}
+	if err := r.createConfigMap(kclient, sclient, versionCM); err != nil {
 		return err
 	}
 
@@ -542,43 +874,62 @@ func (r *RunOptions) Run(kclient kubernetes.Interface, sclient sonobuoyclient.In
 		"dev-count":             r.devCount,
 		"run-mode":              r.mode,
 		"upgrade-target-images": r.upgradeImage,
+		archive.RunProfileKey:   newRunProfileFromOptions(r).String(),
+		"plugins-selected":      strings.Join(selectedPlugins, ","),
+		"plugins-skipped":       strings.Join(skippedPlugins, ","),
+		"rbac-profile":          r.rbacProfile,
+	}
+
+	if r.dedicated {
+		configMapData["dedicated-nodes"] = strconv.Itoa(r.dedicatedNodes)
+	}
+
+	for k, v := range tagsConfigMapData(r.tags) {
+		configMapData[k] = v
+	}
+
+	if r.clusterProxy != nil {
+		configMapData["cluster-proxy"] = "true"
+		if err := r.createConfigMap(kclient, sclient, proxy.NewTrustedCAConfigMap(pkg.GetNamespace())); err != nil {
+			return err
+		}
 	}
 
 	if len(r.imageRepository) > 0 {
 		configMapData["mirror-registry"] = r.imageRepository
 	}
 
+	if len(r.tests) > 0 {
+		configMapData["tests-count"] = strconv.Itoa(len(r.tests))
+		if err := r.createConfigMap(kclient, sclient, &v1.ConfigMap{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      pkg.PluginsTestsConfigMapName,
+				Namespace: pkg.GetNamespace(),
+			},
+			Data: map[string]string{
+				testsConfigMapKey: strings.Join(r.tests, "\n") + "\n",
+			},
+		}); err != nil {
+			return err
+		}
+	}
+
 	if err := r.createConfigMap(kclient, sclient, &v1.ConfigMap{
 		ObjectMeta: metav1.ObjectMeta{
 			Name:      pkg.PluginsVarsConfigMapName,
-			Namespace: pkg.CertificationNamespace,
+			Namespace: pkg.GetNamespace(),
 		},
 		Data: configMapData,
 	}); err != nil {
 		return err
 	}
 
-	if r.plugins == nil || len(*r.plugins) == 0 {
-		log.Debugf("Loading default plugins")
-		var err error
-		manifests, err = loadPluginManifests(r)
-		if err != nil {
-			return err
-		}
-	} else {
-		// User provided their own plugins at command line
-		log.Debugf("Loading plugins specific at command line")
-		for _, p := range *r.plugins {
-			asset, err := loader.LoadDefinitionFromFile(p)
-			if err != nil {
-				return err
-			}
-			manifests = append(manifests, asset)
-		}
+	// The objects created by Sonobuoy are removed with the namespace.
+	if r.inventory == nil {
+		r.inventory = inventory.New(r.runID)
 	}
-
-	if len(manifests) == 0 {
-		return errors.New("No validation plugins to run")
+	if err := inventory.Save(context.TODO(), kclient, r.inventory); err != nil {
+		return err
 	}
 
 	// Fill out the aggregator and worker configs
@@ -591,7 +942,11 @@ func (r *RunOptions) Run(kclient kubernetes.Interface, sclient sonobuoyclient.In
 	}
 
 	// Set aggregator deployment namespace
-	aggConfig.Namespace = pkg.CertificationNamespace
+	aggConfig.Namespace = pkg.GetNamespace()
+
+	// Sonobuoy does not support custom labels, the run ID is set as annotation
+	// to the aggregator and plugin pods.
+	aggConfig.CustomAnnotations = map[string]string{runid.Label: r.runID}
 
 	// Ignore Existing SA created on preflight
 	aggConfig.ExistingServiceAccount = true
@@ -605,55 +960,10 @@ func (r *RunOptions) Run(kclient kubernetes.Interface, sclient sonobuoyclient.In
 			EnableRBAC:         false, // RBAC is created in preflight
 			ImagePullPolicy:    config.DefaultSonobuoyPullPolicy,
 			StaticPlugins:      manifests,
-			PluginEnvOverrides: nil, // TODO We'll use this later
+			PluginEnvOverrides: pluginEnvOverrides,
 		},
 	}
 
-	err := sclient.Run(runConfig)
+	err = sclient.Run(runConfig)
 	return err
 }
-
-func checkClusterOperators(configClient coclient.Interface) []error {
-	var result []error
-	// List all Cluster Operators
-	coList, err := configClient.ConfigV1().ClusterOperators().List(context.TODO(), metav1.ListOptions{})
-	if err != nil {
-		return []error{err}
-	}
-
-	// Each Cluster Operator should be available, not progressing, and not degraded
-	for _, co := range coList.Items {
-		for _, cond := range co.Status.Conditions {
-			switch cond.Type {
-			case configv1.OperatorAvailable:
-				if cond.Status == configv1.ConditionFalse {
-					result = append(result, errors.Errorf("%s is unavailable", co.Name))
-				}
-			case configv1.OperatorProgressing:
-				if cond.Status == configv1.ConditionTrue {
-					result = append(result, errors.Errorf("%s is still progressing", co.Name))
-				}
-			case configv1.OperatorDegraded:
-				if cond.Status == configv1.ConditionTrue {
-					result = append(result, errors.Errorf("%s is in degraded state", co.Name))
-				}
-			}
-		}
-	}
-
-	return result
-}
-
-// Check registry is in managed state. We assume Cluster Operator is stable.
-func checkRegistry(irClient irclient.Interface) (bool, error) {
-	irConfig, err := irClient.ImageregistryV1().Configs().Get(context.TODO(), "cluster", metav1.GetOptions{})
-	if err != nil {
-		return false, err
-	}
-
-	if irConfig.Spec.ManagementState != operatorv1.Managed {
-		return false, nil
-	}
-
-	return true, nil
-}
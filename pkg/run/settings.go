@@ -0,0 +1,283 @@
+package run
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	log "github.com/sirupsen/logrus"
+	"github.com/vmware-tanzu/sonobuoy/pkg/plugin/driver"
+	"github.com/vmware-tanzu/sonobuoy/pkg/plugin/loader"
+	"github.com/vmware-tanzu/sonobuoy/pkg/plugin/manifest"
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+)
+
+// PluginSettings are the per-plugin overrides of the timeout, resources and
+// environment variables, set by the --plugin-* flags or the run profile.
+type PluginSettings struct {
+	// Timeout is the time, in seconds, given to the plugin after the
+	// previous plugins finish. See pluginDeadlines.
+	Timeout int `json:"timeout,omitempty"`
+
+	// Resources are set to every container of the plugin pod.
+	Resources *v1.ResourceRequirements `json:"resources,omitempty"`
+
+	// Env are the environment variables set to the plugin containers. Empty
+	// values remove the variable.
+	Env map[string]string `json:"env,omitempty"`
+}
+
+// pluginSettingsFlags holds the --plugin-* flags, keyed by plugin name.
+type pluginSettingsFlags struct {
+	timeouts       map[string]int
+	cpuRequests    map[string]string
+	cpuLimits      map[string]string
+	memoryRequests map[string]string
+	memoryLimits   map[string]string
+	env            []string
+}
+
+// copyPluginSettings returns a deep copy of the settings.
+func copyPluginSettings(settings map[string]*PluginSettings) map[string]*PluginSettings {
+	out := make(map[string]*PluginSettings, len(settings))
+	for name, s := range settings {
+		if s == nil {
+			continue
+		}
+		c := &PluginSettings{Timeout: s.Timeout}
+		if s.Resources != nil {
+			c.Resources = s.Resources.DeepCopy()
+		}
+		if s.Env != nil {
+			c.Env = make(map[string]string, len(s.Env))
+			for k, v := range s.Env {
+				c.Env[k] = v
+			}
+		}
+		out[name] = c
+	}
+	return out
+}
+
+// mergePluginSettingsFlags merges the --plugin-* flags into the settings,
+// the flags take precedence over the settings loaded from the run profile.
+func mergePluginSettingsFlags(settings map[string]*PluginSettings, f *pluginSettingsFlags) (map[string]*PluginSettings, error) {
+	out := copyPluginSettings(settings)
+	get := func(name string) *PluginSettings {
+		if _, ok := out[name]; !ok {
+			out[name] = &PluginSettings{}
+		}
+		return out[name]
+	}
+	for name, timeout := range f.timeouts {
+		get(name).Timeout = timeout
+	}
+	setQuantity := func(flag string, values map[string]string, resourceName v1.ResourceName, limit bool) error {
+		for name, value := range values {
+			q, err := resource.ParseQuantity(value)
+			if err != nil {
+				return fmt.Errorf("invalid --%s %s=%s: %w", flag, name, value, err)
+			}
+			s := get(name)
+			if s.Resources == nil {
+				s.Resources = &v1.ResourceRequirements{}
+			}
+			list := &s.Resources.Requests
+			if limit {
+				list = &s.Resources.Limits
+			}
+			if *list == nil {
+				*list = v1.ResourceList{}
+			}
+			(*list)[resourceName] = q
+		}
+		return nil
+	}
+	if err := setQuantity("plugin-cpu-request", f.cpuRequests, v1.ResourceCPU, false); err != nil {
+		return nil, err
+	}
+	if err := setQuantity("plugin-cpu-limit", f.cpuLimits, v1.ResourceCPU, true); err != nil {
+		return nil, err
+	}
+	if err := setQuantity("plugin-memory-request", f.memoryRequests, v1.ResourceMemory, false); err != nil {
+		return nil, err
+	}
+	if err := setQuantity("plugin-memory-limit", f.memoryLimits, v1.ResourceMemory, true); err != nil {
+		return nil, err
+	}
+	// Env format follows the sonobuoy's --plugin-env: <plugin>.<name>=<value>
+	for _, item := range f.env {
+		kv := strings.SplitN(item, "=", 2)
+		pluginEnv := strings.SplitN(kv[0], ".", 2)
+		if len(kv) != 2 || len(pluginEnv) != 2 || pluginEnv[0] == "" || pluginEnv[1] == "" {
+			return nil, fmt.Errorf("invalid --plugin-env %q, expected format: <plugin>.<name>=<value>", item)
+		}
+		s := get(pluginEnv[0])
+		if s.Env == nil {
+			s.Env = map[string]string{}
+		}
+		s.Env[pluginEnv[1]] = kv[1]
+	}
+	return out, nil
+}
+
+// validatePluginSettings checks the settings against the available plugins.
+func validatePluginSettings(settings map[string]*PluginSettings, plugins []string) error {
+	available := map[string]struct{}{}
+	for _, name := range plugins {
+		available[name] = struct{}{}
+	}
+	names := make([]string, 0, len(settings))
+	for name := range settings {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		if _, ok := available[name]; !ok {
+			return fmt.Errorf("unknown plugin %q in the plugin settings, available plugins: %s", name, strings.Join(plugins, ", "))
+		}
+		s := settings[name]
+		if s.Timeout < 0 {
+			return fmt.Errorf("invalid timeout %d of plugin %q, must be greater than zero", s.Timeout, name)
+		}
+		if s.Resources == nil {
+			continue
+		}
+		for resourceName, limit := range s.Resources.Limits {
+			if request, ok := s.Resources.Requests[resourceName]; ok && request.Cmp(limit) > 0 {
+				return fmt.Errorf("invalid %s request %s of plugin %q, must be less than or equal to the limit %s", resourceName, request.String(), name, limit.String())
+			}
+		}
+	}
+	return nil
+}
+
+// pluginNames returns the name of the plugins available to run, the embedded
+// plugins or the plugins set by --plugin, and the plugins selected to run.
+func pluginNames(r *RunOptions) ([]string, []string, error) {
+	var manifests []*manifest.Manifest
+	if r.plugins == nil || len(*r.plugins) == 0 {
+		var err error
+		manifests, err = loadPluginManifests(r)
+		if err != nil {
+			return nil, nil, err
+		}
+	} else {
+		for _, p := range *r.plugins {
+			asset, err := loader.LoadDefinitionFromFile(p)
+			if err != nil {
+				return nil, nil, err
+			}
+			manifests = append(manifests, asset)
+		}
+	}
+	names := func(manifests []*manifest.Manifest) []string {
+		names := make([]string, 0, len(manifests))
+		for _, m := range manifests {
+			names = append(names, m.SonobuoyConfig.PluginName)
+		}
+		sort.Strings(names)
+		return names
+	}
+	available := names(manifests)
+	if len(r.includePlugins) > 0 || len(r.excludePlugins) > 0 || r.skipCollector {
+		var err error
+		manifests, _, err = filterPluginManifests(r, manifests)
+		if err != nil {
+			return nil, nil, err
+		}
+	}
+	return available, names(manifests), nil
+}
+
+// pluginDeadlines returns the deadline, in seconds, of the pods of the plugins
+// with timeout. Plugins are scheduled together and each one waits for the
+// previous plugins, in the order of the name, thus the deadline of the pod is
+// the sum of the timeouts of the plugin and the previous plugins: the plugin
+// is given at least its timeout after the previous plugins finish. The timeout
+// of a plugin requires the timeout of every previous plugin, otherwise the
+// time waiting for them would be counted.
+func pluginDeadlines(plugins []string, settings map[string]*PluginSettings) (map[string]int64, error) {
+	names := append([]string{}, plugins...)
+	sort.Strings(names)
+	deadlines := map[string]int64{}
+	var deadline int64
+	untimed := []string{}
+	for _, name := range names {
+		s, ok := settings[name]
+		if !ok || s == nil || s.Timeout == 0 {
+			untimed = append(untimed, name)
+			continue
+		}
+		if len(untimed) > 0 {
+			return nil, fmt.Errorf("timeout of plugin %q requires the timeout of the previous plugins it waits for: %s", name, strings.Join(untimed, ", "))
+		}
+		deadline += int64(s.Timeout)
+		deadlines[name] = deadline
+	}
+	return deadlines, nil
+}
+
+// applyPluginSettings sets the deadline and resources to the plugin manifests,
+// returning the environment overrides used by the sonobuoy run config.
+func applyPluginSettings(manifests []*manifest.Manifest, settings map[string]*PluginSettings, timeout int) (map[string]map[string]string, error) {
+	names := make([]string, 0, len(manifests))
+	for _, m := range manifests {
+		names = append(names, m.SonobuoyConfig.PluginName)
+	}
+	deadlines, err := pluginDeadlines(names, settings)
+	if err != nil {
+		return nil, err
+	}
+
+	envOverrides := map[string]map[string]string{}
+	for _, m := range manifests {
+		name := m.SonobuoyConfig.PluginName
+		s, ok := settings[name]
+		if !ok || s == nil {
+			continue
+		}
+		if deadline, ok := deadlines[name]; ok {
+			if timeout > 0 && deadline > int64(timeout) {
+				log.Warnf("Deadline of plugin %s (%ds) is greater than the execution timeout (%ds), the execution timeout is applied", name, deadline, timeout)
+			}
+			if m.PodSpec == nil {
+				m.PodSpec = &manifest.PodSpec{PodSpec: driver.DefaultPodSpec(m.SonobuoyConfig.Driver)}
+			}
+			m.PodSpec.ActiveDeadlineSeconds = &deadline
+		}
+		if s.Resources != nil {
+			setContainerResources(&m.Spec.Container, s.Resources)
+			if m.PodSpec != nil {
+				for i := range m.PodSpec.Containers {
+					setContainerResources(&m.PodSpec.Containers[i], s.Resources)
+				}
+			}
+		}
+		if len(s.Env) > 0 {
+			envOverrides[name] = s.Env
+		}
+	}
+	if len(envOverrides) == 0 {
+		return nil, nil
+	}
+	return envOverrides, nil
+}
+
+// setContainerResources merges the resources into the container, by resource name.
+func setContainerResources(c *v1.Container, resources *v1.ResourceRequirements) {
+	merge := func(dst *v1.ResourceList, src v1.ResourceList) {
+		if len(src) == 0 {
+			return
+		}
+		if *dst == nil {
+			*dst = v1.ResourceList{}
+		}
+		for k, v := range src {
+			(*dst)[k] = v.DeepCopy()
+		}
+	}
+	merge(&c.Resources.Requests, resources.Requests)
+	merge(&c.Resources.Limits, resources.Limits)
+}
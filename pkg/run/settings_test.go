@@ -0,0 +1,164 @@
+package run
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/vmware-tanzu/sonobuoy/pkg/plugin/manifest"
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+
+	"github.com/redhat-openshift-ecosystem/provider-certification-tool/internal/opct/plugin"
+)
+
+func Test_MergePluginSettingsFlags(t *testing.T) {
+	profile := map[string]*PluginSettings{
+		plugin.PluginNameOpenShiftConformance: {
+			Timeout: 3600,
+			Env:     map[string]string{"FROM_PROFILE": "true"},
+		},
+	}
+	flags := &pluginSettingsFlags{
+		timeouts:     map[string]int{plugin.PluginNameOpenShiftConformance: 7200},
+		cpuRequests:  map[string]string{plugin.PluginNameOpenShiftConformance: "2"},
+		memoryLimits: map[string]string{plugin.PluginNameArtifactsCollector: "1Gi"},
+		env:          []string{plugin.PluginNameOpenShiftConformance + ".MY_VAR=a=b"},
+	}
+
+	got, err := mergePluginSettingsFlags(profile, flags)
+	assert.NoError(t, err)
+	conformance := got[plugin.PluginNameOpenShiftConformance]
+	assert.Equal(t, 7200, conformance.Timeout, "flags must take precedence over the profile")
+	assert.Equal(t, map[string]string{"FROM_PROFILE": "true", "MY_VAR": "a=b"}, conformance.Env)
+	assert.Equal(t, resource.MustParse("2"), conformance.Resources.Requests[v1.ResourceCPU])
+	assert.Equal(t, resource.MustParse("1Gi"), got[plugin.PluginNameArtifactsCollector].Resources.Limits[v1.ResourceMemory])
+	assert.Equal(t, 3600, profile[plugin.PluginNameOpenShiftConformance].Timeout, "profile settings must not be changed")
+
+	_, err = mergePluginSettingsFlags(nil, &pluginSettingsFlags{env: []string{"MY_VAR=value"}})
+	assert.Error(t, err)
+	_, err = mergePluginSettingsFlags(nil, &pluginSettingsFlags{cpuLimits: map[string]string{plugin.PluginNameOpenShiftConformance: "two"}})
+	assert.Error(t, err)
+}
+
+func Test_ValidatePluginSettings(t *testing.T) {
+	plugins := []string{plugin.PluginNameKubernetesConformance, plugin.PluginNameOpenShiftConformance}
+	tests := []struct {
+		name     string
+		settings map[string]*PluginSettings
+		wantErr  bool
+	}{{
+		name:     "valid settings",
+		settings: map[string]*PluginSettings{plugin.PluginNameOpenShiftConformance: {Timeout: 3600}},
+	}, {
+		name:     "unknown plugin",
+		settings: map[string]*PluginSettings{"20-openshift-conformance": {Timeout: 3600}},
+		wantErr:  true,
+	}, {
+		name: "request greater than limit",
+		settings: map[string]*PluginSettings{plugin.PluginNameOpenShiftConformance: {Resources: &v1.ResourceRequirements{
+			Requests: v1.ResourceList{v1.ResourceMemory: resource.MustParse("4Gi")},
+			Limits:   v1.ResourceList{v1.ResourceMemory: resource.MustParse("2Gi")},
+		}}},
+		wantErr: true,
+	}}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			err := validatePluginSettings(test.settings, plugins)
+			if test.wantErr {
+				assert.Error(t, err)
+				return
+			}
+			assert.NoError(t, err)
+		})
+	}
+}
+
+func Test_ApplyPluginSettings(t *testing.T) {
+	manifests := []*manifest.Manifest{
+		{SonobuoyConfig: manifest.SonobuoyConfig{PluginName: plugin.PluginNameOpenShiftConformance, Driver: "Job"}},
+		{SonobuoyConfig: manifest.SonobuoyConfig{PluginName: plugin.PluginNameArtifactsCollector, Driver: "Job"}},
+	}
+	manifests[0].PodSpec = &manifest.PodSpec{PodSpec: v1.PodSpec{Containers: []v1.Container{{Name: "tests"}}}}
+	settings := map[string]*PluginSettings{
+		plugin.PluginNameOpenShiftConformance: {
+			Timeout:   3000,
+			Resources: &v1.ResourceRequirements{Limits: v1.ResourceList{v1.ResourceMemory: resource.MustParse("8Gi")}},
+			Env:       map[string]string{"MY_VAR": "value"},
+		},
+		plugin.PluginNameArtifactsCollector: {Timeout: 1800},
+		plugin.PluginNameConformanceReplay:  {Timeout: 600},
+	}
+
+	env, err := applyPluginSettings(manifests, settings, 3600)
+	assert.NoError(t, err)
+
+	assert.Equal(t, map[string]map[string]string{plugin.PluginNameOpenShiftConformance: {"MY_VAR": "value"}}, env, "only selected plugins must be overridden")
+	assert.Equal(t, resource.MustParse("8Gi"), manifests[0].Spec.Resources.Limits[v1.ResourceMemory])
+	assert.Equal(t, resource.MustParse("8Gi"), manifests[0].PodSpec.Containers[0].Resources.Limits[v1.ResourceMemory])
+	assert.Equal(t, int64(3000), *manifests[0].PodSpec.ActiveDeadlineSeconds)
+	// The collector waits for the conformance plugin, the deadline of the
+	// pod includes the timeout of the conformance plugin.
+	assert.Equal(t, int64(4800), *manifests[1].PodSpec.ActiveDeadlineSeconds)
+}
+
+func Test_PluginDeadlines(t *testing.T) {
+	plugins := []string{
+		plugin.PluginNameArtifactsCollector,
+		plugin.PluginNameKubernetesConformance,
+		plugin.PluginNameOpenShiftConformance,
+	}
+	tests := []struct {
+		name     string
+		settings map[string]*PluginSettings
+		want     map[string]int64
+		wantErr  string
+	}{{
+		name: "timeout of every plugin",
+		settings: map[string]*PluginSettings{
+			plugin.PluginNameKubernetesConformance: {Timeout: 7200},
+			plugin.PluginNameOpenShiftConformance:  {Timeout: 32400},
+			plugin.PluginNameArtifactsCollector:    {Timeout: 3600},
+		},
+		want: map[string]int64{
+			plugin.PluginNameKubernetesConformance: 7200,
+			plugin.PluginNameOpenShiftConformance:  39600,
+			plugin.PluginNameArtifactsCollector:    43200,
+		},
+	}, {
+		name: "timeout of the first plugins",
+		settings: map[string]*PluginSettings{
+			plugin.PluginNameKubernetesConformance: {Timeout: 7200},
+			plugin.PluginNameOpenShiftConformance:  {Timeout: 32400},
+		},
+		want: map[string]int64{
+			plugin.PluginNameKubernetesConformance: 7200,
+			plugin.PluginNameOpenShiftConformance:  39600,
+		},
+	}, {
+		name: "blocked plugin without timeout of the previous plugins",
+		settings: map[string]*PluginSettings{
+			plugin.PluginNameOpenShiftConformance: {Timeout: 32400},
+			plugin.PluginNameArtifactsCollector:   {Timeout: 3600},
+		},
+		wantErr: `timeout of plugin "20-openshift-conformance-validated" requires the timeout of the previous plugins it waits for: 10-openshift-kube-conformance`,
+	}, {
+		name: "without timeout",
+		settings: map[string]*PluginSettings{
+			plugin.PluginNameOpenShiftConformance: {Env: map[string]string{"MY_VAR": "value"}},
+		},
+		want: map[string]int64{},
+	}}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			got, err := pluginDeadlines(plugins, test.settings)
+			if test.wantErr != "" {
+				assert.EqualError(t, err, test.wantErr)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, test.want, got)
+		})
+	}
+}
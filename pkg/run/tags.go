@@ -0,0 +1,43 @@
+package run
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+
+	"github.com/redhat-openshift-ecosystem/provider-certification-tool/internal/opct/archive"
+)
+
+// tagKeyMaxLength is the maximum length of the tag key, keeping the
+// ConfigMap key (prefixed) below the Kubernetes limit.
+const tagKeyMaxLength = 63
+
+var tagKeyRegex = regexp.MustCompile(`^[A-Za-z0-9]([-_.A-Za-z0-9]*[A-Za-z0-9])?$`)
+
+// validateTags checks the user-defined run tags, the keys must be valid
+// ConfigMap keys.
+func validateTags(tags map[string]string) error {
+	keys := make([]string, 0, len(tags))
+	for k := range tags {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		if len(k) > tagKeyMaxLength || !tagKeyRegex.MatchString(k) {
+			return fmt.Errorf("invalid tag key %q, must have up to %d alphanumeric characters, '-', '_' or '.'", k, tagKeyMaxLength)
+		}
+		if tags[k] == "" {
+			return fmt.Errorf("invalid tag %q, the value must not be empty", k)
+		}
+	}
+	return nil
+}
+
+// tagsConfigMapData returns the tags as keys of the plugins-config ConfigMap.
+func tagsConfigMapData(tags map[string]string) map[string]string {
+	data := make(map[string]string, len(tags))
+	for k, v := range tags {
+		data[archive.RunTagKeyPrefix+k] = v
+	}
+	return data
+}
@@ -0,0 +1,42 @@
+package run
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_ValidateTags(t *testing.T) {
+	tests := []struct {
+		name    string
+		tags    map[string]string
+		wantErr bool
+	}{{
+		name: "valid tags",
+		tags: map[string]string{"customer": "acme", "hw.gen": "3", "lab_site": "rdu-2"},
+	}, {
+		name:    "invalid key",
+		tags:    map[string]string{"customer name": "acme"},
+		wantErr: true,
+	}, {
+		name:    "empty value",
+		tags:    map[string]string{"customer": ""},
+		wantErr: true,
+	}}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			err := validateTags(test.tags)
+			if test.wantErr {
+				assert.Error(t, err)
+				return
+			}
+			assert.NoError(t, err)
+		})
+	}
+}
+
+func Test_TagsConfigMapData(t *testing.T) {
+	got := tagsConfigMapData(map[string]string{"customer": "acme", "hw": "gen3"})
+	assert.Equal(t, map[string]string{"tag.customer": "acme", "tag.hw": "gen3"}, got)
+}
@@ -0,0 +1,105 @@
+package run
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+
+	efs "github.com/redhat-openshift-ecosystem/provider-certification-tool/internal/assets"
+	"github.com/redhat-openshift-ecosystem/provider-certification-tool/internal/opct/plugin"
+	"github.com/vmware-tanzu/sonobuoy/pkg/plugin/loader"
+	"github.com/vmware-tanzu/sonobuoy/pkg/plugin/manifest"
+)
+
+const (
+	// testsConfigMapKey is the key in the plugins-tests ConfigMap storing the
+	// list of tests, mounted by the replay plugin.
+	testsConfigMapKey = "tests.txt"
+
+	// testsFileMaxSize is the maximum size of the test list, limited by the
+	// size of the ConfigMap.
+	testsFileMaxSize = 1024 * 1024
+
+	// testsPluginManifest is the replay plugin running the tests from the
+	// list instead of the failures of the previous plugins.
+	testsPluginManifest = "data/templates/tests/openshift-tests-replay.yaml"
+)
+
+// loadTestsFile reads the list of e2e tests to run, one test name by line,
+// quoted or not, as printed by 'openshift-tests run --dry-run'.
+// Empty lines, comments (#) and duplicated tests are ignored.
+func loadTestsFile(path string) ([]string, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("unable to read tests file %q: %w", path, err)
+	}
+	if len(data) > testsFileMaxSize {
+		return nil, fmt.Errorf("tests file %q is too large (%d bytes), the limit is %d bytes", path, len(data), testsFileMaxSize)
+	}
+
+	tests := []string{}
+	seen := map[string]struct{}{}
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	scanner.Buffer(make([]byte, 0, 64*1024), testsFileMaxSize)
+	for n := 1; scanner.Scan(); n++ {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		if strings.HasPrefix(line, `"`) {
+			name, err := strconv.Unquote(line)
+			if err != nil {
+				return nil, fmt.Errorf("invalid test name at line %d of tests file %q: %w", n, path, err)
+			}
+			line = name
+		}
+		if strings.ContainsAny(line, "\r\n") {
+			return nil, fmt.Errorf("invalid test name at line %d of tests file %q: multi-line names are not supported", n, path)
+		}
+		if _, ok := seen[line]; ok {
+			continue
+		}
+		seen[line] = struct{}{}
+		tests = append(tests, line)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("unable to parse tests file %q: %w", path, err)
+	}
+	if len(tests) == 0 {
+		return nil, fmt.Errorf("tests file %q does not have any test", path)
+	}
+	return tests, nil
+}
+
+// loadTestsManifest renders the replay plugin running the tests from the
+// ConfigMap plugins-tests.
+func loadTestsManifest(r *RunOptions) (*manifest.Manifest, error) {
+	tpl, err := efs.GetData().ReadFile(testsPluginManifest)
+	if err != nil {
+		return nil, fmt.Errorf("error reading plugin %s: %w", testsPluginManifest, err)
+	}
+	data, err := ProcessManifestTemplates(r, tpl)
+	if err != nil {
+		return nil, fmt.Errorf("error processing plugin %s: %w", testsPluginManifest, err)
+	}
+	asset, err := loader.LoadDefinition(data)
+	if err != nil {
+		return nil, fmt.Errorf("error loading plugin %s: %w", testsPluginManifest, err)
+	}
+	return &asset, nil
+}
+
+// replaceReplayManifest replaces the default replay plugin by the plugin
+// running the tests from the list.
+func replaceReplayManifest(manifests []*manifest.Manifest, tests *manifest.Manifest) error {
+	for i, m := range manifests {
+		if m.SonobuoyConfig.PluginName == plugin.PluginNameConformanceReplay {
+			manifests[i] = tests
+			return nil
+		}
+	}
+	return fmt.Errorf("plugin %q is not available to run the tests from --tests-file", plugin.PluginNameConformanceReplay)
+}
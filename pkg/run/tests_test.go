@@ -0,0 +1,82 @@
+package run
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/vmware-tanzu/sonobuoy/pkg/plugin/loader"
+
+	"github.com/redhat-openshift-ecosystem/provider-certification-tool/internal/opct/plugin"
+	"github.com/redhat-openshift-ecosystem/provider-certification-tool/pkg"
+)
+
+func Test_LoadTestsFile(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+		want    []string
+		wantErr bool
+	}{{
+		name:    "ignore comments, empty lines and duplicates",
+		content: "# failures from run 1\n[sig-network] test A\n\n[sig-storage] test B\n[sig-network] test A\n",
+		want:    []string{"[sig-network] test A", "[sig-storage] test B"},
+	}, {
+		name:    "quoted names from dry-run",
+		content: "\"[sig-network] test A [Suite:openshift/conformance/parallel]\"\n[sig-network] test A [Suite:openshift/conformance/parallel]\n",
+		want:    []string{"[sig-network] test A [Suite:openshift/conformance/parallel]"},
+	}, {
+		name:    "invalid quoted name",
+		content: "\"[sig-network] test A\n",
+		wantErr: true,
+	}, {
+		name:    "multi-line name",
+		content: "\"[sig-network] test\\nA\"\n",
+		wantErr: true,
+	}, {
+		name:    "empty list",
+		content: "# nothing\n\n",
+		wantErr: true,
+	}}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "tests.txt")
+			assert.NoError(t, os.WriteFile(path, []byte(test.content), 0644))
+			got, err := loadTestsFile(path)
+			if test.wantErr {
+				assert.Error(t, err)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, test.want, got)
+		})
+	}
+}
+
+func Test_TestsManifest(t *testing.T) {
+	tpl, err := os.ReadFile(filepath.Join("../..", testsPluginManifest))
+	assert.NoError(t, err)
+	data, err := ProcessManifestTemplates(&RunOptions{
+		PluginsImage:        "quay.io/opct/plugin-openshift-tests:v0",
+		OpenshiftTestsImage: "quay.io/openshift/tests:v0",
+	}, tpl)
+	assert.NoError(t, err)
+	m, err := loader.LoadDefinition(data)
+	assert.NoError(t, err)
+
+	// The plugin replaces the default replay plugin, mounting the test list.
+	assert.Equal(t, plugin.PluginNameConformanceReplay, m.SonobuoyConfig.PluginName)
+	assert.Equal(t, pkg.PluginsTestsConfigMapName, m.PodSpec.Volumes[1].ConfigMap.Name)
+	tests := m.PodSpec.Containers[0]
+	assert.Equal(t, "quay.io/openshift/tests:v0", tests.Image)
+	assert.Equal(t, "/tmp/opct/tests", tests.VolumeMounts[1].MountPath)
+	assert.Equal(t, "TESTS_FILE", tests.Env[1].Name)
+	assert.Equal(t, "/tmp/opct/tests/"+testsConfigMapKey, tests.Env[1].Value)
+
+	manifests := newTestManifests()
+	assert.NoError(t, replaceReplayManifest(manifests, &m))
+	assert.Same(t, &m, manifests[2])
+	assert.Error(t, replaceReplayManifest(manifests[:2], &m))
+}
@@ -0,0 +1,108 @@
+// Package runid handles the unique ID generated when the validation
+// environment is created, correlating the cluster objects, the logs, the
+// results archive and the published baselines of a run.
+package runid
+
+import (
+	"context"
+	"sync"
+
+	"github.com/google/uuid"
+	log "github.com/sirupsen/logrus"
+	kerrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+
+	"github.com/redhat-openshift-ecosystem/provider-certification-tool/pkg"
+)
+
+const (
+	// Label is set to the objects created by the run. Pods created by
+	// Sonobuoy, which does not support custom labels, have it as annotation.
+	Label = "opct.redhat.com/run-id"
+
+	// ConfigMapKey is the key of the run ID in the ConfigMap opct-version.
+	ConfigMapKey = "run-id"
+
+	// LogField is the field of the run ID in the log entries.
+	LogField = "run_id"
+)
+
+// New returns a new run ID.
+func New() string {
+	return uuid.NewString()
+}
+
+// Get returns the run ID of the validation environment, or empty when the
+// environment was created by a version without run ID.
+func Get(ctx context.Context, kclient kubernetes.Interface) (string, error) {
+	cm, err := kclient.CoreV1().ConfigMaps(pkg.GetNamespace()).Get(ctx, pkg.VersionInfoConfigMapName, metav1.GetOptions{})
+	if err != nil {
+		if kerrors.IsNotFound(err) {
+			return "", nil
+		}
+		return "", err
+	}
+	return cm.Data[ConfigMapKey], nil
+}
+
+// Labels returns the labels of the objects created by the run, with the run ID.
+func Labels(id string) map[string]string {
+	labels := pkg.GetSonobuoyDefaultLabels()
+	if id != "" {
+		labels[Label] = id
+	}
+	return labels
+}
+
+var (
+	// logHook is installed once in the global logger, commands reading the
+	// run ID more than once, e.g. pipeline, update the ID it adds.
+	logHook     = &hook{}
+	logHookOnce sync.Once
+)
+
+// SetLogger adds the run ID to every log entry, including the opct.log file.
+func SetLogger(id string) {
+	if id == "" {
+		return
+	}
+	logHook.set(id)
+	logHookOnce.Do(func() {
+		log.AddHook(logHook)
+	})
+}
+
+// SetLoggerFromCluster reads the run ID of the validation environment and adds
+// it to the log entries. Failures are not blocking, the logs are kept without it.
+func SetLoggerFromCluster(ctx context.Context, kclient kubernetes.Interface) string {
+	id, err := Get(ctx, kclient)
+	if err != nil {
+		log.Debugf("unable to read the run ID: %v", err)
+		return ""
+	}
+	SetLogger(id)
+	return id
+}
+
+type hook struct {
+	mu sync.RWMutex
+	id string
+}
+
+func (h *hook) set(id string) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.id = id
+}
+
+func (h *hook) Levels() []log.Level {
+	return log.AllLevels
+}
+
+func (h *hook) Fire(e *log.Entry) error {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+	e.Data[LogField] = h.id
+	return nil
+}
@@ -0,0 +1,96 @@
+package runid
+
+import (
+	"bytes"
+	"context"
+	"testing"
+
+	"github.com/google/uuid"
+	log "github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/kubernetes/fake"
+
+	"github.com/redhat-openshift-ecosystem/provider-certification-tool/pkg"
+)
+
+func TestNew(t *testing.T) {
+	id := New()
+	_, err := uuid.Parse(id)
+	assert.NoError(t, err)
+	assert.NotEqual(t, id, New())
+}
+
+func TestGet(t *testing.T) {
+	tests := []struct {
+		name    string
+		objects []runtime.Object
+		want    string
+	}{
+		{
+			name:    "environment not found",
+			objects: []runtime.Object{},
+			want:    "",
+		},
+		{
+			name: "environment created without run ID",
+			objects: []runtime.Object{&v1.ConfigMap{
+				ObjectMeta: metav1.ObjectMeta{Name: pkg.VersionInfoConfigMapName, Namespace: pkg.GetNamespace()},
+				Data:       map[string]string{"cli-version": "v0.5.0"},
+			}},
+			want: "",
+		},
+		{
+			name: "environment with run ID",
+			objects: []runtime.Object{&v1.ConfigMap{
+				ObjectMeta: metav1.ObjectMeta{Name: pkg.VersionInfoConfigMapName, Namespace: pkg.GetNamespace()},
+				Data:       map[string]string{ConfigMapKey: "6f1c3a52-2f0e-4a8e-9d5b-0b9e6c2a1f00"},
+			}},
+			want: "6f1c3a52-2f0e-4a8e-9d5b-0b9e6c2a1f00",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := Get(context.TODO(), fake.NewSimpleClientset(tt.objects...))
+			assert.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestLabels(t *testing.T) {
+	assert.Equal(t, "id", Labels("id")[Label])
+	assert.Equal(t, pkg.GetNamespace(), Labels("id")[pkg.SonobuoyLabelNamespaceName])
+	assert.NotContains(t, Labels(""), Label)
+}
+
+func TestHook(t *testing.T) {
+	buf := &bytes.Buffer{}
+	logger := log.New()
+	logger.SetOutput(buf)
+	logger.AddHook(&hook{id: "id"})
+	logger.Info("message")
+	assert.Contains(t, buf.String(), LogField+"=id")
+}
+
+func TestSetLogger(t *testing.T) {
+	SetLogger("first")
+	SetLogger("second")
+
+	hooks := 0
+	for _, h := range log.StandardLogger().Hooks[log.InfoLevel] {
+		if _, ok := h.(*hook); ok {
+			hooks++
+		}
+	}
+	assert.Equal(t, 1, hooks, "the hook must be installed once")
+
+	buf := &bytes.Buffer{}
+	out := log.StandardLogger().Out
+	defer log.SetOutput(out)
+	log.SetOutput(buf)
+	log.Info("message")
+	assert.Contains(t, buf.String(), LogField+"=second")
+}
@@ -0,0 +1,218 @@
+package status
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	coclient "github.com/openshift/client-go/config/clientset/versioned"
+	log "github.com/sirupsen/logrus"
+	"github.com/vmware-tanzu/sonobuoy/pkg/plugin/aggregation"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"github.com/redhat-openshift-ecosystem/provider-certification-tool/internal/report/baseline"
+	"github.com/redhat-openshift-ecosystem/provider-certification-tool/pkg/client"
+)
+
+const (
+	// OverdueFactor is the ratio of the expected time, from the start of the
+	// execution to the end of the plugin, to warn a plugin running longer
+	// than the baseline.
+	OverdueFactor = 1.5
+
+	// reportSummaryFile is the report summary saved by 'opct report --save-to'.
+	reportSummaryFile = "opct-report-summary.json"
+)
+
+// PluginTimings are the expected execution time of each plugin.
+type PluginTimings map[string]time.Duration
+
+// ETA is the estimated remaining time of the execution.
+type ETA struct {
+	// Remaining is the remaining time of the plugins with known timings.
+	Remaining time.Duration
+
+	Plugins map[string]*PluginETA
+}
+
+// PluginETA is the estimated remaining time of a plugin.
+type PluginETA struct {
+	// Expected is the execution time of the plugin in the baseline.
+	Expected time.Duration
+
+	// Remaining is the estimated time to the plugin finish.
+	Remaining time.Duration
+
+	// Overdue is set when the plugin is running longer than the baseline by
+	// the OverdueFactor.
+	Overdue bool
+
+	// Deadline is the expected time, from the start of the execution, the
+	// plugin finishes.
+	Deadline time.Duration
+}
+
+// EstimateETA estimates the remaining time of each plugin from the timings.
+// Plugins run sequentially in the name order, the active plugin is the first
+// not completed, and its remaining time is estimated by the progress of the
+// tests, when reported, or by the expected time from the start of the
+// execution. Plugins without timings are not estimated.
+func EstimateETA(s *aggregation.Status, timings PluginTimings, start, now time.Time) *ETA {
+	if s == nil || len(timings) == 0 {
+		return nil
+	}
+	plugins := make([]aggregation.PluginStatus, len(s.Plugins))
+	copy(plugins, s.Plugins)
+	sort.Slice(plugins, func(i, j int) bool {
+		return plugins[i].Plugin < plugins[j].Plugin
+	})
+
+	eta := &ETA{Plugins: map[string]*PluginETA{}}
+	elapsed := now.Sub(start)
+	var deadline time.Duration
+	active := true
+	for _, pl := range plugins {
+		expected, ok := timings[pl.Plugin]
+		if !ok {
+			continue
+		}
+		deadline += expected
+		pe := &PluginETA{Expected: expected, Deadline: deadline}
+		eta.Plugins[pl.Plugin] = pe
+		switch {
+		case pluginDone(pl):
+		case active:
+			active = false
+			pe.Remaining = deadline - elapsed
+			if p := pl.Progress; p != nil && p.Total > 0 && p.Completed > 0 {
+				pe.Remaining = time.Duration(float64(expected) * float64(p.Total-p.Completed) / float64(p.Total))
+			}
+			if pe.Remaining < 0 {
+				pe.Remaining = 0
+			}
+			pe.Overdue = float64(elapsed) > float64(deadline)*OverdueFactor
+		default:
+			pe.Remaining = expected
+		}
+		eta.Remaining += pe.Remaining
+	}
+	return eta
+}
+
+// pluginDone returns true when the plugin finished, the result is processed
+// or the plugin failed.
+func pluginDone(pl aggregation.PluginStatus) bool {
+	return pl.ResultStatus != "" || pl.Status == aggregation.CompleteStatus || pl.Status == aggregation.FailedStatus
+}
+
+// FormatETA returns the duration rounded to minutes, as shown in the status.
+func FormatETA(d time.Duration) string {
+	if d < time.Minute {
+		return "<1m"
+	}
+	return strings.TrimSuffix(d.Round(time.Minute).String(), "0s")
+}
+
+// LoadTimingsFromBaseline reads the plugin timings from the latest baseline
+// of the OpenShift release and platform type.
+func LoadTimingsFromBaseline(release, platform string) (PluginTimings, error) {
+	brs := baseline.NewBaselineReportSummary()
+	if err := brs.GetLatestRawSummaryFromPlatformWithFallback(release, platform); err != nil {
+		return nil, err
+	}
+	bd := brs.GetBuffer()
+	if bd == nil {
+		return nil, fmt.Errorf("baseline not found for release %s and platform %s", release, platform)
+	}
+	return bd.GetPluginRuntimes()
+}
+
+// LoadTimingsFromHistory reads the plugin timings from the report summaries
+// saved by 'opct report --save-to'. The path is a report summary file, or a
+// directory searched for them. Reports of other releases or platforms are
+// ignored, the timings are the average of the reports.
+func LoadTimingsFromHistory(path, release, platform string) (PluginTimings, error) {
+	files := []string{}
+	err := filepath.WalkDir(path, func(p string, d os.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if !d.IsDir() && (p == path || d.Name() == reportSummaryFile) {
+			files = append(files, p)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("unable to read the report history: %w", err)
+	}
+
+	totals := map[string]time.Duration{}
+	counts := map[string]int{}
+	for _, file := range files {
+		data, err := os.ReadFile(file)
+		if err != nil {
+			return nil, err
+		}
+		bd := &baseline.BaselineData{}
+		bd.SetRawData(data)
+		r, p, err := bd.GetSetupPlatform()
+		if err != nil {
+			log.Warnf("Ignoring report %s: %v", file, err)
+			continue
+		}
+		if (r != "" && release != "" && r != release) || (p != "" && platform != "" && p != platform) {
+			log.Debugf("Ignoring report %s of release %s and platform %s", file, r, p)
+			continue
+		}
+		runtimes, err := bd.GetPluginRuntimes()
+		if err != nil {
+			log.Warnf("Ignoring report %s: %v", file, err)
+			continue
+		}
+		for name, d := range runtimes {
+			totals[name] += d
+			counts[name]++
+		}
+	}
+	if len(totals) == 0 {
+		return nil, fmt.Errorf("no report found in %s for release %s and platform %s", path, release, platform)
+	}
+	timings := PluginTimings{}
+	for name, total := range totals {
+		timings[name] = total / time.Duration(counts[name])
+	}
+	return timings, nil
+}
+
+// ClusterPlatform returns the OpenShift release (X.Y) and the platform type
+// of the cluster, used to select the baseline timings.
+func ClusterPlatform(ctx context.Context) (release, platform string, err error) {
+	restConfig, err := client.CreateRestConfig()
+	if err != nil {
+		return "", "", err
+	}
+	oc, err := coclient.NewForConfig(restConfig)
+	if err != nil {
+		return "", "", err
+	}
+	cv, err := oc.ConfigV1().ClusterVersions().Get(ctx, "version", metav1.GetOptions{})
+	if err != nil {
+		return "", "", fmt.Errorf("unable to get the cluster version: %w", err)
+	}
+	parts := strings.Split(cv.Status.Desired.Version, ".")
+	if len(parts) < 2 {
+		return "", "", fmt.Errorf("unable to parse the cluster version %q", cv.Status.Desired.Version)
+	}
+	infra, err := oc.ConfigV1().Infrastructures().Get(ctx, "cluster", metav1.GetOptions{})
+	if err != nil {
+		return "", "", fmt.Errorf("unable to get the cluster infrastructure: %w", err)
+	}
+	if infra.Status.PlatformStatus != nil {
+		platform = string(infra.Status.PlatformStatus.Type)
+	}
+	return fmt.Sprintf("%s.%s", parts[0], parts[1]), platform, nil
+}
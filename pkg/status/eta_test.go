@@ -0,0 +1,175 @@
+package status
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/vmware-tanzu/sonobuoy/pkg/plugin"
+	"github.com/vmware-tanzu/sonobuoy/pkg/plugin/aggregation"
+)
+
+func Test_EstimateETA(t *testing.T) {
+	start := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	timings := PluginTimings{
+		"05-openshift-cluster-upgrade":       10 * time.Minute,
+		"10-openshift-kube-conformance":      60 * time.Minute,
+		"20-openshift-conformance-validated": 120 * time.Minute,
+	}
+	newStatus := func(plugins ...aggregation.PluginStatus) *aggregation.Status {
+		return &aggregation.Status{Status: aggregation.RunningStatus, Plugins: plugins}
+	}
+	done := func(name string) aggregation.PluginStatus {
+		return aggregation.PluginStatus{Plugin: name, Status: aggregation.CompleteStatus, ResultStatus: "passed"}
+	}
+	running := func(name string, completed, total int64) aggregation.PluginStatus {
+		return aggregation.PluginStatus{Plugin: name, Status: aggregation.RunningStatus, Progress: &plugin.ProgressUpdate{Completed: completed, Total: total}}
+	}
+
+	tests := []struct {
+		name          string
+		status        *aggregation.Status
+		timings       PluginTimings
+		elapsed       time.Duration
+		wantNil       bool
+		wantRemaining time.Duration
+		wantPlugins   map[string]time.Duration
+		wantOverdue   string
+	}{
+		{
+			name:    "timings not loaded",
+			status:  newStatus(running("05-openshift-cluster-upgrade", 0, 0)),
+			wantNil: true,
+		},
+		{
+			name: "first plugin running, estimated by the start time",
+			status: newStatus(
+				running("05-openshift-cluster-upgrade", 0, 0),
+				running("10-openshift-kube-conformance", 0, 0),
+				running("20-openshift-conformance-validated", 0, 0),
+			),
+			timings:       timings,
+			elapsed:       4 * time.Minute,
+			wantRemaining: 186 * time.Minute,
+			wantPlugins: map[string]time.Duration{
+				"05-openshift-cluster-upgrade":       6 * time.Minute,
+				"10-openshift-kube-conformance":      60 * time.Minute,
+				"20-openshift-conformance-validated": 120 * time.Minute,
+			},
+		},
+		{
+			name: "active plugin estimated by the progress",
+			status: newStatus(
+				done("05-openshift-cluster-upgrade"),
+				running("10-openshift-kube-conformance", 300, 400),
+				running("20-openshift-conformance-validated", 0, 0),
+			),
+			timings:       timings,
+			elapsed:       40 * time.Minute,
+			wantRemaining: 135 * time.Minute,
+			wantPlugins: map[string]time.Duration{
+				"05-openshift-cluster-upgrade":       0,
+				"10-openshift-kube-conformance":      15 * time.Minute,
+				"20-openshift-conformance-validated": 120 * time.Minute,
+			},
+		},
+		{
+			name: "active plugin running far longer than the baseline",
+			status: newStatus(
+				done("05-openshift-cluster-upgrade"),
+				running("10-openshift-kube-conformance", 0, 0),
+				running("20-openshift-conformance-validated", 0, 0),
+			),
+			timings:       timings,
+			elapsed:       120 * time.Minute,
+			wantRemaining: 120 * time.Minute,
+			wantPlugins: map[string]time.Duration{
+				"05-openshift-cluster-upgrade":       0,
+				"10-openshift-kube-conformance":      0,
+				"20-openshift-conformance-validated": 120 * time.Minute,
+			},
+			wantOverdue: "10-openshift-kube-conformance",
+		},
+		{
+			name: "plugins without timings are not estimated",
+			status: newStatus(
+				running("05-openshift-cluster-upgrade", 0, 0),
+				running("80-openshift-tests-replay", 0, 0),
+			),
+			timings:       timings,
+			elapsed:       0,
+			wantRemaining: 10 * time.Minute,
+			wantPlugins: map[string]time.Duration{
+				"05-openshift-cluster-upgrade": 10 * time.Minute,
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			eta := EstimateETA(tt.status, tt.timings, start, start.Add(tt.elapsed))
+			if tt.wantNil {
+				assert.Nil(t, eta)
+				return
+			}
+			require.NotNil(t, eta)
+			assert.Equal(t, tt.wantRemaining, eta.Remaining)
+			require.Len(t, eta.Plugins, len(tt.wantPlugins))
+			for name, want := range tt.wantPlugins {
+				assert.Equal(t, want, eta.Plugins[name].Remaining, name)
+				assert.Equal(t, name == tt.wantOverdue, eta.Plugins[name].Overdue, name)
+			}
+		})
+	}
+}
+
+func Test_FormatETA(t *testing.T) {
+	assert.Equal(t, "<1m", FormatETA(30*time.Second))
+	assert.Equal(t, "5m", FormatETA(5*time.Minute+10*time.Second))
+	assert.Equal(t, "2h5m", FormatETA(125*time.Minute))
+}
+
+func Test_LoadTimingsFromHistory(t *testing.T) {
+	dir := t.TempDir()
+	reports := map[string]string{
+		"run1": `{"summary":{"runtime":{"plugins":{"10-openshift-kube-conformance":"1h0m0s","20-openshift-conformance-validated":"2h0m0s"}}},"setup":{"api":{"openshiftRelease":"4.15","platformType":"AWS"}}}`,
+		"run2": `{"summary":{"runtime":{"plugins":{"10-openshift-kube-conformance":"2h0m0s","20-openshift-conformance-validated":"invalid"}}},"setup":{"api":{"openshiftRelease":"4.15","platformType":"AWS"}}}`,
+		"run3": `{"summary":{"runtime":{"plugins":{"10-openshift-kube-conformance":"10h0m0s"}}},"setup":{"api":{"openshiftRelease":"4.14","platformType":"AWS"}}}`,
+	}
+	for name, data := range reports {
+		require.NoError(t, os.MkdirAll(filepath.Join(dir, name), 0755))
+		require.NoError(t, os.WriteFile(filepath.Join(dir, name, reportSummaryFile), []byte(data), 0644))
+	}
+
+	timings, err := LoadTimingsFromHistory(dir, "4.15", "AWS")
+	require.NoError(t, err)
+	assert.Equal(t, PluginTimings{
+		"10-openshift-kube-conformance":      90 * time.Minute,
+		"20-openshift-conformance-validated": 120 * time.Minute,
+	}, timings)
+
+	timings, err = LoadTimingsFromHistory(filepath.Join(dir, "run3", reportSummaryFile), "4.14", "AWS")
+	require.NoError(t, err)
+	assert.Equal(t, PluginTimings{"10-openshift-kube-conformance": 10 * time.Hour}, timings)
+
+	_, err = LoadTimingsFromHistory(dir, "4.16", "AWS")
+	assert.Error(t, err)
+}
+
+func Test_PrintStatusETA(t *testing.T) {
+	start := time.Now().Add(-4 * time.Minute)
+	a := &aggregation.Status{
+		Status:  aggregation.RunningStatus,
+		Plugins: []aggregation.PluginStatus{{Plugin: "05-openshift-cluster-upgrade", Status: aggregation.RunningStatus}},
+	}
+	ps := getPrintableRunningStatus(a, start)
+	ps.setETA(EstimateETA(a, PluginTimings{"05-openshift-cluster-upgrade": 10 * time.Minute}, start, time.Now()))
+
+	buf := &bytes.Buffer{}
+	require.NoError(t, printStatus(buf, ps))
+	assert.Contains(t, buf.String(), "(ETA: 6m)")
+	assert.Contains(t, buf.String(), "| ETA ")
+}
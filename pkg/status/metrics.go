@@ -0,0 +1,203 @@
+package status
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+
+	"github.com/redhat-openshift-ecosystem/provider-certification-tool/pkg"
+)
+
+const (
+	metricsPath        = "/metrics"
+	metricsContentType = "text/plain; version=0.0.4; charset=utf-8"
+)
+
+// MetricsServer exposes the status of the execution in the Prometheus text
+// exposition format, updated on every watch interval.
+type MetricsServer struct {
+	addr     string
+	server   *http.Server
+	listener net.Listener
+
+	mu     sync.RWMutex
+	report *StatusReport
+}
+
+// NewMetricsServer creates the metrics server listening on the address.
+func NewMetricsServer(addr string) *MetricsServer {
+	ms := &MetricsServer{addr: addr}
+	mux := http.NewServeMux()
+	mux.HandleFunc(metricsPath, ms.handleMetrics)
+	ms.server = &http.Server{
+		Handler:           mux,
+		ReadHeaderTimeout: 10 * time.Second,
+	}
+	return ms
+}
+
+// Start listens on the address and serves the metrics in background.
+func (ms *MetricsServer) Start() error {
+	listener, err := net.Listen("tcp", ms.addr)
+	if err != nil {
+		return fmt.Errorf("unable to serve the metrics on %s: %w", ms.addr, err)
+	}
+	ms.listener = listener
+	go func() {
+		if err := ms.server.Serve(listener); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			log.Errorf("metrics server finished with errors: %v", err)
+		}
+	}()
+	log.Infof("Serving the status metrics on http://%s%s", listener.Addr(), metricsPath)
+	return nil
+}
+
+// Addr returns the address the server is listening on.
+func (ms *MetricsServer) Addr() string {
+	if ms.listener == nil {
+		return ms.addr
+	}
+	return ms.listener.Addr().String()
+}
+
+// Shutdown stops the server.
+func (ms *MetricsServer) Shutdown(ctx context.Context) error {
+	return ms.server.Shutdown(ctx)
+}
+
+// Update sets the status exposed by the server.
+func (ms *MetricsServer) Update(sr *StatusReport) {
+	ms.mu.Lock()
+	defer ms.mu.Unlock()
+	ms.report = sr
+}
+
+func (ms *MetricsServer) handleMetrics(w http.ResponseWriter, r *http.Request) {
+	ms.mu.RLock()
+	sr := ms.report
+	ms.mu.RUnlock()
+
+	buf := &bytes.Buffer{}
+	WriteMetrics(buf, sr)
+	w.Header().Set("Content-Type", metricsContentType)
+	_, _ = w.Write(buf.Bytes())
+}
+
+// metricWriter writes the metric families in the text exposition format.
+type metricWriter struct {
+	w      io.Writer
+	labels []string
+}
+
+func (mw *metricWriter) family(name, help, kind string) {
+	fmt.Fprintf(mw.w, "# HELP %s %s\n# TYPE %s %s\n", name, help, name, kind)
+}
+
+// sample writes a sample with the run labels followed by the labels, in
+// name/value pairs.
+func (mw *metricWriter) sample(name string, value float64, labels ...string) {
+	pairs := append(append([]string{}, mw.labels...), labels...)
+	items := make([]string, 0, len(pairs)/2)
+	for i := 0; i+1 < len(pairs); i += 2 {
+		items = append(items, fmt.Sprintf("%s=\"%s\"", pairs[i], escapeLabelValue(pairs[i+1])))
+	}
+	fmt.Fprintf(mw.w, "%s{%s} %v\n", name, strings.Join(items, ","), value)
+}
+
+var labelValueEscaper = strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`)
+
+func escapeLabelValue(v string) string {
+	return labelValueEscaper.Replace(v)
+}
+
+// WriteMetrics writes the status in the Prometheus text exposition format.
+// The samples are labeled by the run ID and the namespace, allowing many
+// validation environments to be scraped by the same Prometheus.
+func WriteMetrics(w io.Writer, sr *StatusReport) {
+	mw := &metricWriter{w: w}
+	mw.family("opct_up", "Whether the status of the validation environment has been collected.", "gauge")
+	if sr == nil {
+		mw.sample("opct_up", 0, "namespace", pkg.GetNamespace())
+		return
+	}
+	mw.labels = []string{"run_id", sr.RunID, "namespace", pkg.GetNamespace()}
+	mw.sample("opct_up", 1)
+
+	mw.family("opct_run_info", "Information of the validation run.", "gauge")
+	mw.sample("opct_run_info", 1, "aggregator_status", sr.AggregatorStatus)
+
+	mw.family("opct_run_state", "State of the execution derived from the aggregator status, 1 for the current state.", "gauge")
+	for _, state := range []string{StateRunning, StatePostProcessing, StateComplete, StateFailed, StateUnknown} {
+		value := 0.0
+		if sr.State == state {
+			value = 1
+		}
+		mw.sample("opct_run_state", value, "state", state)
+	}
+
+	mw.family("opct_run_start_time_seconds", "Start time of the execution since unix epoch in seconds.", "gauge")
+	mw.sample("opct_run_start_time_seconds", float64(sr.StartTime.Unix()))
+
+	mw.family("opct_run_elapsed_seconds", "Elapsed time of the execution in seconds.", "gauge")
+	mw.sample("opct_run_elapsed_seconds", float64(sr.ElapsedSeconds))
+
+	if sr.RemainingSeconds != nil {
+		mw.family("opct_run_remaining_seconds", "Estimated remaining time of the execution in seconds.", "gauge")
+		mw.sample("opct_run_remaining_seconds", float64(*sr.RemainingSeconds))
+	}
+
+	mw.family("opct_plugin_status", "Status of the plugin, 1 for the current status.", "gauge")
+	for _, pl := range sr.Plugins {
+		mw.sample("opct_plugin_status", 1, "plugin", pl.Name, "status", pl.Status, "result", pl.ResultStatus)
+	}
+
+	mw.family("opct_plugin_tests_completed", "Tests completed by the plugin, from the progress updates.", "gauge")
+	for _, pl := range sr.Plugins {
+		mw.sample("opct_plugin_tests_completed", float64(pl.Completed), "plugin", pl.Name)
+	}
+
+	mw.family("opct_plugin_tests_total", "Tests to be executed by the plugin, from the progress updates.", "gauge")
+	for _, pl := range sr.Plugins {
+		mw.sample("opct_plugin_tests_total", float64(pl.Total), "plugin", pl.Name)
+	}
+
+	mw.family("opct_plugin_tests_failures", "Tests failed so far by the plugin, from the progress updates.", "gauge")
+	for _, pl := range sr.Plugins {
+		mw.sample("opct_plugin_tests_failures", float64(pl.Failures), "plugin", pl.Name)
+	}
+
+	mw.family("opct_plugin_results", "Tests processed by result, when the plugin results are processed.", "gauge")
+	for _, pl := range sr.Plugins {
+		results := make([]string, 0, len(pl.ResultCounts))
+		for result := range pl.ResultCounts {
+			results = append(results, result)
+		}
+		sort.Strings(results)
+		for _, result := range results {
+			mw.sample("opct_plugin_results", float64(pl.ResultCounts[result]), "plugin", pl.Name, "result", result)
+		}
+	}
+
+	var remaining []PluginStatusReport
+	for _, pl := range sr.Plugins {
+		if pl.RemainingSeconds != nil {
+			remaining = append(remaining, pl)
+		}
+	}
+	if len(remaining) > 0 {
+		mw.family("opct_plugin_remaining_seconds", "Estimated remaining time of the plugin in seconds.", "gauge")
+		for _, pl := range remaining {
+			mw.sample("opct_plugin_remaining_seconds", float64(*pl.RemainingSeconds), "plugin", pl.Name)
+		}
+	}
+}
@@ -0,0 +1,127 @@
+package status
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/vmware-tanzu/sonobuoy/pkg/plugin"
+	"github.com/vmware-tanzu/sonobuoy/pkg/plugin/aggregation"
+)
+
+func Test_WriteMetrics(t *testing.T) {
+	start := time.Date(2024, 1, 1, 10, 0, 0, 0, time.UTC)
+	running := &aggregation.Status{
+		Status: aggregation.RunningStatus,
+		Plugins: []aggregation.PluginStatus{
+			{
+				Plugin: "10-openshift-kube-conformance",
+				Status: aggregation.RunningStatus,
+				Progress: &plugin.ProgressUpdate{
+					Completed: 120,
+					Total:     400,
+					Failures:  []string{"test a", "test b"},
+				},
+			},
+			{
+				Plugin:             "05-openshift-cluster-upgrade",
+				Status:             aggregation.CompleteStatus,
+				ResultStatus:       "passed",
+				ResultStatusCounts: map[string]int{"passed": 3, "skipped": 1},
+			},
+		},
+	}
+
+	tests := []struct {
+		name     string
+		report   *StatusReport
+		contains []string
+		excludes []string
+	}{
+		{
+			name:     "status not collected",
+			contains: []string{`opct_up{namespace="opct"} 0`},
+			excludes: []string{"opct_run_info"},
+		},
+		{
+			name:   "running",
+			report: NewStatusReport(running, `id"1`, start, start.Add(90*time.Minute)),
+			contains: []string{
+				"# TYPE opct_up gauge",
+				`opct_up{run_id="id\"1",namespace="opct"} 1`,
+				`opct_run_info{run_id="id\"1",namespace="opct",aggregator_status="running"} 1`,
+				`opct_run_state{run_id="id\"1",namespace="opct",state="running"} 1`,
+				`opct_run_state{run_id="id\"1",namespace="opct",state="complete"} 0`,
+				`opct_run_elapsed_seconds{run_id="id\"1",namespace="opct"} 5400`,
+				`opct_plugin_status{run_id="id\"1",namespace="opct",plugin="10-openshift-kube-conformance",status="running",result=""} 1`,
+				`opct_plugin_tests_completed{run_id="id\"1",namespace="opct",plugin="10-openshift-kube-conformance"} 120`,
+				`opct_plugin_tests_total{run_id="id\"1",namespace="opct",plugin="10-openshift-kube-conformance"} 400`,
+				`opct_plugin_tests_failures{run_id="id\"1",namespace="opct",plugin="10-openshift-kube-conformance"} 2`,
+				`opct_plugin_results{run_id="id\"1",namespace="opct",plugin="05-openshift-cluster-upgrade",result="passed"} 3`,
+				`opct_plugin_results{run_id="id\"1",namespace="opct",plugin="05-openshift-cluster-upgrade",result="skipped"} 1`,
+			},
+			excludes: []string{"opct_run_remaining_seconds", "opct_plugin_remaining_seconds"},
+		},
+		{
+			name: "running with eta",
+			report: func() *StatusReport {
+				now := start.Add(30 * time.Minute)
+				sr := NewStatusReport(running, "id", start, now)
+				sr.setETA(EstimateETA(running, PluginTimings{
+					"05-openshift-cluster-upgrade":  10 * time.Minute,
+					"10-openshift-kube-conformance": 100 * time.Minute,
+				}, start, now))
+				return sr
+			}(),
+			contains: []string{
+				`opct_run_remaining_seconds{run_id="id",namespace="opct"} 4200`,
+				`opct_plugin_remaining_seconds{run_id="id",namespace="opct",plugin="10-openshift-kube-conformance"} 4200`,
+				`opct_plugin_remaining_seconds{run_id="id",namespace="opct",plugin="05-openshift-cluster-upgrade"} 0`,
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			buf := &bytes.Buffer{}
+			WriteMetrics(buf, tt.report)
+			for _, want := range tt.contains {
+				assert.Contains(t, buf.String(), want+"\n")
+			}
+			for _, exclude := range tt.excludes {
+				assert.NotContains(t, buf.String(), exclude)
+			}
+		})
+	}
+}
+
+func Test_MetricsServer(t *testing.T) {
+	ms := NewMetricsServer("127.0.0.1:0")
+	require.NoError(t, ms.Start())
+	defer func() {
+		assert.NoError(t, ms.Shutdown(context.Background()))
+	}()
+
+	get := func() string {
+		resp, err := http.Get("http://" + ms.Addr() + metricsPath)
+		require.NoError(t, err)
+		defer resp.Body.Close()
+		assert.Equal(t, http.StatusOK, resp.StatusCode)
+		assert.Equal(t, metricsContentType, resp.Header.Get("Content-Type"))
+		body, err := io.ReadAll(resp.Body)
+		require.NoError(t, err)
+		return string(body)
+	}
+
+	assert.Contains(t, get(), `opct_up{namespace="opct"} 0`)
+
+	start := time.Date(2024, 1, 1, 10, 0, 0, 0, time.UTC)
+	ms.Update(NewStatusReport(&aggregation.Status{Status: aggregation.CompleteStatus}, "id", start, start.Add(time.Hour)))
+	body := get()
+	assert.Contains(t, body, `opct_up{run_id="id",namespace="opct"} 1`)
+	assert.Contains(t, body, `opct_run_state{run_id="id",namespace="opct",state="complete"} 1`)
+}
@@ -0,0 +1,208 @@
+package status
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+	"time"
+
+	"github.com/vmware-tanzu/sonobuoy/pkg/plugin/aggregation"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+	"sigs.k8s.io/yaml"
+
+	"github.com/redhat-openshift-ecosystem/provider-certification-tool/pkg"
+)
+
+const (
+	OutputTable = "table"
+	OutputJSON  = "json"
+	OutputYAML  = "yaml"
+
+	// States derived from the aggregator and plugin statuses.
+	StateRunning        = "running"
+	StatePostProcessing = "post-processing"
+	StateComplete       = "complete"
+	StateFailed         = "failed"
+	StateUnknown        = "unknown"
+
+	aggregatorLabelSelector = "component=sonobuoy,sonobuoy-component=aggregator"
+)
+
+// StatusReport is the machine-readable status of the validation environment.
+type StatusReport struct {
+	RunID            string               `json:"runId,omitempty"`
+	State            string               `json:"state"`
+	AggregatorStatus string               `json:"aggregatorStatus"`
+	StartTime        time.Time            `json:"startTime"`
+	CurrentTime      time.Time            `json:"currentTime"`
+	ElapsedSeconds   int64                `json:"elapsedSeconds"`
+	RemainingSeconds *int64               `json:"remainingSeconds,omitempty"`
+	Plugins          []PluginStatusReport `json:"plugins"`
+
+	// Findings are the unhealthy conditions detected by the watchdog in the
+	// interval.
+	Findings []*Finding `json:"findings,omitempty"`
+}
+
+// PluginStatusReport is the machine-readable status of a plugin.
+type PluginStatusReport struct {
+	Name         string         `json:"name"`
+	Node         string         `json:"node,omitempty"`
+	Status       string         `json:"status"`
+	ResultStatus string         `json:"resultStatus,omitempty"`
+	Completed    int64          `json:"completed"`
+	Total        int64          `json:"total"`
+	Failures     int            `json:"failures"`
+	Message      string         `json:"message,omitempty"`
+	ResultCounts map[string]int `json:"resultCounts,omitempty"`
+
+	// Estimates from the plugin timings, when available.
+	ExpectedSeconds  *int64 `json:"expectedSeconds,omitempty"`
+	RemainingSeconds *int64 `json:"remainingSeconds,omitempty"`
+	Overdue          bool   `json:"overdue,omitempty"`
+}
+
+// ValidateOutput returns error when the output format is not supported.
+func ValidateOutput(output string) error {
+	switch output {
+	case OutputTable, OutputJSON, OutputYAML:
+		return nil
+	}
+	return fmt.Errorf("invalid output format %q, allowed values: %s, %s, %s", output, OutputTable, OutputJSON, OutputYAML)
+}
+
+// DeriveState returns the state of the execution from the aggregator status.
+// Completed executions with failed plugins, e.g. plugins reaching the
+// timeout, are reported as failed.
+func DeriveState(s *aggregation.Status) string {
+	if s == nil {
+		return StateUnknown
+	}
+	switch s.Status {
+	case aggregation.RunningStatus:
+		return StateRunning
+	case aggregation.PostProcessingStatus:
+		return StatePostProcessing
+	case aggregation.FailedStatus:
+		return StateFailed
+	case aggregation.CompleteStatus:
+		for _, pl := range s.Plugins {
+			if pl.Status == aggregation.FailedStatus {
+				return StateFailed
+			}
+		}
+		return StateComplete
+	}
+	return StateUnknown
+}
+
+// NewStatusReport creates the machine-readable status from the aggregator status.
+func NewStatusReport(s *aggregation.Status, runID string, start, now time.Time) *StatusReport {
+	sr := &StatusReport{
+		RunID:          runID,
+		State:          DeriveState(s),
+		StartTime:      start.UTC().Truncate(time.Second),
+		CurrentTime:    now.UTC().Truncate(time.Second),
+		ElapsedSeconds: int64(now.Sub(start).Seconds()),
+		Plugins:        []PluginStatusReport{},
+	}
+	if s == nil {
+		return sr
+	}
+	sr.AggregatorStatus = s.Status
+	ps := getPrintableRunningStatus(s, start)
+	messages := make(map[string]string, len(ps.PluginStatuses))
+	for _, pl := range ps.PluginStatuses {
+		messages[pl.Name] = pl.Message
+	}
+	for _, pl := range s.Plugins {
+		pr := PluginStatusReport{
+			Name:         pl.Plugin,
+			Node:         pl.Node,
+			Status:       pl.Status,
+			ResultStatus: pl.ResultStatus,
+			Message:      messages[pl.Plugin],
+			ResultCounts: pl.ResultStatusCounts,
+		}
+		if pl.Progress != nil {
+			pr.Completed = pl.Progress.Completed
+			pr.Total = pl.Progress.Total
+			pr.Failures = len(pl.Progress.Failures)
+		}
+		sr.Plugins = append(sr.Plugins, pr)
+	}
+	sort.Slice(sr.Plugins, func(i, j int) bool {
+		return sr.Plugins[i].Name < sr.Plugins[j].Name
+	})
+	return sr
+}
+
+// setETA sets the estimated remaining time, overall and per plugin.
+func (sr *StatusReport) setETA(eta *ETA) {
+	if eta == nil {
+		return
+	}
+	seconds := func(d time.Duration) *int64 {
+		v := int64(d.Seconds())
+		return &v
+	}
+	sr.RemainingSeconds = seconds(eta.Remaining)
+	for i := range sr.Plugins {
+		pe, ok := eta.Plugins[sr.Plugins[i].Name]
+		if !ok {
+			continue
+		}
+		sr.Plugins[i].ExpectedSeconds = seconds(pe.Expected)
+		sr.Plugins[i].RemainingSeconds = seconds(pe.Remaining)
+		sr.Plugins[i].Overdue = pe.Overdue
+	}
+}
+
+// Write writes the status in the output format. Watched statuses are written
+// as a stream: one JSON document per line, or YAML documents separated by '---'.
+func (sr *StatusReport) Write(w io.Writer, output string, stream bool) error {
+	var data []byte
+	var err error
+	switch output {
+	case OutputJSON:
+		if stream {
+			data, err = json.Marshal(sr)
+		} else {
+			data, err = json.MarshalIndent(sr, "", "  ")
+		}
+	case OutputYAML:
+		data, err = yaml.Marshal(sr)
+		if err == nil && stream {
+			data = append([]byte("---\n"), data...)
+		}
+	default:
+		return ValidateOutput(output)
+	}
+	if err != nil {
+		return err
+	}
+	if len(data) > 0 && data[len(data)-1] != '\n' {
+		data = append(data, '\n')
+	}
+	_, err = w.Write(data)
+	return err
+}
+
+// AggregatorStartTime returns the start time of the aggregator pod, the start
+// of the execution.
+func AggregatorStartTime(ctx context.Context, kclient kubernetes.Interface) (time.Time, error) {
+	pods, err := kclient.CoreV1().Pods(pkg.GetNamespace()).List(ctx, metav1.ListOptions{LabelSelector: aggregatorLabelSelector})
+	if err != nil {
+		return time.Time{}, err
+	}
+	for _, pod := range pods.Items {
+		if pod.Status.StartTime != nil {
+			return pod.Status.StartTime.Time, nil
+		}
+		return pod.CreationTimestamp.Time, nil
+	}
+	return time.Time{}, fmt.Errorf("aggregator pod not found in the namespace %s", pkg.GetNamespace())
+}
@@ -0,0 +1,142 @@
+package status
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/vmware-tanzu/sonobuoy/pkg/plugin"
+	"github.com/vmware-tanzu/sonobuoy/pkg/plugin/aggregation"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+
+	"github.com/redhat-openshift-ecosystem/provider-certification-tool/pkg"
+)
+
+func Test_DeriveState(t *testing.T) {
+	tests := []struct {
+		name   string
+		status *aggregation.Status
+		want   string
+	}{
+		{
+			name: "status not reported",
+			want: StateUnknown,
+		},
+		{
+			name:   "running",
+			status: &aggregation.Status{Status: aggregation.RunningStatus},
+			want:   StateRunning,
+		},
+		{
+			name:   "post-processing",
+			status: &aggregation.Status{Status: aggregation.PostProcessingStatus},
+			want:   StatePostProcessing,
+		},
+		{
+			name: "complete",
+			status: &aggregation.Status{Status: aggregation.CompleteStatus, Plugins: []aggregation.PluginStatus{
+				{Plugin: "a", Status: aggregation.CompleteStatus, ResultStatus: "failed"},
+			}},
+			want: StateComplete,
+		},
+		{
+			name: "complete with failed plugin",
+			status: &aggregation.Status{Status: aggregation.CompleteStatus, Plugins: []aggregation.PluginStatus{
+				{Plugin: "a", Status: aggregation.CompleteStatus},
+				{Plugin: "b", Status: aggregation.FailedStatus},
+			}},
+			want: StateFailed,
+		},
+		{
+			name:   "aggregator failed",
+			status: &aggregation.Status{Status: aggregation.FailedStatus},
+			want:   StateFailed,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, DeriveState(tt.status))
+		})
+	}
+}
+
+func Test_StatusReport(t *testing.T) {
+	start := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	now := start.Add(90 * time.Minute)
+	a := &aggregation.Status{
+		Status: aggregation.RunningStatus,
+		Plugins: []aggregation.PluginStatus{
+			{
+				Plugin: "20-openshift-conformance-validated",
+				Status: aggregation.RunningStatus,
+				Progress: &plugin.ProgressUpdate{
+					Total:     300,
+					Completed: 100,
+					Failures:  []string{"a", "b"},
+					Message:   "status=running",
+				},
+			},
+			{
+				Plugin:             "05-openshift-cluster-upgrade",
+				Status:             aggregation.CompleteStatus,
+				ResultStatus:       "passed",
+				ResultStatusCounts: map[string]int{"passed": 1},
+			},
+		},
+	}
+	sr := NewStatusReport(a, "id", start, now)
+	assert.Equal(t, StateRunning, sr.State)
+	assert.Equal(t, int64(5400), sr.ElapsedSeconds)
+	require.Len(t, sr.Plugins, 2)
+	assert.Equal(t, "05-openshift-cluster-upgrade", sr.Plugins[0].Name)
+	assert.Equal(t, "Total tests processed: 1 (1 pass / 0 failed)", sr.Plugins[0].Message)
+	assert.Equal(t, int64(100), sr.Plugins[1].Completed)
+	assert.Equal(t, int64(300), sr.Plugins[1].Total)
+	assert.Equal(t, 2, sr.Plugins[1].Failures)
+	assert.Equal(t, "status=running", sr.Plugins[1].Message)
+
+	t.Run("json stream", func(t *testing.T) {
+		buf := &bytes.Buffer{}
+		require.NoError(t, sr.Write(buf, OutputJSON, true))
+		require.NoError(t, sr.Write(buf, OutputJSON, true))
+		lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+		require.Len(t, lines, 2)
+		got := &StatusReport{}
+		require.NoError(t, json.Unmarshal([]byte(lines[0]), got))
+		assert.Equal(t, sr, got)
+	})
+	t.Run("yaml stream", func(t *testing.T) {
+		buf := &bytes.Buffer{}
+		require.NoError(t, sr.Write(buf, OutputYAML, true))
+		assert.True(t, strings.HasPrefix(buf.String(), "---\n"))
+		assert.Contains(t, buf.String(), "state: running\n")
+	})
+	t.Run("invalid output", func(t *testing.T) {
+		assert.Error(t, sr.Write(&bytes.Buffer{}, "xml", false))
+	})
+}
+
+func Test_AggregatorStartTime(t *testing.T) {
+	start := metav1.NewTime(time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC))
+	pod := &v1.Pod{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "sonobuoy",
+			Namespace: pkg.GetNamespace(),
+			Labels:    map[string]string{"component": "sonobuoy", "sonobuoy-component": "aggregator"},
+		},
+		Status: v1.PodStatus{StartTime: &start},
+	}
+	got, err := AggregatorStartTime(context.TODO(), fake.NewSimpleClientset(pod))
+	require.NoError(t, err)
+	assert.True(t, start.Time.Equal(got))
+
+	_, err = AggregatorStartTime(context.TODO(), fake.NewSimpleClientset())
+	assert.Error(t, err)
+}
@@ -3,6 +3,7 @@ package status
 import (
 	"fmt"
 	"html/template"
+	"io"
 	"os"
 	"sort"
 	"time"
@@ -14,6 +15,7 @@ type PrintableStatus struct {
 	GlobalStatus   string
 	CurrentTime    string
 	ElapsedTime    string
+	ETA            string
 	PluginStatuses []PrintablePluginStatus
 }
 
@@ -23,22 +25,48 @@ type PrintablePluginStatus struct {
 	Result   string
 	Progress string
 	Message  string
+	ETA      string
 }
 
-var runningStatusTemplate = `{{.CurrentTime}}|{{.ElapsedTime}}> Global Status: {{.GlobalStatus}}
-{{printf "%-34s | %-10s | %-10s | %-25s | %-50s" "JOB_NAME" "STATUS" "RESULTS" "PROGRESS" "MESSAGE"}}{{range $index, $pl := .PluginStatuses}}
-{{printf "%-34s | %-10s | %-10s | %-25s | %-50s" $pl.Name $pl.Status $pl.Result $pl.Progress $pl.Message}}{{end}}
+var runningStatusTemplate = `{{.CurrentTime}}|{{.ElapsedTime}}> Global Status: {{.GlobalStatus}}{{if .ETA}} (ETA: {{.ETA}}){{end}}
+{{if .ETA}}{{printf "%-34s | %-10s | %-10s | %-25s | %-10s | %-50s" "JOB_NAME" "STATUS" "RESULTS" "PROGRESS" "ETA" "MESSAGE"}}{{range $index, $pl := .PluginStatuses}}
+{{printf "%-34s | %-10s | %-10s | %-25s | %-10s | %-50s" $pl.Name $pl.Status $pl.Result $pl.Progress $pl.ETA $pl.Message}}{{end}}{{else}}{{printf "%-34s | %-10s | %-10s | %-25s | %-50s" "JOB_NAME" "STATUS" "RESULTS" "PROGRESS" "MESSAGE"}}{{range $index, $pl := .PluginStatuses}}
+{{printf "%-34s | %-10s | %-10s | %-25s | %-50s" $pl.Name $pl.Status $pl.Result $pl.Progress $pl.Message}}{{end}}{{end}}
 `
 
 func PrintRunningStatus(s *aggregation.Status, start time.Time) error {
-	ps := getPrintableRunningStatus(s, start)
+	return printStatus(os.Stdout, getPrintableRunningStatus(s, start))
+}
+
+func printStatus(w io.Writer, ps PrintableStatus) error {
 	statusTemplate, err := template.New("statusTemplate").Parse(runningStatusTemplate)
 	if err != nil {
 		return err
 	}
 
-	err = statusTemplate.Execute(os.Stdout, ps)
-	return err
+	return statusTemplate.Execute(w, ps)
+}
+
+// setETA sets the estimated remaining time, overall and per plugin.
+func (ps *PrintableStatus) setETA(eta *ETA) {
+	if eta == nil {
+		return
+	}
+	ps.ETA = FormatETA(eta.Remaining)
+	for i := range ps.PluginStatuses {
+		pe, ok := eta.Plugins[ps.PluginStatuses[i].Name]
+		if !ok {
+			ps.PluginStatuses[i].ETA = "-"
+			continue
+		}
+		ps.PluginStatuses[i].ETA = FormatETA(pe.Remaining)
+		if pe.Remaining == 0 {
+			ps.PluginStatuses[i].ETA = "0"
+		}
+		if pe.Overdue {
+			ps.PluginStatuses[i].ETA = "overdue"
+		}
+	}
 }
 
 func getPrintableRunningStatus(s *aggregation.Status, start time.Time) PrintableStatus {
@@ -3,6 +3,9 @@ package status
 import (
 	"context"
 	"errors"
+	"fmt"
+	"io"
+	"os"
 	"time"
 
 	log "github.com/sirupsen/logrus"
@@ -16,6 +19,8 @@ import (
 
 	"github.com/redhat-openshift-ecosystem/provider-certification-tool/pkg"
 	"github.com/redhat-openshift-ecosystem/provider-certification-tool/pkg/client"
+	"github.com/redhat-openshift-ecosystem/provider-certification-tool/pkg/notify"
+	"github.com/redhat-openshift-ecosystem/provider-certification-tool/pkg/runid"
 	"github.com/redhat-openshift-ecosystem/provider-certification-tool/pkg/wait"
 )
 
@@ -30,20 +35,53 @@ const (
 type StatusOptions struct {
 	StartTime           time.Time
 	Latest              *aggregation.Status
+	RunID               string
 	watch               bool
 	shownPostProcessMsg bool
 	watchInterval       int
 	waitInterval        time.Duration
+
+	// output is the format of the status: table, json or yaml.
+	output string
+	out    io.Writer
+
+	// eta enables the estimated remaining time from the baseline timings,
+	// or from the reports in etaHistory when set.
+	eta           bool
+	etaHistory    string
+	timings       PluginTimings
+	overdueWarned map[string]struct{}
+
+	// watchdog detects unhealthy executions in watch mode, ending the watch
+	// with error on findings when watchdogFail is set.
+	watchdogEnabled    bool
+	watchdogNoProgress time.Duration
+	watchdogFail       bool
+	watchdog           *Watchdog
+	findings           []*Finding
+	kclient            kubernetes.Interface
+
+	// serveMetrics is the address to expose the status as Prometheus metrics
+	// while watching.
+	serveMetrics string
+	metrics      *MetricsServer
+
+	// notifier posts the lifecycle events detected by the tracker while
+	// watching.
+	notifier *notify.Notifier
+	tracker  *notify.Tracker
 }
 
 type StatusInput struct {
 	Watch           bool
 	IntervalSeconds int
+	Notifier        *notify.Notifier
 }
 
 func NewStatusOptions(in *StatusInput) *StatusOptions {
 	s := &StatusOptions{
 		watch:        in.Watch,
+		notifier:     in.Notifier,
 		waitInterval: time.Second * DefaultStatusIntervalSeconds,
 		StartTime:    time.Now(),
 	}
@@ -61,6 +99,28 @@ func NewCmdStatus() *cobra.Command {
 		Short: "Show the current status of the validation tool",
 		Long:  ``,
 		RunE: func(cmd *cobra.Command, args []string) error {
+			if err := ValidateOutput(o.output); err != nil {
+				return err
+			}
+			if o.watchInterval > 0 {
+				o.waitInterval = time.Duration(o.watchInterval) * time.Second
+			}
+			if o.serveMetrics != "" {
+				o.watch = true
+			}
+			if (o.watchdogEnabled || o.watchdogFail) && !o.watch {
+				return errors.New("--watchdog requires --watch")
+			}
+			notifier, err := notify.NewNotifierFromFlags(cmd.Flags())
+			if err != nil {
+				return err
+			}
+			o.notifier = notifier
+			// Machine-readable output is written to stdout, logs are moved to stderr.
+			if o.output != OutputTable {
+				log.SetOutput(os.Stderr)
+			}
+
 			// Client setup
 			kclient, sclient, err := client.CreateClients()
 			if err != nil {
@@ -74,6 +134,7 @@ func NewCmdStatus() *cobra.Command {
 				log.WithError(err).Error("error running pre-checks")
 				return err
 			}
+			o.RunID = runid.SetLoggerFromCluster(cmd.Context(), kclient)
 
 			// Wait for Sonobuoy to create
 			err = wait.WaitForRequiredResources(kclient)
@@ -81,6 +142,31 @@ func NewCmdStatus() *cobra.Command {
 				log.WithError(err).Error("error waiting for sonobuoy pods to become ready")
 				return err
 			}
+			if start, err := AggregatorStartTime(cmd.Context(), kclient); err != nil {
+				log.Debugf("unable to read the aggregator start time: %v", err)
+			} else {
+				o.StartTime = start
+			}
+			if o.eta || o.etaHistory != "" {
+				o.loadTimings(cmd.Context())
+			}
+			if o.watchdogEnabled || o.watchdogFail {
+				o.watchdog = NewWatchdog(o.watchdogNoProgress)
+				o.kclient = kclient
+			}
+			if o.serveMetrics != "" {
+				o.metrics = NewMetricsServer(o.serveMetrics)
+				if err := o.metrics.Start(); err != nil {
+					return err
+				}
+				defer func() {
+					ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+					defer cancel()
+					if err := o.metrics.Shutdown(ctx); err != nil {
+						log.Warnf("unable to stop the metrics server: %v", err)
+					}
+				}()
+			}
 
 			// Wait for Sononbuoy to start reporting status
 			err = o.WaitForStatusReport(cmd.Context(), sclient)
@@ -100,16 +186,21 @@ func NewCmdStatus() *cobra.Command {
 
 	cmd.PersistentFlags().BoolVarP(&o.watch, "watch", "w", false, "Keep watch status after running")
 	cmd.Flags().IntVarP(&o.watchInterval, "watch-interval", "", DefaultStatusIntervalSeconds, "Interval to watch the status and print in the stdout")
-	if o.watchInterval != DefaultStatusIntervalSeconds {
-		o.waitInterval = time.Duration(o.watchInterval) * time.Second
-	}
+	cmd.Flags().BoolVar(&o.eta, "eta", false, "Estimate the remaining time of each plugin from the baseline timings of the same OpenShift release and platform.")
+	cmd.Flags().StringVar(&o.etaHistory, "eta-history", "", "Estimate the remaining time from the report summaries (opct-report-summary.json) in the path, instead of the baseline.")
+	cmd.Flags().BoolVar(&o.watchdogEnabled, "watchdog", false, "Detect unhealthy executions when watching: pods restarting, OOMKilled, evicted or pending due to taints, plugins without progress, and the aggregator approaching the timeout.")
+	cmd.Flags().DurationVar(&o.watchdogNoProgress, "watchdog-no-progress", DefaultNoProgressTimeout, "Time without progress updates of the running plugin to be reported by the watchdog. Zero disables the check.")
+	cmd.Flags().BoolVar(&o.watchdogFail, "watchdog-fail", false, "End the watch with error when the watchdog reports an unhealthy condition. Implies --watchdog.")
+	cmd.Flags().StringVar(&o.serveMetrics, "serve-metrics", "", "Address to expose the status as Prometheus metrics in /metrics, e.g. ':9095'. Implies --watch.")
+	cmd.Flags().StringVarP(&o.output, "output", "o", OutputTable, "Output format. Available: table, json, yaml. Watched status prints one document per interval.")
+	notify.AddFlags(cmd.Flags())
 
 	return cmd
 }
 
 func (s *StatusOptions) PreRunCheck(kclient kubernetes.Interface) error {
 	// Check if sonobuoy namespac already exists
-	_, err := kclient.CoreV1().Namespaces().Get(context.TODO(), pkg.CertificationNamespace, metav1.GetOptions{})
+	_, err := kclient.CoreV1().Namespaces().Get(context.TODO(), pkg.GetNamespace(), metav1.GetOptions{})
 	if err != nil {
 		// If error is due to namespace not being found, return guidance.
 		if kerrors.IsNotFound(err) {
@@ -124,7 +215,7 @@ func (s *StatusOptions) PreRunCheck(kclient kubernetes.Interface) error {
 // Update the Sonobuoy state saved in StatusOptions
 func (s *StatusOptions) Update(sclient sonobuoyclient.Interface) error {
 	// TODO Is a retry in here needed?
-	sstatus, err := sclient.GetStatus(&sonobuoyclient.StatusConfig{Namespace: pkg.CertificationNamespace})
+	sstatus, err := sclient.GetStatus(&sonobuoyclient.StatusConfig{Namespace: pkg.GetNamespace()})
 	if err != nil {
 		return err
 	}
@@ -182,6 +273,7 @@ func (s *StatusOptions) WaitForStatusReport(ctx context.Context, sclient sonobuo
 }
 
 func (s *StatusOptions) Print(cmd *cobra.Command, sclient sonobuoyclient.Interface) error {
+	s.out = cmd.OutOrStdout()
 	if !s.watch {
 		_, err := s.doPrint()
 		return err
@@ -200,25 +292,72 @@ func (s *StatusOptions) Print(cmd *cobra.Command, sclient sonobuoyclient.Interfa
 			return false, nil
 		}
 		tries = 1 // reset retries
-		return s.doPrint()
+		findings := s.checkWatchdog(ctx)
+		s.notifyEvents(ctx)
+		if s.metrics != nil {
+			s.metrics.Update(s.newStatusReport(time.Now()))
+		}
+		complete, err := s.doPrint()
+		if complete || err != nil {
+			return complete, err
+		}
+		if s.watchdogFail && len(findings) > 0 {
+			return true, fmt.Errorf("watchdog detected %d unhealthy condition(s) in the execution", len(findings))
+		}
+		return false, nil
 	})
 }
 
+// checkWatchdog logs the findings of the watchdog, when enabled, returning
+// the new ones.
+func (s *StatusOptions) checkWatchdog(ctx context.Context) []*Finding {
+	if s.watchdog == nil {
+		return nil
+	}
+	findings, err := s.watchdog.Check(ctx, s.kclient, s.Latest, s.StartTime, time.Now())
+	if err != nil {
+		log.Warnf("Watchdog check failed: %v", err)
+		return nil
+	}
+	for _, f := range findings {
+		log.Warnf("Watchdog [%s] %s: %s. Hint: %s", f.ID, f.Object, f.Message, f.Hint)
+	}
+	s.findings = findings
+	return findings
+}
+
+// notifyEvents notifies the lifecycle events detected since the last
+// interval, when the notifier is set.
+func (s *StatusOptions) notifyEvents(ctx context.Context) {
+	if s.notifier == nil {
+		return
+	}
+	if s.tracker == nil {
+		s.tracker = notify.NewTracker(s.RunID)
+	}
+	for _, ev := range s.tracker.Events(s.Latest) {
+		s.notifier.Notify(ctx, ev)
+	}
+}
+
 func (s *StatusOptions) doPrint() (complete bool, err error) {
+	if s.output != "" && s.output != OutputTable {
+		return s.doPrintReport()
+	}
 	switch s.GetStatus() {
 	case aggregation.RunningStatus:
-		err := PrintRunningStatus(s.Latest, s.StartTime)
+		err := s.printRunningStatus()
 		if err != nil {
 			return false, err
 		}
 	case aggregation.PostProcessingStatus:
 		if !s.watch {
-			err := PrintRunningStatus(s.Latest, s.StartTime)
+			err := s.printRunningStatus()
 			if err != nil {
 				return false, err
 			}
 		} else if !s.shownPostProcessMsg {
-			err := PrintRunningStatus(s.Latest, s.StartTime)
+			err := s.printRunningStatus()
 			if err != nil {
 				return false, err
 			}
@@ -226,15 +365,100 @@ func (s *StatusOptions) doPrint() (complete bool, err error) {
 			s.shownPostProcessMsg = true
 		}
 	case aggregation.CompleteStatus:
-		err := PrintRunningStatus(s.Latest, s.StartTime)
+		err := s.printRunningStatus()
 		if err != nil {
 			return true, err
 		}
 		log.Infof("The execution has completed! Use retrieve command to collect the results and share the archive with your Red Hat partner.")
 		return true, nil
+	case aggregation.FailedStatus:
+		err := s.printRunningStatus()
+		if err != nil {
+			return true, err
+		}
+		return true, errors.New("the execution has failed, check the aggregator logs with 'opct sonobuoy logs'")
 	default:
 		log.Infof("Unknown state %s", s.GetStatus())
 	}
 
 	return false, nil
 }
+
+// doPrintReport writes the machine-readable status, every interval when watching.
+func (s *StatusOptions) doPrintReport() (complete bool, err error) {
+	out := s.out
+	if out == nil {
+		out = os.Stdout
+	}
+	sr := s.newStatusReport(time.Now())
+	if err := sr.Write(out, s.output, s.watch); err != nil {
+		return false, err
+	}
+	switch s.GetStatus() {
+	case aggregation.CompleteStatus:
+		return true, nil
+	case aggregation.FailedStatus:
+		return true, errors.New("the execution has failed, check the aggregator logs with 'opct sonobuoy logs'")
+	}
+	return false, nil
+}
+
+// newStatusReport creates the machine-readable status from the latest state.
+func (s *StatusOptions) newStatusReport(now time.Time) *StatusReport {
+	sr := NewStatusReport(s.Latest, s.RunID, s.StartTime, now)
+	sr.setETA(s.estimateETA(now))
+	sr.Findings = s.findings
+	return sr
+}
+
+// loadTimings loads the plugin timings used to estimate the remaining time.
+// Failures are not blocking, the status is shown without estimates.
+func (s *StatusOptions) loadTimings(ctx context.Context) {
+	release, platform, err := ClusterPlatform(ctx)
+	if err != nil {
+		log.Warnf("Unable to discover the cluster platform, the ETA is estimated from any release and platform: %v", err)
+	}
+	if s.etaHistory != "" {
+		s.timings, err = LoadTimingsFromHistory(s.etaHistory, release, platform)
+	} else {
+		s.timings, err = LoadTimingsFromBaseline(release, platform)
+	}
+	if err != nil {
+		log.Warnf("Unable to load the plugin timings, the ETA is not available: %v", err)
+		return
+	}
+	log.Debugf("Plugin timings loaded: %v", s.timings)
+}
+
+// estimateETA estimates the remaining time, warning once the plugins running
+// longer than the timings.
+func (s *StatusOptions) estimateETA(now time.Time) *ETA {
+	eta := EstimateETA(s.Latest, s.timings, s.StartTime, now)
+	if eta == nil {
+		return nil
+	}
+	if s.overdueWarned == nil {
+		s.overdueWarned = map[string]struct{}{}
+	}
+	for name, pe := range eta.Plugins {
+		if _, ok := s.overdueWarned[name]; !pe.Overdue || ok {
+			continue
+		}
+		s.overdueWarned[name] = struct{}{}
+		log.Warnf("Plugin %s is running far longer than the baseline: elapsed %s, expected to finish %s after the start", name, FormatETA(now.Sub(s.StartTime)), FormatETA(pe.Deadline))
+	}
+	return eta
+}
+
+// printRunningStatus prints the status table, with the estimated remaining
+// time when the timings are loaded.
+func (s *StatusOptions) printRunningStatus() error {
+	out := s.out
+	if out == nil {
+		out = os.Stdout
+	}
+	now := time.Now()
+	ps := getPrintableRunningStatus(s.Latest, s.StartTime)
+	ps.setETA(s.estimateETA(now))
+	return printStatus(out, ps)
+}
@@ -0,0 +1,267 @@
+package status
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+	"github.com/vmware-tanzu/sonobuoy/pkg/config"
+	"github.com/vmware-tanzu/sonobuoy/pkg/plugin/aggregation"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+
+	"github.com/redhat-openshift-ecosystem/provider-certification-tool/pkg"
+)
+
+const (
+	FindingPodRestarts       = "pod-restarts"
+	FindingOOMKilled         = "oom-killed"
+	FindingPodEvicted        = "pod-evicted"
+	FindingPodPendingTaint   = "pod-pending-taint"
+	FindingNoProgress        = "no-progress"
+	FindingAggregatorTimeout = "aggregator-timeout"
+
+	// DefaultNoProgressTimeout is the time without progress updates of the
+	// active plugin to report it as stuck.
+	DefaultNoProgressTimeout = 30 * time.Minute
+
+	// aggregatorTimeoutRatio is the ratio of the aggregator timeout elapsed
+	// to report the execution approaching the timeout.
+	aggregatorTimeoutRatio = 0.9
+
+	watchdogPodsLabelSelector = "sonobuoy-component in (aggregator,plugin)"
+	sonobuoyConfigMapName     = "sonobuoy-config-cm"
+	sonobuoyConfigMapKey      = "config.json"
+)
+
+// Finding is an unhealthy condition of the execution detected by the watchdog.
+type Finding struct {
+	ID      string `json:"id"`
+	Object  string `json:"object"`
+	Message string `json:"message"`
+	Hint    string `json:"hint"`
+
+	// key identifies the finding to be reported once.
+	key string
+}
+
+// Watchdog detects unhealthy executions while watching the status: plugin
+// pods restarting, OOMKilled or evicted, pods pending due to taints, the
+// active plugin without progress, and the aggregator approaching the timeout.
+type Watchdog struct {
+	// NoProgressTimeout is the time without progress of the active plugin to
+	// report it. Zero disables the check.
+	NoProgressTimeout time.Duration
+
+	// AggregatorTimeout is the timeout of the aggregator, read from the
+	// Sonobuoy config when not set. Negative values disable the check.
+	AggregatorTimeout time.Duration
+
+	progress map[string]*progressMark
+	reported map[string]struct{}
+}
+
+type progressMark struct {
+	signature string
+	since     time.Time
+}
+
+// NewWatchdog creates the watchdog.
+func NewWatchdog(noProgressTimeout time.Duration) *Watchdog {
+	return &Watchdog{
+		NoProgressTimeout: noProgressTimeout,
+		progress:          map[string]*progressMark{},
+		reported:          map[string]struct{}{},
+	}
+}
+
+// Check evaluates the execution, returning the findings not reported before.
+func (w *Watchdog) Check(ctx context.Context, kclient kubernetes.Interface, s *aggregation.Status, start, now time.Time) ([]*Finding, error) {
+	if w.AggregatorTimeout == 0 {
+		timeout, err := AggregatorTimeout(ctx, kclient)
+		if err != nil {
+			log.Warnf("Watchdog is unable to check the aggregator timeout: %v", err)
+			timeout = -1
+		}
+		w.AggregatorTimeout = timeout
+	}
+	pods, err := kclient.CoreV1().Pods(pkg.GetNamespace()).List(ctx, metav1.ListOptions{LabelSelector: watchdogPodsLabelSelector})
+	if err != nil {
+		return nil, fmt.Errorf("unable to list the pods of the validation environment: %w", err)
+	}
+
+	findings := checkPods(pods.Items)
+	findings = append(findings, w.checkProgress(s, now)...)
+	findings = append(findings, w.checkAggregatorTimeout(start, now)...)
+	return w.filterReported(findings), nil
+}
+
+// filterReported returns the findings not reported before.
+func (w *Watchdog) filterReported(findings []*Finding) []*Finding {
+	news := []*Finding{}
+	for _, f := range findings {
+		if _, ok := w.reported[f.key]; ok {
+			continue
+		}
+		w.reported[f.key] = struct{}{}
+		news = append(news, f)
+	}
+	return news
+}
+
+// podObject returns the name of the plugin, or the aggregator, of the pod.
+func podObject(pod *v1.Pod) string {
+	if name, ok := pod.Labels["sonobuoy-plugin"]; ok {
+		return fmt.Sprintf("plugin %s (pod %s)", name, pod.Name)
+	}
+	return fmt.Sprintf("aggregator (pod %s)", pod.Name)
+}
+
+// checkPods evaluates the containers and the scheduling of the pods.
+func checkPods(pods []v1.Pod) []*Finding {
+	findings := []*Finding{}
+	for i := range pods {
+		pod := &pods[i]
+		object := podObject(pod)
+		plugin := pod.Labels["sonobuoy-plugin"]
+
+		if pod.Status.Phase == v1.PodFailed && pod.Status.Reason == "Evicted" {
+			findings = append(findings, &Finding{
+				ID:      FindingPodEvicted,
+				Object:  object,
+				Message: fmt.Sprintf("pod evicted: %s", pod.Status.Message),
+				Hint:    "The node is under resource pressure. Check the node conditions with 'oc describe node', and use dedicated nodes with enough resources to the validation environment.",
+				key:     fmt.Sprintf("%s/%s", FindingPodEvicted, pod.Name),
+			})
+		}
+
+		if pod.Status.Phase == v1.PodPending {
+			for _, cond := range pod.Status.Conditions {
+				if cond.Type == v1.PodScheduled && cond.Status == v1.ConditionFalse &&
+					cond.Reason == v1.PodReasonUnschedulable && strings.Contains(cond.Message, "taint") {
+					findings = append(findings, &Finding{
+						ID:      FindingPodPendingTaint,
+						Object:  object,
+						Message: fmt.Sprintf("pod pending: %s", cond.Message),
+						Hint:    fmt.Sprintf("The pod does not tolerate the node taints. Check the dedicated node has the label and taint %s, or run 'opct preflight'.", pkg.DedicatedNodeRoleLabel),
+						key:     fmt.Sprintf("%s/%s", FindingPodPendingTaint, pod.Name),
+					})
+				}
+			}
+		}
+
+		statuses := append([]v1.ContainerStatus{}, pod.Status.InitContainerStatuses...)
+		statuses = append(statuses, pod.Status.ContainerStatuses...)
+		for _, cs := range statuses {
+			if isOOMKilled(cs) {
+				hint := "Increase the memory limit of the plugin"
+				if plugin != "" {
+					hint = fmt.Sprintf("%s with --plugin-memory-limit %s=<quantity>", hint, plugin)
+				}
+				findings = append(findings, &Finding{
+					ID:      FindingOOMKilled,
+					Object:  object,
+					Message: fmt.Sprintf("container %s was OOMKilled", cs.Name),
+					Hint:    hint + ", or check the memory available in the dedicated node.",
+					key:     fmt.Sprintf("%s/%s/%s/%d", FindingOOMKilled, pod.Name, cs.Name, cs.RestartCount),
+				})
+			}
+			if cs.RestartCount > 0 {
+				findings = append(findings, &Finding{
+					ID:      FindingPodRestarts,
+					Object:  object,
+					Message: fmt.Sprintf("container %s restarted %d time(s)", cs.Name, cs.RestartCount),
+					Hint:    fmt.Sprintf("Check the logs of the previous container with 'oc logs -n %s %s -c %s --previous'.", pkg.GetNamespace(), pod.Name, cs.Name),
+					key:     fmt.Sprintf("%s/%s/%s/%d", FindingPodRestarts, pod.Name, cs.Name, cs.RestartCount),
+				})
+			}
+		}
+	}
+	return findings
+}
+
+func isOOMKilled(cs v1.ContainerStatus) bool {
+	if t := cs.State.Terminated; t != nil && t.Reason == "OOMKilled" {
+		return true
+	}
+	if t := cs.LastTerminationState.Terminated; t != nil && t.Reason == "OOMKilled" {
+		return true
+	}
+	return false
+}
+
+// checkProgress reports the active plugin, the first not completed in the
+// execution order, without progress updates for the NoProgressTimeout.
+func (w *Watchdog) checkProgress(s *aggregation.Status, now time.Time) []*Finding {
+	if s == nil || w.NoProgressTimeout == 0 || s.Status != aggregation.RunningStatus {
+		return nil
+	}
+	plugins := make([]aggregation.PluginStatus, len(s.Plugins))
+	copy(plugins, s.Plugins)
+	sort.Slice(plugins, func(i, j int) bool {
+		return plugins[i].Plugin < plugins[j].Plugin
+	})
+	for _, pl := range plugins {
+		if pluginDone(pl) {
+			continue
+		}
+		signature := ""
+		if p := pl.Progress; p != nil {
+			signature = fmt.Sprintf("%d/%d/%d/%s", p.Completed, p.Total, len(p.Failures), p.Message)
+		}
+		mark, ok := w.progress[pl.Plugin]
+		if !ok || mark.signature != signature {
+			w.progress[pl.Plugin] = &progressMark{signature: signature, since: now}
+			return nil
+		}
+		if stalled := now.Sub(mark.since); stalled >= w.NoProgressTimeout {
+			return []*Finding{{
+				ID:      FindingNoProgress,
+				Object:  fmt.Sprintf("plugin %s", pl.Plugin),
+				Message: fmt.Sprintf("no progress update for %s", stalled.Round(time.Minute)),
+				Hint:    fmt.Sprintf("Check the plugin logs with 'opct sonobuoy logs -n %s -p %s'.", pkg.GetNamespace(), pl.Plugin),
+				key:     fmt.Sprintf("%s/%s/%s", FindingNoProgress, pl.Plugin, signature),
+			}}
+		}
+		return nil
+	}
+	return nil
+}
+
+// checkAggregatorTimeout reports the execution approaching the aggregator
+// timeout, when the results of the running plugins are lost.
+func (w *Watchdog) checkAggregatorTimeout(start, now time.Time) []*Finding {
+	if w.AggregatorTimeout <= 0 {
+		return nil
+	}
+	elapsed := now.Sub(start)
+	if float64(elapsed) < float64(w.AggregatorTimeout)*aggregatorTimeoutRatio {
+		return nil
+	}
+	return []*Finding{{
+		ID:      FindingAggregatorTimeout,
+		Object:  "aggregator",
+		Message: fmt.Sprintf("execution running for %s, the aggregator timeout is %s", elapsed.Round(time.Minute), w.AggregatorTimeout),
+		Hint:    "Plugins not finished by the timeout are reported as failed. Check the plugin progress, and increase the timeout with 'opct run --timeout' in the next execution.",
+		key:     FindingAggregatorTimeout,
+	}}
+}
+
+// AggregatorTimeout returns the timeout of the aggregator from the Sonobuoy
+// config of the validation environment.
+func AggregatorTimeout(ctx context.Context, kclient kubernetes.Interface) (time.Duration, error) {
+	cm, err := kclient.CoreV1().ConfigMaps(pkg.GetNamespace()).Get(ctx, sonobuoyConfigMapName, metav1.GetOptions{})
+	if err != nil {
+		return 0, fmt.Errorf("unable to read the aggregator config: %w", err)
+	}
+	cfg := &config.Config{}
+	if err := json.Unmarshal([]byte(cm.Data[sonobuoyConfigMapKey]), cfg); err != nil {
+		return 0, fmt.Errorf("unable to parse the aggregator config: %w", err)
+	}
+	return time.Duration(cfg.Aggregation.TimeoutSeconds) * time.Second, nil
+}
@@ -0,0 +1,159 @@
+package status
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/vmware-tanzu/sonobuoy/pkg/plugin"
+	"github.com/vmware-tanzu/sonobuoy/pkg/plugin/aggregation"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+
+	"github.com/redhat-openshift-ecosystem/provider-certification-tool/pkg"
+)
+
+func newPluginPod(name string, status v1.PodStatus) v1.Pod {
+	return v1.Pod{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "sonobuoy-" + name + "-job",
+			Namespace: pkg.GetNamespace(),
+			Labels:    map[string]string{"sonobuoy-component": "plugin", "sonobuoy-plugin": name},
+		},
+		Status: status,
+	}
+}
+
+func Test_CheckPods(t *testing.T) {
+	tests := []struct {
+		name string
+		pod  v1.Pod
+		want []string
+	}{
+		{
+			name: "healthy",
+			pod: newPluginPod("10-openshift-kube-conformance", v1.PodStatus{
+				Phase:             v1.PodRunning,
+				ContainerStatuses: []v1.ContainerStatus{{Name: "plugin"}},
+			}),
+			want: []string{},
+		},
+		{
+			name: "restarted after OOMKilled",
+			pod: newPluginPod("10-openshift-kube-conformance", v1.PodStatus{
+				Phase: v1.PodRunning,
+				ContainerStatuses: []v1.ContainerStatus{{
+					Name:                 "plugin",
+					RestartCount:         1,
+					LastTerminationState: v1.ContainerState{Terminated: &v1.ContainerStateTerminated{Reason: "OOMKilled"}},
+				}},
+			}),
+			want: []string{FindingOOMKilled, FindingPodRestarts},
+		},
+		{
+			name: "evicted",
+			pod: newPluginPod("20-openshift-conformance-validated", v1.PodStatus{
+				Phase:   v1.PodFailed,
+				Reason:  "Evicted",
+				Message: "The node was low on resource: memory.",
+			}),
+			want: []string{FindingPodEvicted},
+		},
+		{
+			name: "pending due to taints",
+			pod: newPluginPod("05-openshift-cluster-upgrade", v1.PodStatus{
+				Phase: v1.PodPending,
+				Conditions: []v1.PodCondition{{
+					Type:    v1.PodScheduled,
+					Status:  v1.ConditionFalse,
+					Reason:  v1.PodReasonUnschedulable,
+					Message: "0/6 nodes are available: 6 node(s) had untolerated taint {node-role.kubernetes.io/master: }.",
+				}},
+			}),
+			want: []string{FindingPodPendingTaint},
+		},
+		{
+			name: "pending without taints",
+			pod: newPluginPod("05-openshift-cluster-upgrade", v1.PodStatus{
+				Phase: v1.PodPending,
+				Conditions: []v1.PodCondition{{
+					Type:    v1.PodScheduled,
+					Status:  v1.ConditionFalse,
+					Reason:  v1.PodReasonUnschedulable,
+					Message: "0/6 nodes are available: 6 Insufficient memory.",
+				}},
+			}),
+			want: []string{},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := []string{}
+			for _, f := range checkPods([]v1.Pod{tt.pod}) {
+				got = append(got, f.ID)
+				assert.NotEmpty(t, f.Hint)
+			}
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func Test_CheckProgress(t *testing.T) {
+	start := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	status := func(completed int64) *aggregation.Status {
+		return &aggregation.Status{Status: aggregation.RunningStatus, Plugins: []aggregation.PluginStatus{
+			{Plugin: "05-openshift-cluster-upgrade", Status: aggregation.CompleteStatus, ResultStatus: "passed"},
+			{Plugin: "10-openshift-kube-conformance", Status: aggregation.RunningStatus, Progress: &plugin.ProgressUpdate{Completed: completed, Total: 400}},
+			{Plugin: "20-openshift-conformance-validated", Status: aggregation.RunningStatus},
+		}}
+	}
+
+	w := NewWatchdog(30 * time.Minute)
+	assert.Empty(t, w.checkProgress(status(10), start))
+	assert.Empty(t, w.checkProgress(status(10), start.Add(20*time.Minute)))
+	assert.Empty(t, w.checkProgress(status(20), start.Add(40*time.Minute)))
+	assert.Empty(t, w.checkProgress(status(20), start.Add(60*time.Minute)))
+
+	findings := w.checkProgress(status(20), start.Add(70*time.Minute))
+	require.Len(t, findings, 1)
+	assert.Equal(t, FindingNoProgress, findings[0].ID)
+	assert.Equal(t, "plugin 10-openshift-kube-conformance", findings[0].Object)
+
+	disabled := NewWatchdog(0)
+	assert.Empty(t, disabled.checkProgress(status(20), start))
+	assert.Empty(t, disabled.checkProgress(status(20), start.Add(70*time.Minute)))
+}
+
+func Test_WatchdogCheck(t *testing.T) {
+	start := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	cm := &v1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{Name: sonobuoyConfigMapName, Namespace: pkg.GetNamespace()},
+		Data:       map[string]string{sonobuoyConfigMapKey: `{"Server":{"timeoutseconds":3600}}`},
+	}
+	pod := newPluginPod("10-openshift-kube-conformance", v1.PodStatus{
+		Phase:             v1.PodRunning,
+		ContainerStatuses: []v1.ContainerStatus{{Name: "plugin", RestartCount: 2}},
+	})
+	kclient := fake.NewSimpleClientset(cm, &pod)
+	s := &aggregation.Status{Status: aggregation.RunningStatus}
+
+	w := NewWatchdog(DefaultNoProgressTimeout)
+	findings, err := w.Check(context.TODO(), kclient, s, start, start.Add(10*time.Minute))
+	require.NoError(t, err)
+	assert.Equal(t, time.Hour, w.AggregatorTimeout)
+	require.Len(t, findings, 1)
+	assert.Equal(t, FindingPodRestarts, findings[0].ID)
+
+	// Findings are reported once, the aggregator timeout is reported at 90%.
+	findings, err = w.Check(context.TODO(), kclient, s, start, start.Add(55*time.Minute))
+	require.NoError(t, err)
+	require.Len(t, findings, 1)
+	assert.Equal(t, FindingAggregatorTimeout, findings[0].ID)
+
+	findings, err = w.Check(context.TODO(), kclient, s, start, start.Add(58*time.Minute))
+	require.NoError(t, err)
+	assert.Empty(t, findings)
+}
@@ -3,6 +3,7 @@ package pkg
 import (
 	"fmt"
 
+	"github.com/spf13/viper"
 	"github.com/vmware-tanzu/sonobuoy/pkg/buildinfo"
 )
 
@@ -12,9 +13,11 @@ const (
 	CertificationNamespace         = "opct"
 	VersionInfoConfigMapName       = "opct-version"
 	PluginsVarsConfigMapName       = "plugins-config"
+	PluginsTestsConfigMapName      = "plugins-tests"
 	DedicatedNodeRoleLabel         = "node-role.kubernetes.io/tests"
 	DedicatedNodeRoleLabelSelector = "node-role.kubernetes.io/tests="
 	SonobuoyServiceAccountName     = "sonobuoy-serviceaccount"
+	MachineConfigPoolName          = "opct"
 	SonobuoyLabelNamespaceName     = "namespace"
 	SonobuoyLabelComponentName     = "component"
 	SonobuoyLabelComponentValue    = "sonobuoy"
@@ -29,10 +32,42 @@ var (
 	SonobuoyImage = fmt.Sprintf("sonobuoy:%s", buildinfo.Version)
 )
 
-var (
-	SonobuoyDefaultLabels = map[string]string{
+// GetNamespace returns the namespace of the validation environment, set by
+// the global flag --namespace. Concurrent environments in the same cluster
+// must use different namespaces.
+func GetNamespace() string {
+	if ns := viper.GetString("namespace"); ns != "" {
+		return ns
+	}
+	return CertificationNamespace
+}
+
+// GetPrivilegedClusterRole returns the name of the ClusterRole granted to the
+// validation environment, suffixed by the namespace when it is not the default.
+func GetPrivilegedClusterRole() string {
+	return namespacedName(PrivilegedClusterRole)
+}
+
+// GetPrivilegedClusterRoleBinding returns the name of the ClusterRoleBinding
+// granted to the validation environment, suffixed by the namespace when it is
+// not the default.
+func GetPrivilegedClusterRoleBinding() string {
+	return namespacedName(PrivilegedClusterRoleBinding)
+}
+
+func namespacedName(name string) string {
+	if ns := GetNamespace(); ns != CertificationNamespace {
+		return fmt.Sprintf("%s-%s", name, ns)
+	}
+	return name
+}
+
+// GetSonobuoyDefaultLabels returns the labels of the objects created in the
+// validation environment.
+func GetSonobuoyDefaultLabels() map[string]string {
+	return map[string]string{
 		SonobuoyLabelComponentName: SonobuoyLabelComponentValue,
-		SonobuoyLabelNamespaceName: CertificationNamespace,
+		SonobuoyLabelNamespaceName: GetNamespace(),
 		// Enforcing privileged mode for PSA on Conformance/Sonobuoy environment.
 		// https://issues.redhat.com/browse/OPCT-11
 		// https://issues.redhat.com/browse/OPCT-31
@@ -40,7 +75,7 @@ var (
 		"pod-security.kubernetes.io/audit":   "privileged",
 		"pod-security.kubernetes.io/warn":    "privileged",
 	}
-)
+}
 
 func GetSonobuoyImage() string {
 	return fmt.Sprintf("%s/%s", DefaultToolsRepository, SonobuoyImage)
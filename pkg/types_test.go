@@ -0,0 +1,51 @@
+package pkg
+
+import (
+	"testing"
+
+	"github.com/spf13/viper"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGetNamespace(t *testing.T) {
+	tests := []struct {
+		name        string
+		namespace   string
+		want        string
+		wantRBAC    string
+		wantLabelNS string
+	}{
+		{
+			name:        "default namespace",
+			namespace:   "",
+			want:        CertificationNamespace,
+			wantRBAC:    PrivilegedClusterRole,
+			wantLabelNS: CertificationNamespace,
+		},
+		{
+			name:        "flag set to the default namespace",
+			namespace:   CertificationNamespace,
+			want:        CertificationNamespace,
+			wantRBAC:    PrivilegedClusterRole,
+			wantLabelNS: CertificationNamespace,
+		},
+		{
+			name:        "custom namespace",
+			namespace:   "opct-pool-a",
+			want:        "opct-pool-a",
+			wantRBAC:    "opct-scc-privileged-opct-pool-a",
+			wantLabelNS: "opct-pool-a",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			viper.Set("namespace", tt.namespace)
+			defer viper.Set("namespace", "")
+
+			assert.Equal(t, tt.want, GetNamespace())
+			assert.Equal(t, tt.wantRBAC, GetPrivilegedClusterRole())
+			assert.Equal(t, tt.wantRBAC, GetPrivilegedClusterRoleBinding())
+			assert.Equal(t, tt.wantLabelNS, GetSonobuoyDefaultLabels()[SonobuoyLabelNamespaceName])
+		})
+	}
+}
@@ -0,0 +1,196 @@
+package upgrade
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	mcfgv1 "github.com/openshift/api/machineconfiguration/v1"
+	mcfgclientset "github.com/openshift/client-go/machineconfiguration/clientset/versioned"
+	log "github.com/sirupsen/logrus"
+	kerrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/wait"
+
+	"github.com/redhat-openshift-ecosystem/provider-certification-tool/pkg"
+)
+
+const (
+	// DefaultMachineConfigPoolWaitTimeout is the time to wait for the pool
+	// to be paused and selecting the dedicated nodes.
+	DefaultMachineConfigPoolWaitTimeout = 10 * time.Minute
+
+	machineConfigPoolWaitInterval = 10 * time.Second
+)
+
+// ManagedByLabel marks the MachineConfigPool created by the tool, the only
+// pool removed by 'opct destroy --delete-mcp'.
+const (
+	ManagedByLabel = "app.kubernetes.io/managed-by"
+	ManagedByValue = "opct"
+)
+
+// NewMachineConfigPool returns the MachineConfigPool used by the dedicated
+// node in upgrade mode. The pool is created unpaused, thus the nodes are
+// moved to the rendered configuration of the pool, and it is paused by
+// WaitForMachineConfigPool, thus the node running the validation environment
+// is not restarted while the cluster is upgrading.
+func NewMachineConfigPool() *mcfgv1.MachineConfigPool {
+	return &mcfgv1.MachineConfigPool{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:   pkg.MachineConfigPoolName,
+			Labels: map[string]string{ManagedByLabel: ManagedByValue},
+		},
+		Spec: mcfgv1.MachineConfigPoolSpec{
+			MachineConfigSelector: &metav1.LabelSelector{
+				MatchExpressions: []metav1.LabelSelectorRequirement{{
+					Key:      "machineconfiguration.openshift.io/role",
+					Operator: metav1.LabelSelectorOpIn,
+					Values:   []string{"worker", pkg.MachineConfigPoolName},
+				}},
+			},
+			NodeSelector: &metav1.LabelSelector{
+				MatchLabels: map[string]string{pkg.DedicatedNodeRoleLabel: ""},
+			},
+		},
+	}
+}
+
+// IsManaged returns true when the pool was created by the tool.
+func IsManaged(pool *mcfgv1.MachineConfigPool) bool {
+	return pool.Labels[ManagedByLabel] == ManagedByValue
+}
+
+// ValidateMachineConfigPool checks if the pool is paused and selecting the
+// dedicated nodes.
+func ValidateMachineConfigPool(pool *mcfgv1.MachineConfigPool) error {
+	if !pool.Spec.Paused {
+		return fmt.Errorf("MachineConfigPool %q is not paused, set `spec.paused=true` and try again", pool.Name)
+	}
+	if pool.Spec.NodeSelector == nil {
+		return fmt.Errorf("MachineConfigPool %q does not have the node selector %q", pool.Name, pkg.DedicatedNodeRoleLabelSelector)
+	}
+	if _, ok := pool.Spec.NodeSelector.MatchLabels[pkg.DedicatedNodeRoleLabel]; !ok {
+		return fmt.Errorf("MachineConfigPool %q does not have the node selector %q", pool.Name, pkg.DedicatedNodeRoleLabelSelector)
+	}
+	return nil
+}
+
+// EnsureMachineConfigPool creates the pool when it does not exist, returning
+// true when it was created. Existing pools are paused by WaitForMachineConfigPool.
+func EnsureMachineConfigPool(ctx context.Context, mcClient mcfgclientset.Interface) (bool, error) {
+	pools := mcClient.MachineconfigurationV1().MachineConfigPools()
+	_, err := pools.Get(ctx, pkg.MachineConfigPoolName, metav1.GetOptions{})
+	if err == nil {
+		log.Infof("MachineConfigPool %s already exists", pkg.MachineConfigPoolName)
+		return false, nil
+	}
+	if !kerrors.IsNotFound(err) {
+		return false, fmt.Errorf("error getting MachineConfigPool %q: %w", pkg.MachineConfigPoolName, err)
+	}
+	if _, err := pools.Create(ctx, NewMachineConfigPool(), metav1.CreateOptions{}); err != nil {
+		return false, fmt.Errorf("error creating MachineConfigPool %q: %w", pkg.MachineConfigPoolName, err)
+	}
+	log.Infof("Created MachineConfigPool %s", pkg.MachineConfigPoolName)
+	return true, nil
+}
+
+// Steps of the MachineConfigPool reconciliation.
+const (
+	poolStepWait    = "wait"
+	poolStepPause   = "pause"
+	poolStepUnpause = "unpause"
+	poolStepDone    = "done"
+)
+
+// machineConfigPoolStep returns the next step to reconcile the pool: the
+// pool must be observed by the controller, not degraded, selecting the
+// dedicated nodes, and the nodes updated to the rendered configuration of the
+// pool before it is paused. The controller does not update the nodes of a
+// paused pool, so the pool created by the tool is unpaused until the nodes
+// are updated. Pools created by the user are not unpaused.
+func machineConfigPoolStep(pool *mcfgv1.MachineConfigPool) (string, string) {
+	if pool.Spec.NodeSelector == nil {
+		return poolStepWait, fmt.Sprintf("pool does not have the node selector %q", pkg.DedicatedNodeRoleLabelSelector)
+	}
+	if _, ok := pool.Spec.NodeSelector.MatchLabels[pkg.DedicatedNodeRoleLabel]; !ok {
+		return poolStepWait, fmt.Sprintf("pool does not have the node selector %q", pkg.DedicatedNodeRoleLabelSelector)
+	}
+	if pool.Status.ObservedGeneration < pool.Generation {
+		return poolStepWait, "waiting for the pool to be observed by the controller"
+	}
+	for _, cond := range pool.Status.Conditions {
+		if cond.Type == mcfgv1.MachineConfigPoolDegraded && cond.Status == "True" {
+			return poolStepWait, fmt.Sprintf("pool is degraded: %s", cond.Message)
+		}
+	}
+	if pool.Status.MachineCount == 0 {
+		return poolStepWait, "waiting for the dedicated nodes to join the pool"
+	}
+	if pool.Status.UpdatedMachineCount < pool.Status.MachineCount {
+		if !pool.Spec.Paused {
+			return poolStepWait, fmt.Sprintf("waiting for the nodes to be updated (%d/%d)", pool.Status.UpdatedMachineCount, pool.Status.MachineCount)
+		}
+		if IsManaged(pool) {
+			return poolStepUnpause, "unpausing the pool to update the nodes"
+		}
+		return poolStepWait, fmt.Sprintf("the pool is paused and the nodes are not updated (%d/%d), unpause the pool to update the nodes", pool.Status.UpdatedMachineCount, pool.Status.MachineCount)
+	}
+	if !pool.Spec.Paused {
+		return poolStepPause, "pausing the pool"
+	}
+	return poolStepDone, ""
+}
+
+// WaitForMachineConfigPool waits for the nodes selected by the pool to be
+// updated, pausing the pool.
+func WaitForMachineConfigPool(ctx context.Context, mcClient mcfgclientset.Interface, timeout time.Duration) error {
+	pools := mcClient.MachineconfigurationV1().MachineConfigPools()
+	var message string
+	err := wait.PollUntilContextTimeout(ctx, machineConfigPoolWaitInterval, timeout, true, func(ctx context.Context) (bool, error) {
+		pool, err := pools.Get(ctx, pkg.MachineConfigPoolName, metav1.GetOptions{})
+		if err != nil {
+			return false, err
+		}
+		var step string
+		step, message = machineConfigPoolStep(pool)
+		switch step {
+		case poolStepDone:
+			return true, nil
+		case poolStepPause, poolStepUnpause:
+			pool.Spec.Paused = step == poolStepPause
+			if _, err := pools.Update(ctx, pool, metav1.UpdateOptions{}); err != nil {
+				return false, fmt.Errorf("error updating MachineConfigPool %q: %w", pkg.MachineConfigPoolName, err)
+			}
+		}
+		log.Infof("MachineConfigPool %s: %s", pkg.MachineConfigPoolName, message)
+		return false, nil
+	})
+	if err != nil {
+		return fmt.Errorf("MachineConfigPool %q is not ready: %s: %w", pkg.MachineConfigPoolName, message, err)
+	}
+	log.Infof("MachineConfigPool %s is paused and the dedicated nodes are updated", pkg.MachineConfigPoolName)
+	return nil
+}
+
+// DeleteMachineConfigPool removes the pool created to the upgrade mode. Pools
+// not created by the tool are kept.
+func DeleteMachineConfigPool(ctx context.Context, mcClient mcfgclientset.Interface) error {
+	pools := mcClient.MachineconfigurationV1().MachineConfigPools()
+	pool, err := pools.Get(ctx, pkg.MachineConfigPoolName, metav1.GetOptions{})
+	if kerrors.IsNotFound(err) {
+		log.Infof("MachineConfigPool %s not found, skipping", pkg.MachineConfigPoolName)
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	if !IsManaged(pool) {
+		return fmt.Errorf("MachineConfigPool %q was not created by opct (label %s=%s), delete it manually", pkg.MachineConfigPoolName, ManagedByLabel, ManagedByValue)
+	}
+	if err := pools.Delete(ctx, pkg.MachineConfigPoolName, metav1.DeleteOptions{}); err != nil {
+		return err
+	}
+	log.Infof("Deleted MachineConfigPool %s", pkg.MachineConfigPoolName)
+	return nil
+}
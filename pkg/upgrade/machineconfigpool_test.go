@@ -0,0 +1,176 @@
+package upgrade
+
+import (
+	"context"
+	"testing"
+
+	mcfgv1 "github.com/openshift/api/machineconfiguration/v1"
+	mcfgfake "github.com/openshift/client-go/machineconfiguration/clientset/versioned/fake"
+	"github.com/stretchr/testify/assert"
+	corev1 "k8s.io/api/core/v1"
+	kerrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"github.com/redhat-openshift-ecosystem/provider-certification-tool/pkg"
+)
+
+func Test_EnsureMachineConfigPool(t *testing.T) {
+	existing := NewMachineConfigPool()
+	existing.Labels = nil
+	existing.Spec.Paused = true
+
+	tests := []struct {
+		name        string
+		objects     []*mcfgv1.MachineConfigPool
+		wantCreated bool
+		wantManaged bool
+	}{{
+		name:        "create missing pool",
+		wantCreated: true,
+		wantManaged: true,
+	}, {
+		name:    "existing pool",
+		objects: []*mcfgv1.MachineConfigPool{existing},
+	}}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			mcClient := mcfgfake.NewSimpleClientset()
+			for _, obj := range test.objects {
+				_, err := mcClient.MachineconfigurationV1().MachineConfigPools().Create(context.TODO(), obj.DeepCopy(), metav1.CreateOptions{})
+				assert.NoError(t, err)
+			}
+			created, err := EnsureMachineConfigPool(context.TODO(), mcClient)
+			assert.NoError(t, err)
+			assert.Equal(t, test.wantCreated, created)
+
+			pool, err := mcClient.MachineconfigurationV1().MachineConfigPools().Get(context.TODO(), pkg.MachineConfigPoolName, metav1.GetOptions{})
+			assert.NoError(t, err)
+			assert.Equal(t, test.wantManaged, IsManaged(pool))
+		})
+	}
+}
+
+func Test_MachineConfigPoolStep(t *testing.T) {
+	// Status of the pool created paused: the nodes are never moved to the
+	// rendered configuration of the pool while it is paused.
+	createdPaused := func(p *mcfgv1.MachineConfigPool) {
+		p.Spec.Paused = true
+		p.Status = mcfgv1.MachineConfigPoolStatus{
+			ObservedGeneration:      1,
+			MachineCount:            1,
+			UpdatedMachineCount:     0,
+			ReadyMachineCount:       0,
+			UnavailableMachineCount: 0,
+			Configuration: mcfgv1.MachineConfigPoolStatusConfiguration{
+				ObjectReference: corev1.ObjectReference{Name: "rendered-opct-0123456789abcdef"},
+			},
+			Conditions: []mcfgv1.MachineConfigPoolCondition{
+				{Type: mcfgv1.MachineConfigPoolUpdated, Status: "False"},
+				{Type: mcfgv1.MachineConfigPoolUpdating, Status: "True"},
+				{Type: mcfgv1.MachineConfigPoolDegraded, Status: "False"},
+			},
+		}
+	}
+	tests := []struct {
+		name   string
+		mutate func(p *mcfgv1.MachineConfigPool)
+		want   string
+	}{{
+		name:   "reconciled",
+		mutate: func(p *mcfgv1.MachineConfigPool) { p.Spec.Paused = true },
+		want:   poolStepDone,
+	}, {
+		name: "nodes updated",
+		want: poolStepPause,
+	}, {
+		name:   "nodes updating",
+		mutate: func(p *mcfgv1.MachineConfigPool) { p.Status.UpdatedMachineCount = 0 },
+		want:   poolStepWait,
+	}, {
+		name:   "created paused",
+		mutate: createdPaused,
+		want:   poolStepUnpause,
+	}, {
+		name: "created paused by the user",
+		mutate: func(p *mcfgv1.MachineConfigPool) {
+			createdPaused(p)
+			p.Labels = nil
+		},
+		want: poolStepWait,
+	}, {
+		name:   "not observed",
+		mutate: func(p *mcfgv1.MachineConfigPool) { p.Generation = 2 },
+		want:   poolStepWait,
+	}, {
+		name:   "without nodes",
+		mutate: func(p *mcfgv1.MachineConfigPool) { p.Status.MachineCount = 0 },
+		want:   poolStepWait,
+	}, {
+		name:   "without node selector",
+		mutate: func(p *mcfgv1.MachineConfigPool) { p.Spec.NodeSelector = nil },
+		want:   poolStepWait,
+	}, {
+		name: "degraded",
+		mutate: func(p *mcfgv1.MachineConfigPool) {
+			p.Status.Conditions = []mcfgv1.MachineConfigPoolCondition{{
+				Type:   mcfgv1.MachineConfigPoolDegraded,
+				Status: "True",
+			}}
+		},
+		want: poolStepWait,
+	}}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			pool := NewMachineConfigPool()
+			pool.Generation = 1
+			pool.Status.ObservedGeneration = 1
+			pool.Status.MachineCount = 1
+			pool.Status.UpdatedMachineCount = 1
+			if test.mutate != nil {
+				test.mutate(pool)
+			}
+			got, _ := machineConfigPoolStep(pool)
+			assert.Equal(t, test.want, got)
+		})
+	}
+}
+
+func Test_DeleteMachineConfigPool(t *testing.T) {
+	userPool := NewMachineConfigPool()
+	userPool.Labels = nil
+
+	tests := []struct {
+		name        string
+		objects     []*mcfgv1.MachineConfigPool
+		wantErr     bool
+		wantDeleted bool
+	}{{
+		name:        "created by opct",
+		objects:     []*mcfgv1.MachineConfigPool{NewMachineConfigPool()},
+		wantDeleted: true,
+	}, {
+		name:    "created by the user",
+		objects: []*mcfgv1.MachineConfigPool{userPool},
+		wantErr: true,
+	}, {
+		name:        "not found",
+		wantDeleted: true,
+	}}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			mcClient := mcfgfake.NewSimpleClientset()
+			for _, obj := range test.objects {
+				_, err := mcClient.MachineconfigurationV1().MachineConfigPools().Create(context.TODO(), obj.DeepCopy(), metav1.CreateOptions{})
+				assert.NoError(t, err)
+			}
+			err := DeleteMachineConfigPool(context.TODO(), mcClient)
+			assert.Equal(t, test.wantErr, err != nil, "error: %v", err)
+
+			_, err = mcClient.MachineconfigurationV1().MachineConfigPools().Get(context.TODO(), pkg.MachineConfigPoolName, metav1.GetOptions{})
+			assert.Equal(t, test.wantDeleted, kerrors.IsNotFound(err))
+		})
+	}
+}
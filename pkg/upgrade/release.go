@@ -0,0 +1,130 @@
+package upgrade
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+
+	configv1 "github.com/openshift/api/config/v1"
+	"k8s.io/apimachinery/pkg/util/version"
+)
+
+// releaseImageRegex matches release images pinned by digest, the format
+// used by the Cluster Version Operator.
+var releaseImageRegex = regexp.MustCompile(`^[^@\s]+@(sha256:[a-f0-9]{64})$`)
+
+// UpgradeTarget is the release selected to the upgrade, resolved from the
+// update edges of the cluster.
+type UpgradeTarget struct {
+	Release configv1.Release
+
+	// Conditional is set when the release is a conditional update.
+	Conditional bool
+
+	// Risks are the risks, reported by the update service, that apply to
+	// a conditional update not recommended to the cluster.
+	Risks []string
+
+	// OutsideGraph is set when the release is not an update edge offered to
+	// the cluster, only the image of the release is known.
+	OutsideGraph bool
+}
+
+// ValidateReleaseImage checks if the release image is pinned by digest,
+// returning the digest.
+func ValidateReleaseImage(image string) (string, error) {
+	match := releaseImageRegex.FindStringSubmatch(image)
+	if match == nil {
+		return "", fmt.Errorf("invalid release image %q: the image must be pinned by digest (<repository>@sha256:<digest>), "+
+			"get the image with 'oc adm release info <version> -o jsonpath={.image}'", image)
+	}
+	return match[1], nil
+}
+
+// ValidateUpgradeTarget checks if the release image is a valid upgrade for
+// the cluster: the release offered to the cluster in the available or
+// conditional updates must be newer than the current version. The release is
+// matched by digest, thus mirrored images are supported. Releases outside the
+// update graph are allowed by --upgrade-to-image, they are reported as not
+// recommended.
+func ValidateUpgradeTarget(cv *configv1.ClusterVersion, image string) (*UpgradeTarget, error) {
+	digest, err := ValidateReleaseImage(image)
+	if err != nil {
+		return nil, err
+	}
+
+	var target *UpgradeTarget
+	for _, release := range cv.Status.AvailableUpdates {
+		if strings.HasSuffix(release.Image, "@"+digest) {
+			target = &UpgradeTarget{Release: release}
+			break
+		}
+	}
+	if target == nil {
+		for _, update := range cv.Status.ConditionalUpdates {
+			if !strings.HasSuffix(update.Release.Image, "@"+digest) {
+				continue
+			}
+			target = &UpgradeTarget{Release: update.Release, Conditional: true}
+			for _, cond := range update.Conditions {
+				if cond.Type == "Recommended" && cond.Status != "True" {
+					for _, risk := range update.Risks {
+						target.Risks = append(target.Risks, fmt.Sprintf("%s: %s (%s)", risk.Name, risk.Message, risk.URL))
+					}
+				}
+			}
+			break
+		}
+	}
+
+	current := CurrentVersion(cv)
+	if target == nil {
+		target = &UpgradeTarget{Release: configv1.Release{Image: image}, OutsideGraph: true}
+		available := availableVersions(cv)
+		if len(available) == 0 {
+			target.Risks = append(target.Risks, fmt.Sprintf("the release is not an update edge of the cluster version %s, the cluster does not offer updates, check the channel with 'oc adm upgrade'", current))
+		} else {
+			target.Risks = append(target.Risks, fmt.Sprintf("the release is not an update edge of the cluster version %s, available updates: %s", current, strings.Join(available, ", ")))
+		}
+		return target, nil
+	}
+
+	currentVersion, err := version.ParseSemantic(current)
+	if err != nil {
+		return nil, fmt.Errorf("unable to parse the cluster version %q: %w", current, err)
+	}
+	targetVersion, err := version.ParseSemantic(target.Release.Version)
+	if err != nil {
+		return nil, fmt.Errorf("unable to parse the target version %q: %w", target.Release.Version, err)
+	}
+	if !currentVersion.LessThan(targetVersion) {
+		return nil, fmt.Errorf("target version %s must be newer than the cluster version %s", target.Release.Version, current)
+	}
+	return target, nil
+}
+
+// CurrentVersion returns the version the cluster runs, the latest completed
+// update in the history. The desired version is the target while an update
+// is in progress, it is used only when no update was completed.
+func CurrentVersion(cv *configv1.ClusterVersion) string {
+	for _, h := range cv.Status.History {
+		if h.State == configv1.CompletedUpdate {
+			return h.Version
+		}
+	}
+	return cv.Status.Desired.Version
+}
+
+// availableVersions returns the sorted versions offered to the cluster.
+func availableVersions(cv *configv1.ClusterVersion) []string {
+	versions := []string{}
+	for _, release := range cv.Status.AvailableUpdates {
+		versions = append(versions, release.Version)
+	}
+	for _, update := range cv.Status.ConditionalUpdates {
+		versions = append(versions, update.Release.Version+" (conditional)")
+	}
+	sort.Strings(versions)
+	return versions
+}
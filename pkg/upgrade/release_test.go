@@ -0,0 +1,148 @@
+package upgrade
+
+import (
+	"testing"
+
+	configv1 "github.com/openshift/api/config/v1"
+	"github.com/stretchr/testify/assert"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+const (
+	digestA = "sha256:aaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaa"
+	digestB = "sha256:bbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbb"
+	digestC = "sha256:cccccccccccccccccccccccccccccccccccccccccccccccccccccccccccccccc"
+	digestD = "sha256:dddddddddddddddddddddddddddddddddddddddddddddddddddddddddddddddd"
+)
+
+func Test_ValidateReleaseImage(t *testing.T) {
+	tests := []struct {
+		name    string
+		image   string
+		wantErr bool
+	}{{
+		name:  "pinned by digest",
+		image: "quay.io/openshift-release-dev/ocp-release@" + digestA,
+	}, {
+		name:    "tag",
+		image:   "quay.io/openshift-release-dev/ocp-release:4.15.2-x86_64",
+		wantErr: true,
+	}, {
+		name:    "short digest",
+		image:   "quay.io/openshift-release-dev/ocp-release@sha256:abc",
+		wantErr: true,
+	}, {
+		name:    "missing repository",
+		image:   "@" + digestA,
+		wantErr: true,
+	}}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			_, err := ValidateReleaseImage(test.image)
+			assert.Equal(t, test.wantErr, err != nil, "error: %v", err)
+		})
+	}
+}
+
+func Test_ValidateUpgradeTarget(t *testing.T) {
+	cv := &configv1.ClusterVersion{
+		Status: configv1.ClusterVersionStatus{
+			// Update to 4.15.3 in progress.
+			Desired: configv1.Release{Version: "4.15.3"},
+			History: []configv1.UpdateHistory{
+				{State: configv1.PartialUpdate, Version: "4.15.3"},
+				{State: configv1.CompletedUpdate, Version: "4.15.1"},
+				{State: configv1.CompletedUpdate, Version: "4.15.0"},
+			},
+			AvailableUpdates: []configv1.Release{{
+				Version: "4.15.2",
+				Image:   "quay.io/openshift-release-dev/ocp-release@" + digestA,
+			}, {
+				Version: "4.15.0",
+				Image:   "quay.io/openshift-release-dev/ocp-release@" + digestC,
+			}},
+			ConditionalUpdates: []configv1.ConditionalUpdate{{
+				Release: configv1.Release{
+					Version: "4.16.0",
+					Image:   "quay.io/openshift-release-dev/ocp-release@" + digestB,
+				},
+				Risks: []configv1.ConditionalUpdateRisk{{
+					Name:    "SomeRisk",
+					Message: "risk message",
+					URL:     "https://example.com",
+				}},
+				Conditions: []metav1.Condition{{
+					Type:   "Recommended",
+					Status: metav1.ConditionFalse,
+				}},
+			}},
+		},
+	}
+
+	tests := []struct {
+		name            string
+		image           string
+		wantVersion     string
+		wantConditional bool
+		wantRisks       int
+		wantOutside     bool
+		wantErr         bool
+	}{{
+		name:        "available update",
+		image:       "quay.io/openshift-release-dev/ocp-release@" + digestA,
+		wantVersion: "4.15.2",
+	}, {
+		name:        "mirrored available update",
+		image:       "mirror.example.com/ocp/release@" + digestA,
+		wantVersion: "4.15.2",
+	}, {
+		name:            "conditional update",
+		image:           "quay.io/openshift-release-dev/ocp-release@" + digestB,
+		wantVersion:     "4.16.0",
+		wantConditional: true,
+		wantRisks:       1,
+	}, {
+		name:    "older release",
+		image:   "quay.io/openshift-release-dev/ocp-release@" + digestC,
+		wantErr: true,
+	}, {
+		name:        "release not offered",
+		image:       "quay.io/openshift-release-dev/ocp-release@" + digestD,
+		wantRisks:   1,
+		wantOutside: true,
+	}, {
+		name:    "tag",
+		image:   "quay.io/openshift-release-dev/ocp-release:4.15.2-x86_64",
+		wantErr: true,
+	}}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			target, err := ValidateUpgradeTarget(cv, test.image)
+			if test.wantErr {
+				assert.Error(t, err)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, test.wantVersion, target.Release.Version)
+			assert.Equal(t, test.wantConditional, target.Conditional)
+			assert.Len(t, target.Risks, test.wantRisks)
+			assert.Equal(t, test.wantOutside, target.OutsideGraph)
+		})
+	}
+}
+
+func Test_CurrentVersion(t *testing.T) {
+	cv := &configv1.ClusterVersion{Status: configv1.ClusterVersionStatus{
+		Desired: configv1.Release{Version: "4.15.2"},
+		History: []configv1.UpdateHistory{
+			{State: configv1.PartialUpdate, Version: "4.15.2"},
+			{State: configv1.CompletedUpdate, Version: "4.15.1"},
+		},
+	}}
+	assert.Equal(t, "4.15.1", CurrentVersion(cv), "the desired version is the target of the update in progress")
+
+	cv.Status.History = nil
+	assert.Equal(t, "4.15.2", CurrentVersion(cv))
+}
@@ -24,7 +24,7 @@ func WaitForRequiredResources(kclient kubernetes.Interface) error {
 
 	restClient := kclient.CoreV1().RESTClient()
 
-	lw := cache.NewFilteredListWatchFromClient(restClient, "pods", pkg.CertificationNamespace, func(options *metav1.ListOptions) {
+	lw := cache.NewFilteredListWatchFromClient(restClient, "pods", pkg.GetNamespace(), func(options *metav1.ListOptions) {
 		options.LabelSelector = "component=sonobuoy,sonobuoy-component=aggregator"
 	})
 